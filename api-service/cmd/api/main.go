@@ -2,20 +2,16 @@ package main
 
 import (
 	"context"
-	"net/http"
-	"os"
+	"database/sql"
 	"os/signal"
 	"syscall"
-	"time"
 
-	"github.com/go-chi/chi/v5"
 	"github.com/joho/godotenv"
+	_ "github.com/lib/pq"
 	"go.uber.org/zap"
 
-	"github.com/Bharat1Rajput/apiService/internal/broker"
-	"github.com/Bharat1Rajput/apiService/internal/config"
-	"github.com/Bharat1Rajput/apiService/internal/handler"
-	"github.com/Bharat1Rajput/apiService/internal/middleware"
+	"github.com/Bharat1Rajput/apiService/server"
+	"github.com/Bharat1Rajput/broker"
 )
 
 func main() {
@@ -33,67 +29,40 @@ func run() error {
 	}
 	defer logger.Sync()
 
-	cfg, err := config.Load()
+	cfg, err := server.LoadConfig()
 	if err != nil {
 		logger.Fatal("failed to load config", zap.Error(err))
 	}
+	if cfg.Broker == broker.BackendMemory {
+		logger.Fatal("BROKER=memory only works in one process, run dispatchgo instead")
+	}
 
-	pub, err := broker.NewRabbitPublisher(
-		cfg.RabbitURL,
-		cfg.RabbitExchange,
-		cfg.RabbitQueue,
-		cfg.RabbitRoutingKey,
-		logger, 
-	)
+	pub, err := broker.NewPublisher(context.Background(), cfg.BrokerConfig(), logger)
 	if err != nil {
-		logger.Fatal("failed to create rabbit publisher", zap.Error(err))
+		logger.Fatal("failed to create publisher", zap.Error(err), zap.String("broker", cfg.Broker))
 	}
 	defer pub.Close()
 
-	r := chi.NewRouter()
-	r.Use(middleware.RequestLogger(logger))
-	r.Group(func(r chi.Router) {
-    r.Get("/health", handler.HealthHandler)
-})
-	r.Group(func(r chi.Router) {
-		r.Use(middleware.HMACAuth(cfg.HMACSecret, logger))
-		r.Mount("/", handler.NewWebhookHandler(cfg, pub, logger).Routes())
-	})
-
-	addr := ":" + cfg.AppPort
-	srv := &http.Server{
-		Addr:    addr,
-		Handler: r,
-	} 
-
-	errCh := make(chan error, 1)
-
-	go func() {
-		logger.Info("api-service starting", zap.String("addr", addr))
-		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			errCh <- err
+	var store *server.Storage
+	if cfg.DatabaseURL != "" {
+		db, err := sql.Open("postgres", cfg.DatabaseURL)
+		if err != nil {
+			logger.Fatal("failed to connect to database", zap.Error(err))
 		}
-	}()
-
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-
-	select {
-	case sig := <-quit:
-		logger.Info("shutdown signal received", zap.String("signal", sig.String()))
-	case err := <-errCh:
-		return err
+		if err := db.Ping(); err != nil {
+			logger.Fatal("database ping failed", zap.Error(err))
+		}
+		defer db.Close()
+		store = server.PostgresStorage(db, cfg.DatabaseURL)
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.ShutdownTimeoutSec)*time.Second)
-	defer cancel()
-
-	if err := srv.Shutdown(ctx); err != nil {
-		logger.Error("server shutdown error", zap.Error(err))
-		return err
+	srv, err := server.New(cfg, pub, store, logger)
+	if err != nil {
+		logger.Fatal("failed to build api", zap.Error(err))
 	}
 
-	logger.Info("api-service stopped gracefully")
-	return nil
-}
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
 
+	return srv.Run(ctx)
+}
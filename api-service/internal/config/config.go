@@ -4,39 +4,90 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
+
+	"github.com/Bharat1Rajput/broker"
 )
 
 type Config struct {
-	AppPort           string
-	HMACSecret        string
-	RabbitURL         string
-	RabbitExchange    string
-	RabbitQueue       string
-	RabbitRoutingKey  string
-	ShutdownTimeoutSec int
+	AppPort             string
+	HMACSecret          string
+	Broker              string
+	RabbitURL           string
+	DatabaseURL         string
+	RabbitExchange      string
+	RabbitQueue         string
+	RabbitRoutingKey    string
+	RabbitMaxPriority   int
+	NATSURL             string
+	NATSStream          string
+	NATSSubject         string
+	RedisURL            string
+	RedisStream         string
+	ShutdownTimeoutSec  int
+	SSRFDenyCIDRs       []string
+	SSRFAllowCIDRs      []string
+	BlobStore           string
+	BlobLocalDir        string
+	PayloadOffloadBytes int
+	AdminUser           string
+	AdminPassword       string
 }
 
 func Load() (*Config, error) {
 	cfg := &Config{
-		AppPort:           getEnv("APP_PORT", "8080"),
-		HMACSecret:        os.Getenv("HMAC_SECRET"),
-		RabbitURL:         os.Getenv("RABBITMQ_URL"),
-		RabbitExchange:    getEnv("RABBITMQ_EXCHANGE", "webhooks"),
-		RabbitQueue:       getEnv("RABBITMQ_QUEUE", "webhook.jobs"),
-		RabbitRoutingKey:  getEnv("RABBITMQ_ROUTING_KEY", "webhook.jobs"),
-		ShutdownTimeoutSec: getEnvInt("SHUTDOWN_TIMEOUT_SEC", 15),
+		AppPort:             getEnv("APP_PORT", "8080"),
+		HMACSecret:          os.Getenv("HMAC_SECRET"),
+		Broker:              getEnv("BROKER", broker.BackendRabbitMQ),
+		RabbitURL:           os.Getenv("RABBITMQ_URL"),
+		DatabaseURL:         os.Getenv("DATABASE_URL"),
+		RabbitExchange:      getEnv("RABBITMQ_EXCHANGE", "webhooks"),
+		RabbitQueue:         getEnv("RABBITMQ_QUEUE", "webhook.jobs"),
+		RabbitRoutingKey:    getEnv("RABBITMQ_ROUTING_KEY", "webhook.jobs"),
+		RabbitMaxPriority:   getEnvInt("RABBITMQ_MAX_PRIORITY", 0),
+		NATSURL:             os.Getenv("NATS_URL"),
+		NATSStream:          getEnv("NATS_STREAM", "WEBHOOKS"),
+		NATSSubject:         getEnv("NATS_SUBJECT", "webhook.jobs"),
+		RedisURL:            os.Getenv("REDIS_URL"),
+		RedisStream:         getEnv("REDIS_STREAM", "webhook.jobs"),
+		ShutdownTimeoutSec:  getEnvInt("SHUTDOWN_TIMEOUT_SEC", 15),
+		SSRFDenyCIDRs:       getEnvList("SSRF_DENY_CIDRS"),
+		SSRFAllowCIDRs:      getEnvList("SSRF_ALLOW_CIDRS"),
+		BlobStore:           os.Getenv("BLOB_STORE"),
+		BlobLocalDir:        os.Getenv("BLOB_LOCAL_DIR"),
+		PayloadOffloadBytes: getEnvInt("PAYLOAD_OFFLOAD_BYTES", 256*1024),
+		AdminUser:           os.Getenv("ADMIN_USER"),
+		AdminPassword:       os.Getenv("ADMIN_PASSWORD"),
 	}
 
 	if cfg.HMACSecret == "" {
 		return nil, fmt.Errorf("config: HMAC_SECRET is required")
 	}
-	if cfg.RabbitURL == "" {
-		return nil, fmt.Errorf("config: RABBITMQ_URL is required")
+	if err := cfg.BrokerConfig().Validate(); err != nil {
+		return nil, err
 	}
 
 	return cfg, nil
 }
 
+// BrokerConfig returns the settings of the job queue.
+func (c *Config) BrokerConfig() broker.Config {
+	return broker.Config{
+		Backend:           c.Broker,
+		RabbitURL:         c.RabbitURL,
+		RabbitExchange:    c.RabbitExchange,
+		RabbitQueue:       c.RabbitQueue,
+		RabbitRoutingKey:  c.RabbitRoutingKey,
+		RabbitMaxPriority: c.RabbitMaxPriority,
+		NATSURL:           c.NATSURL,
+		NATSStream:        c.NATSStream,
+		NATSSubject:       c.NATSSubject,
+		RedisURL:          c.RedisURL,
+		RedisStream:       c.RedisStream,
+		PostgresURL:       c.DatabaseURL,
+	}
+}
+
 func getEnv(key, fallback string) string {
 	if v := os.Getenv(key); v != "" {
 		return v
@@ -53,3 +104,10 @@ func getEnvInt(key string, fallback int) int {
 	return fallback
 }
 
+func getEnvList(key string) []string {
+	v := os.Getenv(key)
+	if v == "" {
+		return nil
+	}
+	return strings.Split(v, ",")
+}
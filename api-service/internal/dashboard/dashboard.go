@@ -0,0 +1,204 @@
+// Package dashboard serves the read-mostly support UI: recent jobs with
+// filters, a job page with its attempt history, and a retry action.
+package dashboard
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"encoding/json"
+	"errors"
+	"html/template"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+
+	"github.com/Bharat1Rajput/apiService/internal/model"
+	"github.com/Bharat1Rajput/apiService/internal/repository"
+	"github.com/Bharat1Rajput/broker"
+	"github.com/Bharat1Rajput/contract"
+)
+
+// Prefix is the path the dashboard is mounted at.
+const Prefix = "/dashboard"
+
+// maxPayloadPreview bounds how much of a payload the job page renders.
+const maxPayloadPreview = 16 << 10
+
+//go:embed templates/*.html
+var templateFS embed.FS
+
+var statuses = []model.WebhookStatus{
+	model.StatusPending,
+	model.StatusProcessing,
+	model.StatusSuccess,
+	model.StatusFailed,
+	model.StatusExpired,
+	model.StatusCancelled,
+}
+
+type Handler struct {
+	jobs      repository.JobReader
+	retrier   repository.JobRetrier
+	publisher broker.Publisher
+	logger    *zap.Logger
+	pages     map[string]*template.Template
+}
+
+func NewHandler(jobs repository.JobReader, retrier repository.JobRetrier, pub broker.Publisher, logger *zap.Logger) (*Handler, error) {
+	funcs := template.FuncMap{"prefix": func() string { return Prefix }}
+	pages := make(map[string]*template.Template)
+	for _, name := range []string{"jobs", "job"} {
+		t, err := template.New(name).Funcs(funcs).ParseFS(templateFS, "templates/layout.html", "templates/"+name+".html")
+		if err != nil {
+			return nil, err
+		}
+		pages[name] = t
+	}
+
+	return &Handler{
+		jobs:      jobs,
+		retrier:   retrier,
+		publisher: pub,
+		logger:    logger,
+		pages:     pages,
+	}, nil
+}
+
+func (h *Handler) Routes() chi.Router {
+	r := chi.NewRouter()
+	r.Get("/", h.handleJobs)
+	r.Get("/jobs/{id}", h.handleJob)
+	r.Post("/jobs/{id}/retry", h.handleRetry)
+	return r
+}
+
+func (h *Handler) handleJobs(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	filter := repository.JobFilter{
+		Status:   model.WebhookStatus(q.Get("status")),
+		TenantID: q.Get("tenant"),
+		Host:     q.Get("host"),
+		Limit:    100,
+	}
+
+	jobs, err := h.jobs.ListJobs(r.Context(), filter)
+	if err != nil {
+		h.logger.Error("dashboard: list jobs", zap.Error(err))
+		http.Error(w, "failed to load jobs", http.StatusInternalServerError)
+		return
+	}
+
+	h.render(w, "jobs", map[string]any{
+		"Jobs":     jobs,
+		"Filter":   filter,
+		"Statuses": statuses,
+	})
+}
+
+func (h *Handler) handleJob(w http.ResponseWriter, r *http.Request) {
+	h.renderJob(w, r, chi.URLParam(r, "id"), "")
+}
+
+func (h *Handler) renderJob(w http.ResponseWriter, r *http.Request, id, errMsg string) {
+	job, err := h.jobs.GetJob(r.Context(), id)
+	if errors.Is(err, repository.ErrNotFound) {
+		http.Error(w, "job not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		h.logger.Error("dashboard: get job", zap.Error(err))
+		http.Error(w, "failed to load job", http.StatusInternalServerError)
+		return
+	}
+	attempts, err := h.jobs.ListAttempts(r.Context(), id)
+	if err != nil {
+		h.logger.Error("dashboard: list attempts", zap.Error(err))
+		http.Error(w, "failed to load attempts", http.StatusInternalServerError)
+		return
+	}
+
+	h.render(w, "job", map[string]any{
+		"Job":       job,
+		"Attempts":  attempts,
+		"Payload":   payloadPreview(job),
+		"Retryable": retryable(job.Status) && job.ClientURL != "",
+		"Retried":   r.URL.Query().Has("retried"),
+		"Error":     errMsg,
+	})
+}
+
+// handleRetry resets the job and publishes it again, then redirects back to
+// the job page (post/redirect/get).
+func (h *Handler) handleRetry(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	_, err := h.retrier.ResetForRetry(r.Context(), id, func(job *model.WebhookJob) error {
+		attempts, err := h.jobs.ListAttempts(r.Context(), id)
+		if err != nil {
+			return err
+		}
+		body, err := contract.Encode(job.Message(len(attempts)))
+		if err != nil {
+			return err
+		}
+		ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+		defer cancel()
+		return h.publisher.Publish(ctx, body, uint8(job.Priority))
+	})
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		http.Error(w, "job not found", http.StatusNotFound)
+		return
+	case errors.Is(err, repository.ErrNotRetryable):
+		h.renderJob(w, r, id, "This job is pending, being processed or has already been delivered, so it cannot be retried.")
+		return
+	case err != nil:
+		h.logger.Error("dashboard: retry job", zap.Error(err))
+		h.renderJob(w, r, id, "The job could not be queued and was left as it was. Please try again.")
+		return
+	}
+
+	h.logger.Info("dashboard: job retried", zap.String("job_id", id))
+	http.Redirect(w, r, Prefix+"/jobs/"+id+"?retried=1", http.StatusSeeOther)
+}
+
+func (h *Handler) render(w http.ResponseWriter, page string, data any) {
+	var buf bytes.Buffer
+	if err := h.pages[page].ExecuteTemplate(&buf, "layout", data); err != nil {
+		h.logger.Error("dashboard: render", zap.String("page", page), zap.Error(err))
+		http.Error(w, "failed to render page", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = buf.WriteTo(w)
+}
+
+func retryable(s model.WebhookStatus) bool {
+	switch s {
+	case model.StatusFailed, model.StatusExpired, model.StatusCancelled:
+		return true
+	}
+	return false
+}
+
+// payloadPreview renders JSON payloads indented and other payloads as text,
+// truncated to maxPayloadPreview.
+func payloadPreview(job *model.WebhookJob) string {
+	if job.PayloadRef != nil {
+		return "(stored in blob store: " + job.PayloadRef.Key + ")"
+	}
+	body := job.Body
+	if model.IsJSONContentType(job.ContentType) || job.ContentType == "" {
+		var buf bytes.Buffer
+		if json.Indent(&buf, body, "", "  ") == nil {
+			body = buf.Bytes()
+		}
+	}
+	if len(body) > maxPayloadPreview {
+		return string(body[:maxPayloadPreview]) + "\n… (truncated)"
+	}
+	return string(body)
+}
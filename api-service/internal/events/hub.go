@@ -0,0 +1,140 @@
+// Package events fans job status transitions, published by a Postgres
+// trigger on the webhook_job_events channel, out to in-process subscribers.
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+
+	"github.com/Bharat1Rajput/apiService/internal/model"
+)
+
+// Channel is the NOTIFY channel written by worker-service migration 0013.
+const Channel = "webhook_job_events"
+
+// subscriberBuffer is how many events a subscriber may fall behind before
+// it is dropped; its stream then ends and the client reconnects.
+const subscriberBuffer = 64
+
+// Event is one status transition of a job.
+type Event struct {
+	JobID          string              `json:"job_id"`
+	TenantID       string              `json:"tenant_id,omitempty"`
+	Status         model.WebhookStatus `json:"status"`
+	RetryCount     int                 `json:"retry_count"`
+	Classification string              `json:"classification,omitempty"`
+	Error          string              `json:"error,omitempty"`
+	UpdatedAt      time.Time           `json:"updated_at"`
+}
+
+// Terminal reports whether no further transitions are expected.
+func (e Event) Terminal() bool {
+	switch e.Status {
+	case model.StatusSuccess, model.StatusFailed, model.StatusExpired, model.StatusCancelled:
+		return true
+	}
+	return false
+}
+
+type Subscription struct {
+	C     <-chan Event
+	c     chan Event
+	match func(Event) bool
+	hub   *Hub
+}
+
+// Close unsubscribes. It is safe to call more than once.
+func (s *Subscription) Close() {
+	s.hub.remove(s)
+}
+
+type Hub struct {
+	mu     sync.Mutex
+	subs   map[*Subscription]struct{}
+	logger *zap.Logger
+}
+
+func NewHub(logger *zap.Logger) *Hub {
+	return &Hub{subs: make(map[*Subscription]struct{}), logger: logger}
+}
+
+// Subscribe registers for events accepted by match (all events if nil).
+func (h *Hub) Subscribe(match func(Event) bool) *Subscription {
+	c := make(chan Event, subscriberBuffer)
+	s := &Subscription{C: c, c: c, match: match, hub: h}
+
+	h.mu.Lock()
+	h.subs[s] = struct{}{}
+	h.mu.Unlock()
+	return s
+}
+
+func (h *Hub) remove(s *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.subs[s]; ok {
+		delete(h.subs, s)
+		close(s.c)
+	}
+}
+
+func (h *Hub) publish(e Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for s := range h.subs {
+		if s.match != nil && !s.match(e) {
+			continue
+		}
+		select {
+		case s.c <- e:
+		default:
+			h.logger.Warn("events: dropping slow subscriber")
+			delete(h.subs, s)
+			close(s.c)
+		}
+	}
+}
+
+// Listen receives notifications on Channel until ctx is canceled. pq's
+// listener reconnects on its own; transitions that happen while it is
+// disconnected are not replayed.
+func (h *Hub) Listen(ctx context.Context, dsn string) error {
+	l := pq.NewListener(dsn, time.Second, time.Minute, func(ev pq.ListenerEventType, err error) {
+		if err != nil {
+			h.logger.Warn("events: listener", zap.Error(err))
+		}
+	})
+	defer l.Close()
+
+	if err := l.Listen(Channel); err != nil {
+		return err
+	}
+
+	ping := time.NewTicker(90 * time.Second)
+	defer ping.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case n := <-l.Notify:
+			if n == nil {
+				// Reconnected; anything sent meanwhile is lost.
+				continue
+			}
+			var e Event
+			if err := json.Unmarshal([]byte(n.Extra), &e); err != nil {
+				h.logger.Warn("events: decode notification", zap.Error(err))
+				continue
+			}
+			h.publish(e)
+		case <-ping.C:
+			go func() { _ = l.Ping() }()
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+
+	"github.com/Bharat1Rajput/apiService/internal/events"
+	"github.com/Bharat1Rajput/apiService/internal/model"
+	"github.com/Bharat1Rajput/apiService/internal/repository"
+)
+
+// heartbeatInterval keeps idle streams alive through proxies.
+const heartbeatInterval = 15 * time.Second
+
+// EventHandler streams job status transitions as Server-Sent Events.
+type EventHandler struct {
+	hub    *events.Hub
+	jobs   repository.JobReader
+	logger *zap.Logger
+
+	stopOnce sync.Once
+	stopped  chan struct{}
+}
+
+func NewEventHandler(hub *events.Hub, jobs repository.JobReader, logger *zap.Logger) *EventHandler {
+	return &EventHandler{hub: hub, jobs: jobs, logger: logger, stopped: make(chan struct{})}
+}
+
+// Stop ends every open stream, which http.Server.Shutdown would otherwise
+// wait on until its timeout. Other requests are left to finish.
+func (h *EventHandler) Stop() {
+	h.stopOnce.Do(func() { close(h.stopped) })
+}
+
+// HandleStream serves GET /webhooks/stream: every transition, optionally
+// narrowed by ?tenant_id= and one or more ?job_id=.
+func (h *EventHandler) HandleStream(w http.ResponseWriter, r *http.Request) {
+	tenant := r.URL.Query().Get("tenant_id")
+	ids := make(map[string]bool)
+	for _, id := range r.URL.Query()["job_id"] {
+		ids[id] = true
+	}
+
+	sub := h.hub.Subscribe(func(e events.Event) bool {
+		return (tenant == "" || e.TenantID == tenant) && (len(ids) == 0 || ids[e.JobID])
+	})
+	defer sub.Close()
+
+	h.stream(w, r, sub, nil)
+}
+
+// HandleJobEvents serves GET /webhooks/{id}/events: the job's current state,
+// then each transition until it reaches a terminal status.
+func (h *EventHandler) HandleJobEvents(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	// Subscribe before reading the current state so no transition falls
+	// between the two.
+	sub := h.hub.Subscribe(func(e events.Event) bool { return e.JobID == id })
+	defer sub.Close()
+
+	var initial *events.Event
+	job, err := h.jobs.GetJob(r.Context(), id)
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		// Not picked up by a worker yet; its first event will follow.
+		initial = &events.Event{JobID: id, Status: model.StatusPending}
+	case err != nil:
+		h.logger.Error("handler.events: get job", zap.Error(err))
+		http.Error(w, "failed to load job", http.StatusInternalServerError)
+		return
+	default:
+		initial = &events.Event{
+			JobID:          job.ID,
+			TenantID:       job.TenantID,
+			Status:         job.Status,
+			RetryCount:     job.RetryCount,
+			Classification: string(job.Classification),
+			Error:          job.Error,
+			UpdatedAt:      job.UpdatedAt,
+		}
+	}
+
+	h.stream(w, r, sub, initial)
+}
+
+// stream writes events until the client goes away, the subscription is
+// dropped, the handler is stopped, or — when following a single job — a
+// terminal event is sent.
+func (h *EventHandler) stream(w http.ResponseWriter, r *http.Request, sub *events.Subscription, initial *events.Event) {
+	rc := http.NewResponseController(w)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	if err := rc.Flush(); err != nil {
+		h.logger.Error("handler.events: streaming unsupported", zap.Error(err))
+		return
+	}
+
+	follow := initial != nil
+	send := func(e events.Event) bool {
+		data, err := json.Marshal(e)
+		if err != nil {
+			return false
+		}
+		if _, err := fmt.Fprintf(w, "event: status\ndata: %s\n\n", data); err != nil {
+			return false
+		}
+		return rc.Flush() == nil && !(follow && e.Terminal())
+	}
+
+	if initial != nil && !send(*initial) {
+		return
+	}
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-h.stopped:
+			return
+		case e, ok := <-sub.C:
+			if !ok || !send(e) {
+				return
+			}
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil || rc.Flush() != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package handler
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Bharat1Rajput/apiService/internal/model"
+	"github.com/Bharat1Rajput/apiService/internal/repository"
+)
+
+const (
+	defaultListLimit = 50
+	maxListLimit     = 100
+)
+
+type listResponse struct {
+	Jobs       []jobView `json:"jobs"`
+	NextCursor string    `json:"next_cursor,omitempty"`
+}
+
+// handleListWebhooks serves GET /webhooks, newest first. Pages are chained
+// with the opaque next_cursor, which stays stable while new jobs arrive.
+func (h *WebhookHandler) handleListWebhooks(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseListFilter(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// One extra row tells whether another page follows.
+	limit := filter.Limit
+	filter.Limit = limit + 1
+
+	jobs, err := h.jobs.ListJobs(r.Context(), filter)
+	if err != nil {
+		h.logger.Error("handler.webhook: list jobs", zap.Error(err))
+		http.Error(w, "failed to list jobs", http.StatusInternalServerError)
+		return
+	}
+
+	resp := listResponse{Jobs: make([]jobView, 0, len(jobs))}
+	if len(jobs) > limit {
+		jobs = jobs[:limit]
+		last := jobs[limit-1]
+		resp.NextCursor = encodeCursor(repository.Cursor{CreatedAt: last.CreatedAt, ID: last.ID})
+	}
+	for i := range jobs {
+		resp.Jobs = append(resp.Jobs, newJobView(&jobs[i]))
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func parseListFilter(q url.Values) (repository.JobFilter, error) {
+	filter := repository.JobFilter{
+		Status:   model.WebhookStatus(q.Get("status")),
+		TenantID: q.Get("tenant_id"),
+		Host:     q.Get("host"),
+		Error:    q.Get("error"),
+		Limit:    defaultListLimit,
+	}
+
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxListLimit {
+			return filter, fmt.Errorf("limit must be between 1 and %d", maxListLimit)
+		}
+		filter.Limit = n
+	}
+
+	for _, p := range []struct {
+		name string
+		dst  *time.Time
+	}{
+		{"created_after", &filter.CreatedFrom},
+		{"created_before", &filter.CreatedTo},
+	} {
+		v := q.Get(p.name)
+		if v == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return filter, fmt.Errorf("%s must be an RFC 3339 timestamp", p.name)
+		}
+		*p.dst = t
+	}
+
+	if v := q.Get("cursor"); v != "" {
+		c, err := decodeCursor(v)
+		if err != nil {
+			return filter, err
+		}
+		filter.After = &c
+	}
+	return filter, nil
+}
+
+// Cursors are base64url("<created_at RFC 3339 nano>|<id>"); clients should
+// treat them as opaque.
+func encodeCursor(c repository.Cursor) string {
+	raw := c.CreatedAt.UTC().Format(time.RFC3339Nano) + "|" + c.ID
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeCursor(s string) (repository.Cursor, error) {
+	errInvalid := errors.New("invalid cursor")
+
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return repository.Cursor{}, errInvalid
+	}
+	ts, id, ok := strings.Cut(string(raw), "|")
+	if !ok || id == "" {
+		return repository.Cursor{}, errInvalid
+	}
+	t, err := time.Parse(time.RFC3339Nano, ts)
+	if err != nil {
+		return repository.Cursor{}, errInvalid
+	}
+	return repository.Cursor{CreatedAt: t, ID: id}, nil
+}
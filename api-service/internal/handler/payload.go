@@ -0,0 +1,108 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+
+	"github.com/Bharat1Rajput/apiService/internal/model"
+	"github.com/Bharat1Rajput/blob"
+)
+
+// offloadPayload moves payloads larger than PayloadOffloadBytes into the blob
+// store, leaving only a reference and checksum in the queued job.
+func (h *WebhookHandler) offloadPayload(ctx context.Context, job *model.WebhookJob) error {
+	if h.blobs == nil || len(job.Body) <= h.cfg.PayloadOffloadBytes {
+		return nil
+	}
+
+	sum := sha256.Sum256(job.Body)
+	ref := &blob.Ref{
+		Key:    "payloads/" + job.ID,
+		Size:   int64(len(job.Body)),
+		SHA256: hex.EncodeToString(sum[:]),
+	}
+	if err := h.blobs.Put(ctx, ref.Key, bytes.NewReader(job.Body), ref.Size); err != nil {
+		return err
+	}
+
+	job.Body = nil
+	job.PayloadRef = ref
+	return nil
+}
+
+const defaultBinaryContentType = "application/octet-stream"
+
+// decodePayload returns the exact bytes to deliver and their content type.
+//
+// "payload" is any JSON value and is forwarded byte-for-byte as submitted.
+// A JSON string is treated as the legacy string-encoded form and unquoted,
+// so existing clients that double-encode keep working. Non-JSON bodies are
+// sent as "payload_base64" together with their "content_type".
+func decodePayload(req *webhookRequest) ([]byte, string, error) {
+	hasJSON := len(req.Payload) > 0 && string(req.Payload) != "null"
+
+	switch {
+	case hasJSON && req.PayloadBase64 != "":
+		return nil, "", errors.New("payload and payload_base64 are mutually exclusive")
+
+	case req.PayloadBase64 != "":
+		body, err := base64.StdEncoding.DecodeString(req.PayloadBase64)
+		if err != nil {
+			return nil, "", errors.New("payload_base64 must be valid base64")
+		}
+		contentType := req.ContentType
+		if contentType == "" {
+			contentType = defaultBinaryContentType
+		}
+		return body, contentType, nil
+
+	case hasJSON:
+		contentType := req.ContentType
+		if contentType == "" {
+			contentType = model.ContentTypeJSON
+		}
+		if !model.IsJSONContentType(contentType) {
+			return nil, "", errors.New("content_type must be a JSON media type when payload is used; send other bodies as payload_base64")
+		}
+
+		if req.Payload[0] == '"' {
+			var legacy string
+			if err := json.Unmarshal(req.Payload, &legacy); err != nil {
+				return nil, "", errors.New("payload is not valid JSON")
+			}
+			if legacy == "" {
+				return nil, "", errors.New("payload is required")
+			}
+			return []byte(legacy), contentType, nil
+		}
+		return []byte(req.Payload), contentType, nil
+
+	default:
+		return nil, "", errors.New("payload is required")
+	}
+}
+
+// jobView renders a stored job with JSON payloads inlined as JSON rather than
+// base64-encoded bytes.
+type jobView struct {
+	*model.WebhookJob
+	Payload json.RawMessage `json:"payload,omitempty"`
+	Body    []byte          `json:"body,omitempty"`
+	// Priority is shown even when it is the default, which messages omit.
+	Priority int `json:"priority"`
+}
+
+func newJobView(job *model.WebhookJob) jobView {
+	v := jobView{WebhookJob: job, Priority: job.Priority}
+	if model.IsJSONContentType(job.ContentType) && json.Valid(job.Body) {
+		v.Payload = job.Body
+	} else {
+		v.Body = job.Body
+	}
+	return v
+}
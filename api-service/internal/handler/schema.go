@@ -0,0 +1,181 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+
+	"github.com/Bharat1Rajput/apiService/internal/repository"
+	"github.com/Bharat1Rajput/apiService/internal/schema"
+)
+
+// SchemaHandler publishes, lists and deprecates event payload schemas.
+type SchemaHandler struct {
+	store  repository.SchemaStore
+	logger *zap.Logger
+}
+
+func NewSchemaHandler(store repository.SchemaStore, logger *zap.Logger) *SchemaHandler {
+	return &SchemaHandler{
+		store:  store,
+		logger: logger,
+	}
+}
+
+// Routes serves reading schemas to clients passing producer, and publishing
+// and deprecating them, which change how every producer's submissions are
+// validated, to clients passing admin. Without admin only reads are served.
+func (h *SchemaHandler) Routes(producer, admin func(http.Handler) http.Handler) chi.Router {
+	r := chi.NewRouter()
+	r.With(producer).Get("/{eventType}", h.handleList)
+	r.With(producer).Get("/{eventType}/{version}", h.handleGet)
+	if admin != nil {
+		r.With(admin).Post("/{eventType}", h.handlePublish)
+		r.With(admin).Post("/{eventType}/{version}/deprecate", h.handleDeprecate)
+	}
+	return r
+}
+
+// handlePublish stores the request body, a JSON Schema, as the next version
+// of the event type.
+func (h *SchemaHandler) handlePublish(w http.ResponseWriter, r *http.Request) {
+	raw, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "failed to read body", http.StatusBadRequest)
+		return
+	}
+	if !json.Valid(raw) {
+		http.Error(w, "schema must be valid JSON", http.StatusBadRequest)
+		return
+	}
+	if _, err := schema.Compile(raw); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	es, err := h.store.CreateSchema(r.Context(), chi.URLParam(r, "eventType"), raw)
+	if errors.Is(err, repository.ErrVersionConflict) {
+		http.Error(w, "another version was published at the same time, please retry", http.StatusConflict)
+		return
+	}
+	if err != nil {
+		h.logger.Error("handler.schema: create", zap.Error(err))
+		http.Error(w, "failed to publish schema", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, es)
+}
+
+func (h *SchemaHandler) handleList(w http.ResponseWriter, r *http.Request) {
+	schemas, err := h.store.ListSchemas(r.Context(), chi.URLParam(r, "eventType"))
+	if err != nil {
+		h.logger.Error("handler.schema: list", zap.Error(err))
+		http.Error(w, "failed to list schemas", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, schemas)
+}
+
+func (h *SchemaHandler) handleGet(w http.ResponseWriter, r *http.Request) {
+	version, ok := versionParam(w, r)
+	if !ok {
+		return
+	}
+
+	es, err := h.store.GetSchema(r.Context(), chi.URLParam(r, "eventType"), version)
+	if errors.Is(err, repository.ErrNotFound) {
+		http.Error(w, "schema not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		h.logger.Error("handler.schema: get", zap.Error(err))
+		http.Error(w, "failed to load schema", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, es)
+}
+
+func (h *SchemaHandler) handleDeprecate(w http.ResponseWriter, r *http.Request) {
+	version, ok := versionParam(w, r)
+	if !ok {
+		return
+	}
+
+	es, err := h.store.DeprecateSchema(r.Context(), chi.URLParam(r, "eventType"), version)
+	if errors.Is(err, repository.ErrNotFound) {
+		http.Error(w, "schema not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		h.logger.Error("handler.schema: deprecate", zap.Error(err))
+		http.Error(w, "failed to deprecate schema", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, es)
+}
+
+func versionParam(w http.ResponseWriter, r *http.Request) (int, bool) {
+	version, err := strconv.Atoi(chi.URLParam(r, "version"))
+	if err != nil || version < 1 {
+		http.Error(w, "version must be a positive integer", http.StatusBadRequest)
+		return 0, false
+	}
+	return version, true
+}
+
+type schemaErrorResponse struct {
+	Error         string              `json:"error"`
+	EventType     string              `json:"event_type"`
+	SchemaVersion int                 `json:"schema_version"`
+	Errors        []schema.FieldError `json:"errors"`
+}
+
+// validatePayload checks a submission against its event type's schema and
+// writes the rejection itself when it fails. On success it pins the schema
+// version used onto req.
+func (h *WebhookHandler) validatePayload(w http.ResponseWriter, r *http.Request, req *webhookRequest, payload []byte) bool {
+	if h.schemas == nil {
+		return true
+	}
+
+	res, err := h.schemas.Validate(r.Context(), req.EventType, req.SchemaVersion, payload)
+	switch {
+	case errors.Is(err, schema.ErrUnknownVersion):
+		http.Error(w, "unknown schema_version for event_type", http.StatusBadRequest)
+		return false
+	case errors.Is(err, schema.ErrNotJSON):
+		http.Error(w, "event_type has a schema but payload is not JSON", http.StatusBadRequest)
+		return false
+	case err != nil:
+		h.logger.Error("handler.webhook: validate payload", zap.Error(err))
+		http.Error(w, "failed to validate payload", http.StatusInternalServerError)
+		return false
+	case res == nil:
+		return true
+	}
+
+	if res.Schema.DeprecatedAt != nil {
+		w.Header().Set("Deprecation", "true")
+	}
+	if !res.Valid() {
+		writeJSON(w, http.StatusUnprocessableEntity, schemaErrorResponse{
+			Error:         "payload does not match schema",
+			EventType:     res.Schema.EventType,
+			SchemaVersion: res.Schema.Version,
+			Errors:        res.Errors,
+		})
+		return false
+	}
+
+	req.SchemaVersion = res.Schema.Version
+	return true
+}
@@ -3,6 +3,7 @@ package handler
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/url"
 	"time"
@@ -11,21 +12,40 @@ import (
 	"github.com/google/uuid"
 	"go.uber.org/zap"
 
-	"github.com/Bharat1Rajput/apiService/internal/broker"
 	"github.com/Bharat1Rajput/apiService/internal/config"
 	"github.com/Bharat1Rajput/apiService/internal/model"
+	"github.com/Bharat1Rajput/apiService/internal/repository"
+	"github.com/Bharat1Rajput/apiService/internal/schema"
+	"github.com/Bharat1Rajput/blob"
+	"github.com/Bharat1Rajput/broker"
+	"github.com/Bharat1Rajput/contract"
+	"github.com/Bharat1Rajput/ssrf"
 )
 
 type WebhookHandler struct {
 	cfg       *config.Config
 	publisher broker.Publisher
+	jobs      repository.JobReader
+	recorder  repository.JobRecorder
+	guard     *ssrf.Guard
+	blobs     blob.Store
+	schemas   *schema.Registry
 	logger    *zap.Logger
 }
 
-func NewWebhookHandler(cfg *config.Config, pub broker.Publisher, logger *zap.Logger) *WebhookHandler {
+// NewWebhookHandler wires the webhook routes. jobs, recorder and schemas may
+// be nil when the API runs without a database, in which case only
+// unvalidated submission is available, and blobs may be nil to always carry
+// payloads inline.
+func NewWebhookHandler(cfg *config.Config, pub broker.Publisher, jobs repository.JobReader, recorder repository.JobRecorder, guard *ssrf.Guard, blobs blob.Store, schemas *schema.Registry, logger *zap.Logger) *WebhookHandler {
 	return &WebhookHandler{
 		cfg:       cfg,
 		publisher: pub,
+		jobs:      jobs,
+		recorder:  recorder,
+		guard:     guard,
+		blobs:     blobs,
+		schemas:   schemas,
 		logger:    logger,
 	}
 }
@@ -33,12 +53,32 @@ func NewWebhookHandler(cfg *config.Config, pub broker.Publisher, logger *zap.Log
 func (h *WebhookHandler) Routes() chi.Router {
 	r := chi.NewRouter()
 	r.Post("/webhooks", h.handlePostWebhook)
+	if h.jobs != nil {
+		r.Get("/webhooks", h.handleListWebhooks)
+		r.Get("/webhooks/{id}", h.handleGetWebhook)
+		r.Get("/webhooks/{id}/attempts", h.handleListAttempts)
+	}
 	return r
 }
 
+const maxOrderingKeyLen = 255
+
 type webhookRequest struct {
-	Payload   string `json:"payload"`
-	ClientURL string `json:"client_url"`
+	Payload       json.RawMessage `json:"payload"`
+	PayloadBase64 string          `json:"payload_base64,omitempty"`
+	ContentType   string          `json:"content_type,omitempty"`
+	ClientURL     string          `json:"client_url"`
+	RetryPolicy   string          `json:"retry_policy,omitempty"`
+	TenantID      string          `json:"tenant_id,omitempty"`
+	EventType     string          `json:"event_type,omitempty"`
+	SchemaVersion int             `json:"schema_version,omitempty"`
+	OrderingKey   string          `json:"ordering_key,omitempty"`
+	Priority      int             `json:"priority,omitempty"`
+	ExpiresAt     *time.Time      `json:"expires_at,omitempty"`
+	TTL           string          `json:"ttl,omitempty"`
+
+	// StatusCallbackURL is notified once the job reaches a terminal status.
+	StatusCallbackURL string `json:"status_callback_url,omitempty"`
 }
 
 type webhookResponse struct {
@@ -54,28 +94,82 @@ func (h *WebhookHandler) handlePostWebhook(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	if req.Payload == "" {
-		http.Error(w, "payload is required", http.StatusBadRequest)
+	payload, contentType, err := decodePayload(&req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	u, err := url.Parse(req.ClientURL)
-	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
-		http.Error(w, "client_url must be a valid http or https URL", http.StatusBadRequest)
+	if req.SchemaVersion != 0 && req.EventType == "" {
+		http.Error(w, "schema_version requires event_type", http.StatusBadRequest)
+		return
+	}
+	if req.EventType != "" && !h.validatePayload(w, r, &req, payload) {
+		return
+	}
+
+	if len(req.OrderingKey) > maxOrderingKeyLen {
+		http.Error(w, "ordering_key is too long", http.StatusBadRequest)
+		return
+	}
+
+	if req.Priority < 0 || req.Priority > model.MaxPriority {
+		http.Error(w, "priority must be between 0 and 9", http.StatusBadRequest)
+		return
+	}
+	// A priority would let a later job overtake an earlier one with the
+	// same key in the queue, so the two are not combined.
+	if req.Priority != 0 && req.OrderingKey != "" {
+		http.Error(w, "priority cannot be combined with ordering_key", http.StatusBadRequest)
+		return
+	}
+
+	now := time.Now().UTC()
+	expiresAt, err := expiry(&req, now)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if !h.checkURL(w, r, "client_url", req.ClientURL) {
+		return
+	}
+	if req.StatusCallbackURL != "" && !h.checkURL(w, r, "status_callback_url", req.StatusCallbackURL) {
 		return
 	}
 
 	job := model.WebhookJob{
-		ID:        uuid.New().String(),
-		Payload:   req.Payload,
-		ClientURL: req.ClientURL,
-		Status:    model.StatusPending,
-		CreatedAt: time.Now().UTC(),
+		Job: contract.Job{
+			ID:            uuid.New().String(),
+			EventType:     req.EventType,
+			SchemaVersion: req.SchemaVersion,
+			Body:          payload,
+			ContentType:   contentType,
+			ClientURL:     req.ClientURL,
+			Status:        model.StatusPending,
+			RetryPolicy:   req.RetryPolicy,
+			OrderingKey:   req.OrderingKey,
+			Priority:      req.Priority,
+			ExpiresAt:     expiresAt,
+			CreatedAt:     now,
+			UpdatedAt:     now,
+
+			StatusCallbackURL: req.StatusCallbackURL,
+		},
+		TenantID: req.TenantID,
 	}
 
-	body, err := json.Marshal(job)
+	if err := h.offloadPayload(r.Context(), &job); err != nil {
+		h.logger.Error("handler.webhook: offload payload", zap.Error(err))
+		http.Error(w, "failed to store payload", http.StatusInternalServerError)
+		return
+	}
+
+	msg := job.Message(0)
+	msg.Headers = traceHeaders(r.Header)
+	body, err := contract.Encode(msg)
 	if err != nil {
-		h.logger.Error("handler.webhook: marshal job", zap.Error(err))
+		h.logger.Error("handler.webhook: encode job", zap.Error(err))
 		http.Error(w, "failed to enqueue job", http.StatusInternalServerError)
 		return
 	}
@@ -83,8 +177,20 @@ func (h *WebhookHandler) handlePostWebhook(w http.ResponseWriter, r *http.Reques
 	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
 	defer cancel()
 
-	if err := h.publisher.Publish(ctx, h.cfg.RabbitRoutingKey, body); err != nil {
+	// The job is recorded before it is published, so that it can be looked
+	// up as soon as it is accepted and so that a keyed job queued behind it
+	// waits for it even if a worker picks that one up first.
+	if h.recorder != nil {
+		if err := h.recorder.InsertPending(ctx, &job); err != nil {
+			h.logger.Error("handler.webhook: record job", zap.Error(err))
+			http.Error(w, "failed to record job", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if err := h.publisher.Publish(ctx, body, uint8(job.Priority)); err != nil {
 		h.logger.Error("handler.webhook: publish job", zap.Error(err))
+		h.forget(r.Context(), job.ID)
 		http.Error(w, "failed to enqueue job", http.StatusInternalServerError)
 		return
 	}
@@ -99,4 +205,124 @@ func (h *WebhookHandler) handlePostWebhook(w http.ResponseWriter, r *http.Reques
 	_ = json.NewEncoder(w).Encode(resp)
 }
 
+// forget removes the row of a job whose message could not be published. A
+// row left behind stays pending, and blocks later jobs with its ordering
+// key, until it is cancelled with dispatchctl.
+func (h *WebhookHandler) forget(ctx context.Context, id string) {
+	if h.recorder == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancel()
+	if err := h.recorder.DeletePending(ctx, id); err != nil {
+		h.logger.Error("handler.webhook: delete unpublished job", zap.String("job_id", id), zap.Error(err))
+	}
+}
 
+// traceHeaders returns the trace context the request was made in, for the
+// worker to carry on to the delivery.
+func traceHeaders(h http.Header) map[string]string {
+	var headers map[string]string
+	for _, name := range contract.TraceHeaders {
+		if v := h.Get(name); v != "" {
+			if headers == nil {
+				headers = make(map[string]string)
+			}
+			headers[name] = v
+		}
+	}
+	return headers
+}
+
+// checkURL validates a destination URL named field in the request and
+// writes the 400 response when it is malformed or blocked by the SSRF guard.
+func (h *WebhookHandler) checkURL(w http.ResponseWriter, r *http.Request, field, raw string) bool {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		http.Error(w, field+" must be a valid http or https URL", http.StatusBadRequest)
+		return false
+	}
+
+	if err := h.guard.CheckURL(r.Context(), u); err != nil {
+		if errors.Is(err, ssrf.ErrBlocked) {
+			http.Error(w, field+" destination is not allowed", http.StatusBadRequest)
+			return false
+		}
+		http.Error(w, field+" host could not be resolved", http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+// expiry resolves the optional expires_at / ttl (a Go duration such as "5m")
+// into an absolute deadline.
+func expiry(req *webhookRequest, now time.Time) (*time.Time, error) {
+	switch {
+	case req.ExpiresAt != nil && req.TTL != "":
+		return nil, errors.New("set either expires_at or ttl, not both")
+	case req.TTL != "":
+		ttl, err := time.ParseDuration(req.TTL)
+		if err != nil || ttl <= 0 {
+			return nil, errors.New("ttl must be a positive duration such as \"90s\" or \"5m\"")
+		}
+		t := now.Add(ttl)
+		return &t, nil
+	case req.ExpiresAt != nil:
+		if !req.ExpiresAt.After(now) {
+			return nil, errors.New("expires_at must be in the future")
+		}
+		t := req.ExpiresAt.UTC()
+		return &t, nil
+	default:
+		return nil, nil
+	}
+}
+
+func (h *WebhookHandler) handleGetWebhook(w http.ResponseWriter, r *http.Request) {
+	job, err := h.jobs.GetJob(r.Context(), chi.URLParam(r, "id"))
+	if errors.Is(err, repository.ErrNotFound) {
+		http.Error(w, "job not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		h.logger.Error("handler.webhook: get job", zap.Error(err))
+		http.Error(w, "failed to load job", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newJobView(job))
+}
+
+type attemptsResponse struct {
+	JobID    string          `json:"job_id"`
+	Attempts []model.Attempt `json:"attempts"`
+}
+
+func (h *WebhookHandler) handleListAttempts(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	if _, err := h.jobs.GetJob(r.Context(), id); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			http.Error(w, "job not found", http.StatusNotFound)
+			return
+		}
+		h.logger.Error("handler.webhook: get job", zap.Error(err))
+		http.Error(w, "failed to load job", http.StatusInternalServerError)
+		return
+	}
+
+	attempts, err := h.jobs.ListAttempts(r.Context(), id)
+	if err != nil {
+		h.logger.Error("handler.webhook: list attempts", zap.Error(err))
+		http.Error(w, "failed to load attempts", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, attemptsResponse{JobID: id, Attempts: attempts})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
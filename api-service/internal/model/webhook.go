@@ -1,22 +1,75 @@
 package model
 
-import "time"
+import (
+	"encoding/json"
+	"mime"
+	"strings"
+	"time"
 
-type WebhookStatus string
+	"github.com/Bharat1Rajput/contract"
+)
+
+// WebhookStatus is a job status, as shared with the worker.
+type WebhookStatus = contract.Status
 
 const (
-	StatusPending  WebhookStatus = "pending"
-	StatusSuccess  WebhookStatus = "success"
-	StatusFailed   WebhookStatus = "failed"
-	StatusUnknown  WebhookStatus = "unknown"
+	StatusPending    = WebhookStatus(contract.StatusPending)
+	StatusProcessing = WebhookStatus(contract.StatusProcessing)
+	StatusSuccess    = WebhookStatus(contract.StatusSuccess)
+	StatusFailed     = WebhookStatus(contract.StatusFailed)
+	StatusExpired    = WebhookStatus(contract.StatusExpired)
+	StatusCancelled  = WebhookStatus(contract.StatusCancelled)
+	// StatusUnknown is the API's own; it is never stored or published.
+	StatusUnknown WebhookStatus = "unknown"
 )
 
+const ContentTypeJSON = "application/json"
+
+// MaxPriority is the highest job priority a submission may ask for; 0 is
+// the default and lowest.
+const MaxPriority = 9
+
+// IsJSONContentType reports whether ct is application/json or a +json type.
+func IsJSONContentType(ct string) bool {
+	mediaType, _, err := mime.ParseMediaType(ct)
+	if err != nil {
+		return false
+	}
+	return mediaType == ContentTypeJSON || strings.HasSuffix(mediaType, "+json")
+}
+
+// WebhookJob is a job as stored by the worker: the contract.Job published
+// for it, the tenant it was submitted for, and, once it is finished, what
+// became of its status callback (pending, sent, failed or skipped).
 type WebhookJob struct {
-	ID        string        `json:"id"`
-	Payload   string        `json:"payload"`
-	ClientURL string        `json:"client_url"`
-	Status    WebhookStatus `json:"status"`
-	Error     string        `json:"error,omitempty"`
-	CreatedAt time.Time     `json:"created_at"`
+	contract.Job
+	TenantID       string `json:"tenant_id,omitempty"`
+	CallbackStatus string `json:"callback_status,omitempty"`
 }
 
+// Message wraps the job for the queue. attempt is the number of delivery
+// attempts already recorded for it.
+func (j *WebhookJob) Message(attempt int) *contract.Message {
+	return contract.NewMessage(j.Job, j.TenantID, attempt)
+}
+
+// Attempt is one delivery try recorded by the worker in webhook_attempts.
+type Attempt struct {
+	Attempt         int               `json:"attempt"`
+	StartedAt       time.Time         `json:"started_at"`
+	DurationMS      int64             `json:"duration_ms"`
+	StatusCode      int               `json:"status_code"`
+	ResponseHeaders map[string]string `json:"response_headers"`
+	ResponseBody    string            `json:"response_body"`
+	Error           string            `json:"error,omitempty"`
+	ErrorClass      string            `json:"error_class,omitempty"`
+}
+
+// EventSchema is one published version of the JSON Schema for an event type.
+type EventSchema struct {
+	EventType    string          `json:"event_type"`
+	Version      int             `json:"version"`
+	Schema       json.RawMessage `json:"schema"`
+	CreatedAt    time.Time       `json:"created_at"`
+	DeprecatedAt *time.Time      `json:"deprecated_at,omitempty"`
+}
@@ -0,0 +1,357 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Bharat1Rajput/apiService/internal/model"
+	"github.com/Bharat1Rajput/jobstore"
+)
+
+var (
+	ErrNotFound     = errors.New("repository: not found")
+	ErrNotRetryable = jobstore.ErrNotRetryable
+)
+
+// MaxListLimit caps how many jobs one ListJobs call returns.
+const MaxListLimit = 200
+
+// JobFilter narrows job listings. Zero fields match everything; Host
+// matches the host of client_url and Error is a case-insensitive substring
+// of the last error. CreatedFrom is inclusive, CreatedTo exclusive.
+type JobFilter struct {
+	Status      model.WebhookStatus
+	TenantID    string
+	Host        string
+	Error       string
+	CreatedFrom time.Time
+	CreatedTo   time.Time
+	After       *Cursor
+	Limit       int
+}
+
+// Cursor is the position of the last job on a page. Listings are ordered by
+// (created_at, id) descending, which is stable even when jobs share a
+// timestamp.
+type Cursor struct {
+	CreatedAt time.Time
+	ID        string
+}
+
+// JobReader gives the API read access to the job history written by worker-service.
+type JobReader interface {
+	GetJob(ctx context.Context, id string) (*model.WebhookJob, error)
+	ListJobs(ctx context.Context, filter JobFilter) ([]model.WebhookJob, error)
+	ListAttempts(ctx context.Context, jobID string) ([]model.Attempt, error)
+}
+
+// JobRetrier resets finished jobs so they can be published again.
+type JobRetrier interface {
+	// ResetForRetry resets a failed, expired or cancelled job as described
+	// at jobstore.ResetForRetry and hands it to publish.
+	ResetForRetry(ctx context.Context, id string, publish func(*model.WebhookJob) error) (*model.WebhookJob, error)
+}
+
+// JobRecorder records accepted jobs before they are published, so that they
+// can be looked up while still in the queue. The worker claims the pending
+// row when it picks the job up.
+type JobRecorder interface {
+	// InsertPending records job, which must be pending and not yet stored.
+	InsertPending(ctx context.Context, job *model.WebhookJob) error
+	// DeletePending removes a job recorded by InsertPending whose message
+	// could not be published, unless a worker has claimed it since.
+	DeletePending(ctx context.Context, id string) error
+}
+
+type PostgresJobReader struct {
+	db *sql.DB
+}
+
+func NewPostgresJobReader(db *sql.DB) *PostgresJobReader {
+	return &PostgresJobReader{db: db}
+}
+
+func (r *PostgresJobReader) GetJob(ctx context.Context, id string) (*model.WebhookJob, error) {
+	const query = `SELECT ` + jobColumns + ` FROM webhook_jobs WHERE id = $1`
+	job, err := scanJob(r.db.QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("repository.job: get job: %w", err)
+	}
+	return job, nil
+}
+
+// ListJobs returns the most recent jobs matching filter, newest first. The
+// payload columns are not read, so listed jobs have no Body.
+func (r *PostgresJobReader) ListJobs(ctx context.Context, filter JobFilter) ([]model.WebhookJob, error) {
+	var (
+		where []string
+		args  []any
+	)
+	add := func(cond string, arg any) {
+		args = append(args, arg)
+		where = append(where, fmt.Sprintf(cond, len(args)))
+	}
+	if filter.Status != "" {
+		add("status = $%d", filter.Status)
+	}
+	if filter.TenantID != "" {
+		add("tenant_id = $%d", filter.TenantID)
+	}
+	if filter.Host != "" {
+		add(clientHostExpr+" = lower($%d)", filter.Host)
+	}
+	if filter.Error != "" {
+		add(`error ILIKE '%%' || $%d || '%%'`, likeEscape(filter.Error))
+	}
+	if !filter.CreatedFrom.IsZero() {
+		add("created_at >= $%d", filter.CreatedFrom)
+	}
+	if !filter.CreatedTo.IsZero() {
+		add("created_at < $%d", filter.CreatedTo)
+	}
+	if filter.After != nil {
+		args = append(args, filter.After.CreatedAt, filter.After.ID)
+		where = append(where, fmt.Sprintf("(created_at, id) < ($%d, $%d)", len(args)-1, len(args)))
+	}
+
+	query := `SELECT ` + jobSummaryColumns + ` FROM webhook_jobs`
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	limit := filter.Limit
+	if limit <= 0 || limit > MaxListLimit {
+		limit = MaxListLimit
+	}
+	args = append(args, limit)
+	query += fmt.Sprintf(" ORDER BY created_at DESC, id DESC LIMIT $%d", len(args))
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("repository.job: list jobs: %w", err)
+	}
+	defer rows.Close()
+
+	jobs := []model.WebhookJob{}
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			return nil, fmt.Errorf("repository.job: list jobs: %w", err)
+		}
+		jobs = append(jobs, *job)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("repository.job: list jobs: %w", err)
+	}
+	return jobs, nil
+}
+
+// InsertPending leaves payload_json to the worker, which copies JSON bodies
+// there when it claims the job, as JSONB refuses some valid JSON.
+func (r *PostgresJobReader) InsertPending(ctx context.Context, job *model.WebhookJob) error {
+	const query = `
+		INSERT INTO webhook_jobs (
+			id, tenant_id, event_type, schema_version, payload, payload_bytes,
+			content_type, payload_ref, client_url, status, error, retry_count, retry_policy,
+			ordering_key, priority, expires_at, status_callback_url, created_at, updated_at
+		) VALUES ($1,$2,$3,$4,'',$5,$6,$7,$8,$9,'',0,$10,$11,$12,$13,$14,$15,$16)
+	`
+	payloadRef, err := marshalNullable(job.PayloadRef)
+	if err != nil {
+		return fmt.Errorf("repository.job: marshal payload ref: %w", err)
+	}
+	_, err = r.db.ExecContext(
+		ctx,
+		query,
+		job.ID,
+		job.TenantID,
+		job.EventType,
+		job.SchemaVersion,
+		job.Body,
+		job.ContentType,
+		payloadRef,
+		job.ClientURL,
+		model.StatusPending,
+		job.RetryPolicy,
+		job.OrderingKey,
+		job.Priority,
+		job.ExpiresAt,
+		job.StatusCallbackURL,
+		job.CreatedAt,
+		job.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("repository.job: insert pending: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresJobReader) DeletePending(ctx context.Context, id string) error {
+	const query = `DELETE FROM webhook_jobs WHERE id = $1 AND status = $2`
+	if _, err := r.db.ExecContext(ctx, query, id, model.StatusPending); err != nil {
+		return fmt.Errorf("repository.job: delete pending: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresJobReader) ResetForRetry(ctx context.Context, id string, publish func(*model.WebhookJob) error) (*model.WebhookJob, error) {
+	return resetForRetry(ctx, r.db, jobstore.Postgres, "SELECT "+jobColumns+" FROM webhook_jobs WHERE id = $1", id, publish)
+}
+
+// resetForRetry runs jobstore.ResetForRetry, reading the reset job with
+// query inside its transaction.
+func resetForRetry(ctx context.Context, db *sql.DB, dialect jobstore.Dialect, query, id string, publish func(*model.WebhookJob) error) (*model.WebhookJob, error) {
+	var job *model.WebhookJob
+	err := jobstore.ResetForRetry(ctx, db, dialect, id, func(tx jobstore.Querier) error {
+		var err error
+		if job, err = scanJob(tx.QueryRowContext(ctx, query, id)); err != nil {
+			return fmt.Errorf("repository.job: reset for retry: %w", err)
+		}
+		return publish(job)
+	})
+	if errors.Is(err, jobstore.ErrNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+func (r *PostgresJobReader) ListAttempts(ctx context.Context, jobID string) ([]model.Attempt, error) {
+	const query = `
+		SELECT attempt, started_at, duration_ms, status_code,
+		       response_headers, response_body, error, error_class
+		FROM webhook_attempts
+		WHERE job_id = $1
+		ORDER BY attempt
+	`
+	rows, err := r.db.QueryContext(ctx, query, jobID)
+	if err != nil {
+		return nil, fmt.Errorf("repository.job: list attempts: %w", err)
+	}
+	defer rows.Close()
+
+	attempts := []model.Attempt{}
+	for rows.Next() {
+		var (
+			a       model.Attempt
+			headers []byte
+		)
+		if err := rows.Scan(
+			&a.Attempt,
+			&a.StartedAt,
+			&a.DurationMS,
+			&a.StatusCode,
+			&headers,
+			&a.ResponseBody,
+			&a.Error,
+			&a.ErrorClass,
+		); err != nil {
+			return nil, fmt.Errorf("repository.job: scan attempt: %w", err)
+		}
+		if err := json.Unmarshal(headers, &a.ResponseHeaders); err != nil {
+			return nil, fmt.Errorf("repository.job: decode attempt headers: %w", err)
+		}
+		attempts = append(attempts, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("repository.job: list attempts: %w", err)
+	}
+	return attempts, nil
+}
+
+// jobColumns is the column list scanJob expects.
+const jobColumns = `id, tenant_id, event_type, schema_version, payload, payload_json::text, payload_bytes,
+	content_type, payload_ref, client_url, status, error, retry_count, classification, retry_policy,
+	ordering_key, priority, expires_at, status_callback_url, callback_status, created_at, updated_at`
+
+// jobSummaryColumns matches jobColumns but leaves the payload out.
+const jobSummaryColumns = `id, tenant_id, event_type, schema_version, '', NULL::text, NULL::bytea,
+	content_type, payload_ref, client_url, status, error, retry_count, classification, retry_policy,
+	ordering_key, priority, expires_at, status_callback_url, callback_status, created_at, updated_at`
+
+// clientHostExpr extracts the lower-cased host of client_url. It must stay
+// identical to the expression indexed by worker-service migration 0012.
+const clientHostExpr = `lower(substring(client_url from '^[A-Za-z][A-Za-z0-9+.-]*://(?:[^@/]*@)?([^/:?#]+)'))`
+
+// scanJob reads a row selected with jobColumns. sql.ErrNoRows is returned
+// unwrapped.
+func scanJob(s rowScanner) (*model.WebhookJob, error) {
+	var (
+		job          model.WebhookJob
+		legacy       string
+		payloadJSON  []byte
+		payloadBytes []byte
+		payloadRef   []byte
+	)
+	if err := s.Scan(
+		&job.ID,
+		&job.TenantID,
+		&job.EventType,
+		&job.SchemaVersion,
+		&legacy,
+		&payloadJSON,
+		&payloadBytes,
+		&job.ContentType,
+		&payloadRef,
+		&job.ClientURL,
+		&job.Status,
+		&job.Error,
+		&job.RetryCount,
+		&job.Classification,
+		&job.RetryPolicy,
+		&job.OrderingKey,
+		&job.Priority,
+		&job.ExpiresAt,
+		&job.StatusCallbackURL,
+		&job.CallbackStatus,
+		&job.CreatedAt,
+		&job.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	if payloadRef != nil {
+		if err := json.Unmarshal(payloadRef, &job.PayloadRef); err != nil {
+			return nil, fmt.Errorf("decode payload ref: %w", err)
+		}
+	}
+	job.Body = payloadBody(legacy, payloadJSON, payloadBytes)
+	return &job, nil
+}
+
+// payloadBody picks the exact body from BYTEA. Rows written before bodies
+// were always kept there have JSON bodies only in JSONB, which is otherwise
+// a normalised copy for queries, and rows older still have them in TEXT.
+func payloadBody(legacy string, payloadJSON, payloadBytes []byte) []byte {
+	switch {
+	case payloadBytes != nil:
+		return payloadBytes
+	case payloadJSON != nil:
+		return payloadJSON
+	case legacy != "":
+		return []byte(legacy)
+	default:
+		return nil
+	}
+}
+
+// marshalNullable encodes v as JSON for a nullable JSONB column.
+func marshalNullable[T any](v *T) ([]byte, error) {
+	if v == nil {
+		return nil, nil
+	}
+	return json.Marshal(v)
+}
+
+// likeEscape escapes LIKE wildcards so s matches literally.
+func likeEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -0,0 +1,162 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/Bharat1Rajput/apiService/internal/model"
+)
+
+// ErrVersionConflict is returned by CreateSchema when concurrent publishes
+// kept taking the version it computed.
+var ErrVersionConflict = errors.New("repository.schema: version taken by a concurrent publish")
+
+// createAttempts bounds how often CreateSchema computes the next version.
+const createAttempts = 3
+
+// SchemaStore persists versioned JSON Schemas per event type.
+type SchemaStore interface {
+	CreateSchema(ctx context.Context, eventType string, schema []byte) (*model.EventSchema, error)
+	GetSchema(ctx context.Context, eventType string, version int) (*model.EventSchema, error)
+	LatestSchema(ctx context.Context, eventType string) (*model.EventSchema, error)
+	ListSchemas(ctx context.Context, eventType string) ([]model.EventSchema, error)
+	DeprecateSchema(ctx context.Context, eventType string, version int) (*model.EventSchema, error)
+}
+
+type PostgresSchemaStore struct {
+	db *sql.DB
+}
+
+func NewPostgresSchemaStore(db *sql.DB) *PostgresSchemaStore {
+	return &PostgresSchemaStore{db: db}
+}
+
+const schemaColumns = `event_type, version, schema::text, created_at, deprecated_at`
+
+func (s *PostgresSchemaStore) CreateSchema(ctx context.Context, eventType string, schema []byte) (*model.EventSchema, error) {
+	const query = `
+		INSERT INTO event_schemas (event_type, version, schema)
+		SELECT $1, COALESCE(MAX(version), 0) + 1, $2
+		FROM event_schemas
+		WHERE event_type = $1
+		RETURNING ` + schemaColumns
+
+	return createVersion(func() (*model.EventSchema, error) {
+		return scanSchema(s.db.QueryRowContext(ctx, query, eventType, string(schema)))
+	}, isPostgresUniqueViolation)
+}
+
+// createVersion runs insert, which publishes the version after the newest,
+// again when a concurrent publish took that version first.
+func createVersion(insert func() (*model.EventSchema, error), taken func(error) bool) (*model.EventSchema, error) {
+	for attempt := 1; ; attempt++ {
+		es, err := insert()
+		switch {
+		case err == nil:
+			return es, nil
+		case !taken(err):
+			return nil, fmt.Errorf("repository.schema: create: %w", err)
+		case attempt == createAttempts:
+			return nil, ErrVersionConflict
+		}
+	}
+}
+
+func isPostgresUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
+
+func (s *PostgresSchemaStore) GetSchema(ctx context.Context, eventType string, version int) (*model.EventSchema, error) {
+	query := `SELECT ` + schemaColumns + ` FROM event_schemas WHERE event_type = $1 AND version = $2`
+
+	es, err := scanSchema(s.db.QueryRowContext(ctx, query, eventType, version))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("repository.schema: get: %w", err)
+	}
+	return es, nil
+}
+
+// LatestSchema returns the newest non-deprecated version of eventType, or
+// the newest version if all of them are deprecated.
+func (s *PostgresSchemaStore) LatestSchema(ctx context.Context, eventType string) (*model.EventSchema, error) {
+	query := `
+		SELECT ` + schemaColumns + `
+		FROM event_schemas
+		WHERE event_type = $1
+		ORDER BY deprecated_at IS NULL DESC, version DESC
+		LIMIT 1
+	`
+	es, err := scanSchema(s.db.QueryRowContext(ctx, query, eventType))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("repository.schema: latest: %w", err)
+	}
+	return es, nil
+}
+
+func (s *PostgresSchemaStore) ListSchemas(ctx context.Context, eventType string) ([]model.EventSchema, error) {
+	query := `SELECT ` + schemaColumns + ` FROM event_schemas WHERE event_type = $1 ORDER BY version`
+
+	rows, err := s.db.QueryContext(ctx, query, eventType)
+	if err != nil {
+		return nil, fmt.Errorf("repository.schema: list: %w", err)
+	}
+	defer rows.Close()
+
+	schemas := []model.EventSchema{}
+	for rows.Next() {
+		es, err := scanSchema(rows)
+		if err != nil {
+			return nil, fmt.Errorf("repository.schema: scan: %w", err)
+		}
+		schemas = append(schemas, *es)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("repository.schema: list: %w", err)
+	}
+	return schemas, nil
+}
+
+func (s *PostgresSchemaStore) DeprecateSchema(ctx context.Context, eventType string, version int) (*model.EventSchema, error) {
+	query := `
+		UPDATE event_schemas
+		SET deprecated_at = COALESCE(deprecated_at, $3)
+		WHERE event_type = $1 AND version = $2
+		RETURNING ` + schemaColumns
+
+	es, err := scanSchema(s.db.QueryRowContext(ctx, query, eventType, version, time.Now().UTC()))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("repository.schema: deprecate: %w", err)
+	}
+	return es, nil
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanSchema(row rowScanner) (*model.EventSchema, error) {
+	var (
+		es     model.EventSchema
+		schema string
+	)
+	if err := row.Scan(&es.EventType, &es.Version, &schema, &es.CreatedAt, &es.DeprecatedAt); err != nil {
+		return nil, err
+	}
+	es.Schema = []byte(schema)
+	return &es, nil
+}
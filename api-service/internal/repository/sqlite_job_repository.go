@@ -0,0 +1,229 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/Bharat1Rajput/apiService/internal/model"
+	"github.com/Bharat1Rajput/jobstore"
+)
+
+// SQLiteJobReader is JobReader, JobRecorder and JobRetrier on SQLite, for dispatchgo's
+// single-process mode, where worker-service writes the same tables.
+type SQLiteJobReader struct {
+	db *sql.DB
+}
+
+func NewSQLiteJobReader(db *sql.DB) *SQLiteJobReader {
+	return &SQLiteJobReader{db: db}
+}
+
+// sqliteJobColumns and sqliteJobSummaryColumns are jobColumns and
+// jobSummaryColumns without Postgres casts.
+const sqliteJobColumns = `id, tenant_id, event_type, schema_version, payload, payload_json, payload_bytes,
+	content_type, payload_ref, client_url, status, error, retry_count, classification, retry_policy,
+	ordering_key, priority, expires_at, status_callback_url, callback_status, created_at, updated_at`
+
+const sqliteJobSummaryColumns = `id, tenant_id, event_type, schema_version, '', NULL, NULL,
+	content_type, payload_ref, client_url, status, error, retry_count, classification, retry_policy,
+	ordering_key, priority, expires_at, status_callback_url, callback_status, created_at, updated_at`
+
+// clientHostPattern is clientHostExpr as a Go regexp. SQLite has no regular
+// expressions, so ListJobs filters by host as it reads rows.
+var clientHostPattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9+.-]*://(?:[^@/]*@)?([^/:?#]+)`)
+
+func (r *SQLiteJobReader) GetJob(ctx context.Context, id string) (*model.WebhookJob, error) {
+	const query = `SELECT ` + sqliteJobColumns + ` FROM webhook_jobs WHERE id = ?`
+	job, err := scanJob(r.db.QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("repository.job: get job: %w", err)
+	}
+	return job, nil
+}
+
+// ListJobs returns the most recent jobs matching filter, newest first. The
+// payload columns are not read, so listed jobs have no Body.
+func (r *SQLiteJobReader) ListJobs(ctx context.Context, filter JobFilter) ([]model.WebhookJob, error) {
+	var (
+		where []string
+		args  []any
+	)
+	add := func(cond string, arg any) {
+		args = append(args, arg)
+		where = append(where, cond)
+	}
+	if filter.Status != "" {
+		add("status = ?", filter.Status)
+	}
+	if filter.TenantID != "" {
+		add("tenant_id = ?", filter.TenantID)
+	}
+	if filter.Error != "" {
+		// LIKE is case-insensitive for ASCII in SQLite.
+		add(`error LIKE '%' || ? || '%' ESCAPE '\'`, likeEscape(filter.Error))
+	}
+	if !filter.CreatedFrom.IsZero() {
+		add("created_at >= ?", filter.CreatedFrom.UTC())
+	}
+	if !filter.CreatedTo.IsZero() {
+		add("created_at < ?", filter.CreatedTo.UTC())
+	}
+	if filter.After != nil {
+		args = append(args, filter.After.CreatedAt.UTC(), filter.After.ID)
+		where = append(where, "(created_at, id) < (?, ?)")
+	}
+
+	query := `SELECT ` + sqliteJobSummaryColumns + ` FROM webhook_jobs`
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY created_at DESC, id DESC"
+	limit := filter.Limit
+	if limit <= 0 || limit > MaxListLimit {
+		limit = MaxListLimit
+	}
+	if filter.Host == "" {
+		args = append(args, limit)
+		query += " LIMIT ?"
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("repository.job: list jobs: %w", err)
+	}
+	defer rows.Close()
+
+	jobs := []model.WebhookJob{}
+	for len(jobs) < limit && rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			return nil, fmt.Errorf("repository.job: list jobs: %w", err)
+		}
+		if filter.Host != "" && !strings.EqualFold(clientHost(job.ClientURL), filter.Host) {
+			continue
+		}
+		jobs = append(jobs, *job)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("repository.job: list jobs: %w", err)
+	}
+	return jobs, nil
+}
+
+func (r *SQLiteJobReader) InsertPending(ctx context.Context, job *model.WebhookJob) error {
+	const query = `
+		INSERT INTO webhook_jobs (
+			id, tenant_id, event_type, schema_version, payload, payload_json, payload_bytes,
+			content_type, payload_ref, client_url, status, error, retry_count, retry_policy,
+			ordering_key, priority, expires_at, status_callback_url, created_at, updated_at
+		) VALUES (?,?,?,?,'',?,?,?,?,?,?,'',0,?,?,?,?,?,?,?)
+	`
+	payloadRef, err := marshalNullable(job.PayloadRef)
+	if err != nil {
+		return fmt.Errorf("repository.job: marshal payload ref: %w", err)
+	}
+	var payloadJSON, ref any
+	if job.Body != nil && model.IsJSONContentType(job.ContentType) && json.Valid(job.Body) {
+		payloadJSON = string(job.Body)
+	}
+	if payloadRef != nil {
+		ref = string(payloadRef)
+	}
+	_, err = r.db.ExecContext(
+		ctx,
+		query,
+		job.ID,
+		job.TenantID,
+		job.EventType,
+		job.SchemaVersion,
+		payloadJSON,
+		job.Body,
+		job.ContentType,
+		ref,
+		job.ClientURL,
+		model.StatusPending,
+		job.RetryPolicy,
+		job.OrderingKey,
+		job.Priority,
+		job.ExpiresAt,
+		job.StatusCallbackURL,
+		job.CreatedAt,
+		job.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("repository.job: insert pending: %w", err)
+	}
+	return nil
+}
+
+func (r *SQLiteJobReader) DeletePending(ctx context.Context, id string) error {
+	const query = `DELETE FROM webhook_jobs WHERE id = ? AND status = ?`
+	if _, err := r.db.ExecContext(ctx, query, id, model.StatusPending); err != nil {
+		return fmt.Errorf("repository.job: delete pending: %w", err)
+	}
+	return nil
+}
+
+func (r *SQLiteJobReader) ResetForRetry(ctx context.Context, id string, publish func(*model.WebhookJob) error) (*model.WebhookJob, error) {
+	return resetForRetry(ctx, r.db, jobstore.SQLite, "SELECT "+sqliteJobColumns+" FROM webhook_jobs WHERE id = ?", id, publish)
+}
+
+func (r *SQLiteJobReader) ListAttempts(ctx context.Context, jobID string) ([]model.Attempt, error) {
+	const query = `
+		SELECT attempt, started_at, duration_ms, status_code,
+		       response_headers, response_body, error, error_class
+		FROM webhook_attempts
+		WHERE job_id = ?
+		ORDER BY attempt
+	`
+	rows, err := r.db.QueryContext(ctx, query, jobID)
+	if err != nil {
+		return nil, fmt.Errorf("repository.job: list attempts: %w", err)
+	}
+	defer rows.Close()
+
+	attempts := []model.Attempt{}
+	for rows.Next() {
+		var (
+			a       model.Attempt
+			headers []byte
+		)
+		if err := rows.Scan(
+			&a.Attempt,
+			&a.StartedAt,
+			&a.DurationMS,
+			&a.StatusCode,
+			&headers,
+			&a.ResponseBody,
+			&a.Error,
+			&a.ErrorClass,
+		); err != nil {
+			return nil, fmt.Errorf("repository.job: scan attempt: %w", err)
+		}
+		if err := json.Unmarshal(headers, &a.ResponseHeaders); err != nil {
+			return nil, fmt.Errorf("repository.job: decode attempt headers: %w", err)
+		}
+		attempts = append(attempts, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("repository.job: list attempts: %w", err)
+	}
+	return attempts, nil
+}
+
+// clientHost returns the host of a client URL as clientHostExpr extracts it.
+func clientHost(rawURL string) string {
+	m := clientHostPattern.FindStringSubmatch(rawURL)
+	if m == nil {
+		return ""
+	}
+	return strings.ToLower(m[1])
+}
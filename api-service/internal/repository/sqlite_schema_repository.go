@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Bharat1Rajput/apiService/internal/model"
+)
+
+// SQLiteSchemaStore is SchemaStore on SQLite, for dispatchgo's
+// single-process mode.
+type SQLiteSchemaStore struct {
+	db *sql.DB
+}
+
+func NewSQLiteSchemaStore(db *sql.DB) *SQLiteSchemaStore {
+	return &SQLiteSchemaStore{db: db}
+}
+
+const sqliteSchemaColumns = `event_type, version, schema, created_at, deprecated_at`
+
+func (s *SQLiteSchemaStore) CreateSchema(ctx context.Context, eventType string, schema []byte) (*model.EventSchema, error) {
+	const query = `
+		INSERT INTO event_schemas (event_type, version, schema, created_at)
+		SELECT ?1, COALESCE(MAX(version), 0) + 1, ?2, ?3
+		FROM event_schemas
+		WHERE event_type = ?1
+		RETURNING ` + sqliteSchemaColumns
+
+	return createVersion(func() (*model.EventSchema, error) {
+		return scanSchema(s.db.QueryRowContext(ctx, query, eventType, string(schema), time.Now().UTC()))
+	}, isSQLiteUniqueViolation)
+}
+
+// isSQLiteUniqueViolation matches the driver's message, as the driver
+// itself is only linked into dispatchgo.
+func isSQLiteUniqueViolation(err error) bool {
+	return strings.Contains(err.Error(), "UNIQUE constraint failed")
+}
+
+func (s *SQLiteSchemaStore) GetSchema(ctx context.Context, eventType string, version int) (*model.EventSchema, error) {
+	query := `SELECT ` + sqliteSchemaColumns + ` FROM event_schemas WHERE event_type = ? AND version = ?`
+
+	es, err := scanSchema(s.db.QueryRowContext(ctx, query, eventType, version))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("repository.schema: get: %w", err)
+	}
+	return es, nil
+}
+
+// LatestSchema returns the newest non-deprecated version of eventType, or
+// the newest version if all of them are deprecated.
+func (s *SQLiteSchemaStore) LatestSchema(ctx context.Context, eventType string) (*model.EventSchema, error) {
+	query := `
+		SELECT ` + sqliteSchemaColumns + `
+		FROM event_schemas
+		WHERE event_type = ?
+		ORDER BY deprecated_at IS NULL DESC, version DESC
+		LIMIT 1
+	`
+	es, err := scanSchema(s.db.QueryRowContext(ctx, query, eventType))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("repository.schema: latest: %w", err)
+	}
+	return es, nil
+}
+
+func (s *SQLiteSchemaStore) ListSchemas(ctx context.Context, eventType string) ([]model.EventSchema, error) {
+	query := `SELECT ` + sqliteSchemaColumns + ` FROM event_schemas WHERE event_type = ? ORDER BY version`
+
+	rows, err := s.db.QueryContext(ctx, query, eventType)
+	if err != nil {
+		return nil, fmt.Errorf("repository.schema: list: %w", err)
+	}
+	defer rows.Close()
+
+	schemas := []model.EventSchema{}
+	for rows.Next() {
+		es, err := scanSchema(rows)
+		if err != nil {
+			return nil, fmt.Errorf("repository.schema: scan: %w", err)
+		}
+		schemas = append(schemas, *es)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("repository.schema: list: %w", err)
+	}
+	return schemas, nil
+}
+
+func (s *SQLiteSchemaStore) DeprecateSchema(ctx context.Context, eventType string, version int) (*model.EventSchema, error) {
+	query := `
+		UPDATE event_schemas
+		SET deprecated_at = COALESCE(deprecated_at, ?)
+		WHERE event_type = ? AND version = ?
+		RETURNING ` + sqliteSchemaColumns
+
+	es, err := scanSchema(s.db.QueryRowContext(ctx, query, time.Now().UTC(), eventType, version))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("repository.schema: deprecate: %w", err)
+	}
+	return es, nil
+}
@@ -0,0 +1,161 @@
+package schema
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+
+	"github.com/Bharat1Rajput/apiService/internal/model"
+	"github.com/Bharat1Rajput/apiService/internal/repository"
+)
+
+var (
+	ErrUnknownVersion = errors.New("schema: unknown schema version")
+	ErrInvalidSchema  = errors.New("schema: invalid JSON Schema")
+	ErrNotJSON        = errors.New("schema: payload is not JSON")
+)
+
+// FieldError is one violation, located by JSON pointer into the payload.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Result reports which schema a payload was checked against and what failed.
+type Result struct {
+	Schema *model.EventSchema
+	Errors []FieldError
+}
+
+func (r *Result) Valid() bool {
+	return len(r.Errors) == 0
+}
+
+// Registry validates payloads against the schemas in a SchemaStore. Published
+// versions are immutable, so compiled schemas are cached for the process lifetime.
+type Registry struct {
+	store repository.SchemaStore
+
+	mu       sync.RWMutex
+	compiled map[string]*jsonschema.Schema
+}
+
+func NewRegistry(store repository.SchemaStore) *Registry {
+	return &Registry{
+		store:    store,
+		compiled: make(map[string]*jsonschema.Schema),
+	}
+}
+
+const schemaURL = "mem://schema.json"
+
+// Compile checks that raw is a usable JSON Schema without storing it.
+func Compile(raw []byte) (*jsonschema.Schema, error) {
+	c := jsonschema.NewCompiler()
+	c.Draft = jsonschema.Draft2020
+	// Schemas come from API callers: never let $ref reach the filesystem or network.
+	c.LoadURL = func(s string) (io.ReadCloser, error) {
+		return nil, fmt.Errorf("external $ref %q is not allowed", s)
+	}
+	if err := c.AddResource(schemaURL, bytes.NewReader(raw)); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSchema, err)
+	}
+	s, err := c.Compile(schemaURL)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSchema, err)
+	}
+	return s, nil
+}
+
+// Validate checks payload against eventType's schema. Version 0 selects the
+// latest non-deprecated version, or the newest version once all of them are
+// deprecated. A nil Result means the event type has no schemas registered
+// and the payload is accepted unchecked.
+func (r *Registry) Validate(ctx context.Context, eventType string, version int, payload []byte) (*Result, error) {
+	var (
+		es  *model.EventSchema
+		err error
+	)
+	if version == 0 {
+		es, err = r.store.LatestSchema(ctx, eventType)
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, nil
+		}
+	} else {
+		es, err = r.store.GetSchema(ctx, eventType, version)
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrUnknownVersion
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	compiled, err := r.compiledFor(es)
+	if err != nil {
+		return nil, err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(payload))
+	dec.UseNumber()
+	var doc any
+	if err := dec.Decode(&doc); err != nil {
+		return nil, ErrNotJSON
+	}
+
+	res := &Result{Schema: es}
+	if err := compiled.Validate(doc); err != nil {
+		var ve *jsonschema.ValidationError
+		if !errors.As(err, &ve) {
+			return nil, fmt.Errorf("schema: validate: %w", err)
+		}
+		res.Errors = fieldErrors(ve)
+	}
+	return res, nil
+}
+
+func (r *Registry) compiledFor(es *model.EventSchema) (*jsonschema.Schema, error) {
+	key := es.EventType + "@" + strconv.Itoa(es.Version)
+
+	r.mu.RLock()
+	s, ok := r.compiled[key]
+	r.mu.RUnlock()
+	if ok {
+		return s, nil
+	}
+
+	s, err := Compile(es.Schema)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	r.compiled[key] = s
+	r.mu.Unlock()
+	return s, nil
+}
+
+// fieldErrors flattens the validation tree to its leaves, which carry the
+// specific failing keyword for each offending field.
+func fieldErrors(ve *jsonschema.ValidationError) []FieldError {
+	if len(ve.Causes) == 0 {
+		field := ve.InstanceLocation
+		if field == "" {
+			field = "/"
+		}
+		return []FieldError{{Field: field, Message: ve.Message}}
+	}
+
+	var out []FieldError
+	for _, c := range ve.Causes {
+		out = append(out, fieldErrors(c)...)
+	}
+	return out
+}
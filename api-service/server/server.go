@@ -0,0 +1,202 @@
+// Package server assembles api-service's HTTP API. cmd/api runs it on its
+// own; dispatchgo runs it next to the worker pool in one process.
+package server
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+
+	"github.com/Bharat1Rajput/apiService/internal/config"
+	"github.com/Bharat1Rajput/apiService/internal/dashboard"
+	"github.com/Bharat1Rajput/apiService/internal/events"
+	"github.com/Bharat1Rajput/apiService/internal/handler"
+	"github.com/Bharat1Rajput/apiService/internal/middleware"
+	"github.com/Bharat1Rajput/apiService/internal/repository"
+	"github.com/Bharat1Rajput/apiService/internal/schema"
+	"github.com/Bharat1Rajput/blob"
+	"github.com/Bharat1Rajput/broker"
+	"github.com/Bharat1Rajput/ssrf"
+)
+
+// Config is api-service's configuration, read from the environment.
+type Config = config.Config
+
+// LoadConfig reads Config from the environment.
+func LoadConfig() (*Config, error) {
+	return config.Load()
+}
+
+// Storage is where the API records and looks up jobs and event schemas.
+// Without it, job lookup, the dashboard and the schema endpoints are
+// disabled.
+type Storage struct {
+	jobs     repository.JobReader
+	recorder repository.JobRecorder
+	retrier  repository.JobRetrier
+	schemas  repository.SchemaStore
+	// eventsURL is the Postgres connection LISTENed on for job status
+	// streams. Without it the stream endpoints are disabled.
+	eventsURL string
+}
+
+// PostgresStorage uses the worker-service database at db, which was opened
+// from url. Accepted jobs are recorded there as pending.
+func PostgresStorage(db *sql.DB, url string) *Storage {
+	reader := repository.NewPostgresJobReader(db)
+	return &Storage{
+		jobs:      reader,
+		recorder:  reader,
+		retrier:   reader,
+		schemas:   repository.NewPostgresSchemaStore(db),
+		eventsURL: url,
+	}
+}
+
+// SQLiteStorage uses a dispatchgo SQLite database. SQLite has no
+// LISTEN/NOTIFY, so job status streams are disabled.
+func SQLiteStorage(db *sql.DB) *Storage {
+	reader := repository.NewSQLiteJobReader(db)
+	return &Storage{
+		jobs:     reader,
+		recorder: reader,
+		retrier:  reader,
+		schemas:  repository.NewSQLiteSchemaStore(db),
+	}
+}
+
+// Server is the HTTP API, ready to Run.
+type Server struct {
+	cfg       *Config
+	hub       *events.Hub
+	eventsURL string
+	srv       *http.Server
+	logger    *zap.Logger
+}
+
+// New builds the API, publishing accepted jobs to pub. store may be nil.
+func New(cfg *Config, pub broker.Publisher, store *Storage, logger *zap.Logger) (*Server, error) {
+	if store == nil {
+		store = &Storage{}
+		logger.Warn("DATABASE_URL not set, job lookup and schema endpoints disabled")
+	}
+
+	guard, err := ssrf.New(cfg.SSRFDenyCIDRs, cfg.SSRFAllowCIDRs)
+	if err != nil {
+		return nil, err
+	}
+
+	blobs, err := blob.Open(cfg.BlobStore, cfg.BlobLocalDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var hub *events.Hub
+	if store.eventsURL != "" {
+		hub = events.NewHub(logger)
+	}
+
+	srv := &http.Server{Addr: ":" + cfg.AppPort}
+
+	r := chi.NewRouter()
+	r.Use(middleware.RequestLogger(logger))
+	r.Get("/health", handler.HealthHandler)
+
+	producerAuth := middleware.HMACAuth(cfg.HMACSecret, logger)
+	var adminAuth func(http.Handler) http.Handler
+	if cfg.AdminUser != "" && cfg.AdminPassword != "" {
+		adminAuth = middleware.AdminAuth(cfg.AdminUser, cfg.AdminPassword)
+	}
+
+	if store.jobs != nil && adminAuth != nil {
+		dash, err := dashboard.NewHandler(store.jobs, store.retrier, pub, logger)
+		if err != nil {
+			return nil, err
+		}
+		r.With(adminAuth).Mount(dashboard.Prefix, dash.Routes())
+	} else {
+		logger.Info("dashboard disabled, it needs DATABASE_URL, ADMIN_USER and ADMIN_PASSWORD")
+	}
+
+	var registry *schema.Registry
+	if store.schemas != nil {
+		registry = schema.NewRegistry(store.schemas)
+		r.Mount("/schemas", handler.NewSchemaHandler(store.schemas, logger).Routes(producerAuth, adminAuth))
+		if adminAuth == nil {
+			logger.Info("schema publishing disabled, it needs ADMIN_USER and ADMIN_PASSWORD")
+		}
+	}
+
+	r.Group(func(r chi.Router) {
+		r.Use(producerAuth)
+		if hub != nil {
+			stream := handler.NewEventHandler(hub, store.jobs, logger)
+			srv.RegisterOnShutdown(stream.Stop)
+			r.Get("/webhooks/stream", stream.HandleStream)
+			r.Get("/webhooks/{id}/events", stream.HandleJobEvents)
+		}
+		r.Mount("/", handler.NewWebhookHandler(cfg, pub, store.jobs, store.recorder, guard, blobs, registry, logger).Routes())
+	})
+
+	srv.Handler = r
+	return &Server{
+		cfg:       cfg,
+		hub:       hub,
+		eventsURL: store.eventsURL,
+		srv:       srv,
+		logger:    logger,
+	}, nil
+}
+
+// Handler returns the API's routes, for serving them other than with Run,
+// such as from an httptest.Server in tests.
+func (s *Server) Handler() http.Handler {
+	return s.srv.Handler
+}
+
+// Run serves the API until ctx ends, then shuts it down gracefully, waiting
+// up to SHUTDOWN_TIMEOUT_SEC for requests in flight.
+func (s *Server) Run(ctx context.Context) error {
+	// Open event streams are ended by the handler's Stop, which Shutdown
+	// calls; the listener feeding them runs until Run returns.
+	listenCtx, stopListening := context.WithCancel(context.Background())
+	defer stopListening()
+
+	if s.hub != nil {
+		go func() {
+			if err := s.hub.Listen(listenCtx, s.eventsURL); err != nil {
+				s.logger.Error("job event listener stopped", zap.Error(err))
+			}
+		}()
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		s.logger.Info("api-service starting", zap.String("addr", s.srv.Addr))
+		if err := s.srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			errCh <- err
+		}
+	}()
+
+	select {
+	case <-ctx.Done():
+		s.logger.Info("api-service shutting down")
+	case err := <-errCh:
+		return err
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), time.Duration(s.cfg.ShutdownTimeoutSec)*time.Second)
+	defer cancel()
+
+	if err := s.srv.Shutdown(shutdownCtx); err != nil {
+		s.logger.Error("server shutdown error", zap.Error(err))
+		return err
+	}
+
+	s.logger.Info("api-service stopped gracefully")
+	return nil
+}
@@ -0,0 +1,64 @@
+package blob
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestLocalStoreRoundTrip(t *testing.T) {
+	s, err := NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	if err := s.Put(ctx, "payloads/a.bin", strings.NewReader("hello"), 5); err != nil {
+		t.Fatal(err)
+	}
+	rc, err := s.Get(ctx, "payloads/a.bin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := io.ReadAll(rc)
+	rc.Close()
+	if err != nil || string(got) != "hello" {
+		t.Fatalf("Get = %q, %v", got, err)
+	}
+
+	if err := s.Delete(ctx, "payloads/a.bin"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Get(ctx, "payloads/a.bin"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get after Delete error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestLocalStoreRejectsEscapingKeys(t *testing.T) {
+	s, err := NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"", "/", "../etc/passwd", "a/../../b"} {
+		if _, err := s.Get(context.Background(), key); err == nil || errors.Is(err, ErrNotFound) {
+			t.Errorf("Get(%q) error = %v, want an invalid key error", key, err)
+		}
+	}
+}
+
+func TestVerify(t *testing.T) {
+	sum := sha256.Sum256([]byte("hello"))
+	ref := Ref{Key: "k", Size: 5, SHA256: hex.EncodeToString(sum[:])}
+
+	if _, err := io.ReadAll(Verify(strings.NewReader("hello"), ref)); err != nil {
+		t.Fatalf("intact blob: %v", err)
+	}
+	for _, body := range []string{"hell", "jello"} {
+		if _, err := io.ReadAll(Verify(strings.NewReader(body), ref)); !errors.Is(err, ErrCorrupt) {
+			t.Errorf("Verify(%q) error = %v, want ErrCorrupt", body, err)
+		}
+	}
+}
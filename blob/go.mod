@@ -0,0 +1,3 @@
+module github.com/Bharat1Rajput/blob
+
+go 1.22
@@ -0,0 +1,19 @@
+package blob
+
+import "fmt"
+
+// Open returns the Store selected by backend, or nil when backend is empty
+// and payload offloading is disabled.
+func Open(backend, localDir string) (Store, error) {
+	switch backend {
+	case "":
+		return nil, nil
+	case "local":
+		if localDir == "" {
+			return nil, fmt.Errorf("blob: BLOB_LOCAL_DIR is required for the local store")
+		}
+		return NewLocalStore(localDir)
+	default:
+		return nil, fmt.Errorf("blob: unknown store %q", backend)
+	}
+}
@@ -0,0 +1,50 @@
+package blob
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+)
+
+// ErrCorrupt is returned when a blob does not match its recorded size or
+// checksum.
+var ErrCorrupt = errors.New("blob: corrupt")
+
+type verifyingReader struct {
+	r      io.Reader
+	h      hash.Hash
+	n      int64
+	ref    Ref
+	failed error
+}
+
+// Verify wraps r so that reaching EOF fails with an error unless exactly
+// ref.Size bytes with checksum ref.SHA256 were read. Streaming the body into
+// an HTTP request through it aborts the request on corruption.
+func Verify(r io.Reader, ref Ref) io.Reader {
+	return &verifyingReader{r: r, h: sha256.New(), ref: ref}
+}
+
+func (v *verifyingReader) Read(p []byte) (int, error) {
+	if v.failed != nil {
+		return 0, v.failed
+	}
+	n, err := v.r.Read(p)
+	v.n += int64(n)
+	v.h.Write(p[:n])
+
+	if err == io.EOF {
+		if v.n != v.ref.Size {
+			v.failed = fmt.Errorf("%w: size mismatch for %s: got %d, want %d", ErrCorrupt, v.ref.Key, v.n, v.ref.Size)
+			return n, v.failed
+		}
+		if sum := hex.EncodeToString(v.h.Sum(nil)); sum != v.ref.SHA256 {
+			v.failed = fmt.Errorf("%w: checksum mismatch for %s", ErrCorrupt, v.ref.Key)
+			return n, v.failed
+		}
+	}
+	return n, err
+}
@@ -0,0 +1,177 @@
+// Package broker is the job queue shared by api-service and worker-service.
+// It hides the message broker behind Publisher and Consumer so DispatchGo can
+// run on RabbitMQ (the default), NATS JetStream, Redis Streams or a Postgres
+// table, or on an in-process queue when everything runs in one process.
+package broker
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	BackendRabbitMQ = "rabbitmq"
+	BackendNATS     = "nats"
+	BackendRedis    = "redis"
+	BackendPostgres = "postgres"
+	BackendMemory   = "memory"
+)
+
+// Config selects a backend and configures it. Only the fields of the
+// selected backend are read.
+type Config struct {
+	Backend string
+
+	RabbitURL         string
+	RabbitExchange    string
+	RabbitQueue       string
+	RabbitRoutingKey  string
+	RabbitMaxPriority int
+
+	NATSURL     string
+	NATSStream  string
+	NATSSubject string
+
+	RedisURL    string
+	RedisStream string
+
+	// PostgresURL is the database holding the job_queue table.
+	PostgresURL string
+
+	// ConsumerName names the consumer: the RabbitMQ consumer tag, the
+	// JetStream durable consumer and the Redis consumer group. Redis and
+	// Postgres consumers add the host and process to tell workers apart.
+	ConsumerName string
+	// Prefetch bounds how many messages a consumer holds unacknowledged.
+	Prefetch int
+	// AckWait is how long NATS, Redis and Postgres wait on a consumer that has stopped
+	// responding before redelivering its unacknowledged messages. Messages
+	// being worked on are kept alive automatically, however long that takes.
+	AckWait time.Duration
+}
+
+// Validate checks that the selected backend is known and that its
+// connection URL is set. Errors name the environment variables both services
+// read these settings from.
+func (c Config) Validate() error {
+	switch c.Backend {
+	case BackendRabbitMQ, "":
+		if c.RabbitURL == "" {
+			return fmt.Errorf("broker: RABBITMQ_URL is required")
+		}
+	case BackendNATS:
+		if c.NATSURL == "" {
+			return fmt.Errorf("broker: NATS_URL is required with BROKER=nats")
+		}
+	case BackendRedis:
+		if c.RedisURL == "" {
+			return fmt.Errorf("broker: REDIS_URL is required with BROKER=redis")
+		}
+	case BackendPostgres:
+		if c.PostgresURL == "" {
+			return fmt.Errorf("broker: DATABASE_URL is required with BROKER=postgres")
+		}
+	case BackendMemory:
+		// In-process only; whether that is usable is up to the caller.
+	default:
+		return fmt.Errorf("broker: unknown BROKER %q (rabbitmq, nats, redis, postgres or memory)", c.Backend)
+	}
+	return nil
+}
+
+type Publisher interface {
+	// Publish queues body. priority is honoured by RabbitMQ priority queues
+	// (see RabbitMaxPriority), Postgres and the in-process queue, and ignored
+	// by NATS and Redis.
+	Publish(ctx context.Context, body []byte, priority uint8) error
+	Close() error
+}
+
+// Delivery is one received message. Exactly one of Ack, Reject or Requeue
+// must be called on it.
+type Delivery interface {
+	Body() []byte
+	Ack() error
+	// Reject drops the message. RabbitMQ dead-letters it if the queue has a
+	// dead-letter exchange.
+	Reject() error
+	// Requeue returns the message to the queue to be delivered again, to any
+	// consumer, no sooner than delay.
+	Requeue(delay time.Duration) error
+}
+
+type Consumer interface {
+	// Consume starts receiving. The channel is closed after Cancel, or when
+	// the connection to the broker is lost.
+	Consume(ctx context.Context) (<-chan Delivery, error)
+	// Cancel stops new deliveries. Messages already received can still be
+	// settled until Close.
+	Cancel() error
+	Close() error
+}
+
+// Stats is a point-in-time view of the job queue. Pending counts messages
+// delivered but not yet settled, or is -1 if the backend does not report it.
+type Stats struct {
+	Name      string
+	Ready     int
+	Pending   int
+	Consumers int
+}
+
+// NewPublisher connects to the configured backend and declares the queue.
+func NewPublisher(ctx context.Context, cfg Config, logger *zap.Logger) (Publisher, error) {
+	switch cfg.Backend {
+	case BackendRabbitMQ, "":
+		return NewRabbitPublisher(cfg, logger)
+	case BackendNATS:
+		return NewNATSPublisher(ctx, cfg)
+	case BackendRedis:
+		return NewRedisPublisher(ctx, cfg)
+	case BackendPostgres:
+		return NewPostgresPublisher(ctx, cfg)
+	case BackendMemory:
+		return memory, nil
+	default:
+		return nil, fmt.Errorf("broker: unknown backend %q", cfg.Backend)
+	}
+}
+
+// NewConsumer connects to the configured backend and declares the queue.
+func NewConsumer(ctx context.Context, cfg Config, logger *zap.Logger) (Consumer, error) {
+	switch cfg.Backend {
+	case BackendRabbitMQ, "":
+		return NewRabbitConsumer(cfg, logger)
+	case BackendNATS:
+		return NewNATSConsumer(ctx, cfg, logger)
+	case BackendRedis:
+		return NewRedisConsumer(ctx, cfg, logger)
+	case BackendPostgres:
+		return NewPostgresConsumer(ctx, cfg, logger)
+	case BackendMemory:
+		return memory.NewConsumer(cfg.Prefetch), nil
+	default:
+		return nil, fmt.Errorf("broker: unknown backend %q", cfg.Backend)
+	}
+}
+
+// QueueStats reports the depth of the configured queue without consuming.
+func QueueStats(ctx context.Context, cfg Config) (Stats, error) {
+	switch cfg.Backend {
+	case BackendRabbitMQ, "":
+		return rabbitStats(cfg)
+	case BackendNATS:
+		return natsStats(ctx, cfg)
+	case BackendRedis:
+		return redisStats(ctx, cfg)
+	case BackendPostgres:
+		return postgresStats(ctx, cfg)
+	case BackendMemory:
+		return memory.Stats(), nil
+	default:
+		return Stats{}, fmt.Errorf("broker: unknown backend %q", cfg.Backend)
+	}
+}
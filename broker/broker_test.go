@@ -0,0 +1,188 @@
+package broker
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidate(t *testing.T) {
+	for _, tc := range []struct {
+		cfg  Config
+		want string
+	}{
+		{Config{Backend: BackendRabbitMQ, RabbitURL: "amqp://localhost"}, ""},
+		{Config{RabbitURL: "amqp://localhost"}, ""},
+		{Config{Backend: BackendMemory}, ""},
+		{Config{Backend: BackendRabbitMQ}, "RABBITMQ_URL"},
+		{Config{Backend: BackendNATS}, "NATS_URL"},
+		{Config{Backend: BackendRedis}, "REDIS_URL"},
+		{Config{Backend: BackendPostgres}, "DATABASE_URL"},
+		{Config{Backend: "kafka"}, "unknown BROKER"},
+	} {
+		err := tc.cfg.Validate()
+		switch {
+		case tc.want == "" && err != nil:
+			t.Errorf("Validate(%q) = %v, want nil", tc.cfg.Backend, err)
+		case tc.want != "" && (err == nil || !strings.Contains(err.Error(), tc.want)):
+			t.Errorf("Validate(%q) = %v, want an error naming %s", tc.cfg.Backend, err, tc.want)
+		}
+	}
+}
+
+func TestDelayBucket(t *testing.T) {
+	for _, tc := range []struct {
+		delay, want time.Duration
+	}{
+		{time.Millisecond, time.Second},
+		{time.Second, time.Second},
+		{1500 * time.Millisecond, 2 * time.Second},
+		{33 * time.Second, 64 * time.Second},
+		{64 * time.Second, 64 * time.Second},
+		{maxDelayBucket, maxDelayBucket},
+		{24 * time.Hour, maxDelayBucket},
+	} {
+		if got := delayBucket(tc.delay); got != tc.want {
+			t.Errorf("delayBucket(%v) = %v, want %v", tc.delay, got, tc.want)
+		}
+	}
+}
+
+func TestMemoryQueue(t *testing.T) {
+	q := NewMemoryQueue()
+	testBackend(t, q, func() Consumer { return q.NewConsumer(4) })
+}
+
+func TestMemoryPriority(t *testing.T) {
+	q := NewMemoryQueue()
+	ctx := context.Background()
+	for _, p := range []uint8{1, 5, 3} {
+		if err := q.Publish(ctx, []byte{'0' + p}, p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	c := q.NewConsumer(1)
+	defer c.Close()
+	deliveries, err := c.Consume(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got string
+	for range 3 {
+		d := receive(t, deliveries)
+		got += string(d.Body())
+		if err := d.Ack(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got != "531" {
+		t.Fatalf("delivery order = %s, want 531", got)
+	}
+}
+
+func TestMemoryCloseReturnsUnsettled(t *testing.T) {
+	q := NewMemoryQueue()
+	ctx := context.Background()
+	if err := q.Publish(ctx, []byte("a"), 0); err != nil {
+		t.Fatal(err)
+	}
+
+	c := q.NewConsumer(1)
+	deliveries, err := c.Consume(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := receive(t, deliveries)
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Ack(); err == nil {
+		t.Fatal("Ack after Close succeeded")
+	}
+	if s := q.Stats(); s.Ready != 1 || s.Pending != 0 {
+		t.Fatalf("stats after Close = %+v, want the message ready again", s)
+	}
+}
+
+// testBackend checks the behaviour every backend shares. The queue behind
+// pub and newConsumer must start empty.
+func testBackend(t *testing.T, pub Publisher, newConsumer func() Consumer) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	c := newConsumer()
+	defer c.Close()
+	deliveries, err := c.Consume(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := pub.Publish(ctx, []byte("ack"), 0); err != nil {
+		t.Fatal(err)
+	}
+	d := receive(t, deliveries)
+	if string(d.Body()) != "ack" {
+		t.Fatalf("body = %q, want ack", d.Body())
+	}
+	if err := d.Ack(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := pub.Publish(ctx, []byte("requeue"), 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := receive(t, deliveries).Requeue(0); err != nil {
+		t.Fatal(err)
+	}
+	if err := receive(t, deliveries).Ack(); err != nil {
+		t.Fatal(err)
+	}
+
+	const delay = 2 * time.Second
+	if err := pub.Publish(ctx, []byte("delay"), 0); err != nil {
+		t.Fatal(err)
+	}
+	requeued := time.Now()
+	if err := receive(t, deliveries).Requeue(delay); err != nil {
+		t.Fatal(err)
+	}
+	d = receive(t, deliveries)
+	if waited := time.Since(requeued); waited < delay {
+		t.Errorf("redelivered after %v, want at least %v", waited, delay)
+	}
+	if string(d.Body()) != "delay" {
+		t.Fatalf("body = %q, want delay", d.Body())
+	}
+	if err := d.Ack(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := pub.Publish(ctx, []byte("reject"), 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := receive(t, deliveries).Reject(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case d := <-deliveries:
+		t.Fatalf("rejected message redelivered: %q", d.Body())
+	case <-time.After(2 * time.Second):
+	}
+}
+
+func receive(t *testing.T, deliveries <-chan Delivery) Delivery {
+	t.Helper()
+	select {
+	case d, ok := <-deliveries:
+		if !ok {
+			t.Fatal("deliveries closed")
+		}
+		return d
+	case <-time.After(30 * time.Second):
+		t.Fatal("no delivery within 30s")
+	}
+	return nil
+}
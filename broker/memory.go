@@ -0,0 +1,264 @@
+package broker
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// errSettled is returned when a delivery is settled twice, or after its
+// consumer was closed and returned it to the queue.
+var errSettled = errors.New("broker.memory: delivery already settled")
+
+// memory is the queue shared by every publisher and consumer created with
+// BackendMemory in this process.
+var memory = NewMemoryQueue()
+
+// MemoryQueue is an in-process queue for tests and for running the API and
+// workers in one process. Messages are lost when the process exits.
+// Priorities are honoured: higher priorities are delivered first.
+type MemoryQueue struct {
+	mu        sync.Mutex
+	ready     memoryHeap
+	seq       uint64
+	pending   int
+	consumers int
+	// arrived is closed, and replaced, whenever a message becomes ready.
+	arrived chan struct{}
+}
+
+func NewMemoryQueue() *MemoryQueue {
+	return &MemoryQueue{arrived: make(chan struct{})}
+}
+
+func (q *MemoryQueue) Publish(_ context.Context, body []byte, priority uint8) error {
+	q.push(append([]byte(nil), body...), priority)
+	return nil
+}
+
+// Close does nothing: the queue lives as long as it is referenced.
+func (q *MemoryQueue) Close() error { return nil }
+
+// NewConsumer returns a consumer holding at most prefetch messages
+// unsettled.
+func (q *MemoryQueue) NewConsumer(prefetch int) *MemoryConsumer {
+	return &MemoryConsumer{
+		queue:     q,
+		prefetch:  max(prefetch, 1),
+		cancelled: make(chan struct{}),
+		slot:      make(chan struct{}, 1),
+		inflight:  make(map[*memoryDelivery]struct{}),
+	}
+}
+
+func (q *MemoryQueue) Stats() Stats {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return Stats{
+		Name:      BackendMemory,
+		Ready:     q.ready.Len(),
+		Pending:   q.pending,
+		Consumers: q.consumers,
+	}
+}
+
+func (q *MemoryQueue) push(body []byte, priority uint8) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.seq++
+	heap.Push(&q.ready, &memoryMessage{body: body, priority: priority, seq: q.seq})
+	close(q.arrived)
+	q.arrived = make(chan struct{})
+}
+
+// take removes the next message, or returns a channel closed when one
+// arrives.
+func (q *MemoryQueue) take() (*memoryMessage, <-chan struct{}) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.ready.Len() == 0 {
+		return nil, q.arrived
+	}
+	q.pending++
+	return heap.Pop(&q.ready).(*memoryMessage), nil
+}
+
+// settle ends a delivery, putting msg back after delay if requeue is set.
+func (q *MemoryQueue) settle(msg *memoryMessage, requeue bool, delay time.Duration) {
+	q.mu.Lock()
+	q.pending--
+	q.mu.Unlock()
+
+	switch {
+	case !requeue:
+	case delay <= 0:
+		q.push(msg.body, msg.priority)
+	default:
+		time.AfterFunc(delay, func() { q.push(msg.body, msg.priority) })
+	}
+}
+
+type MemoryConsumer struct {
+	queue    *MemoryQueue
+	prefetch int
+
+	cancelOnce sync.Once
+	cancelled  chan struct{}
+	// slot is signalled when a delivery is settled.
+	slot chan struct{}
+
+	mu       sync.Mutex
+	inflight map[*memoryDelivery]struct{}
+}
+
+func (c *MemoryConsumer) Consume(ctx context.Context) (<-chan Delivery, error) {
+	c.queue.mu.Lock()
+	c.queue.consumers++
+	c.queue.mu.Unlock()
+
+	out := make(chan Delivery)
+	go func() {
+		defer close(out)
+		defer func() {
+			c.queue.mu.Lock()
+			c.queue.consumers--
+			c.queue.mu.Unlock()
+		}()
+
+		for {
+			if c.inflightCount() >= c.prefetch {
+				select {
+				case <-c.slot:
+				case <-c.cancelled:
+					return
+				case <-ctx.Done():
+					return
+				}
+				continue
+			}
+
+			msg, arrived := c.queue.take()
+			if msg == nil {
+				select {
+				case <-arrived:
+				case <-c.cancelled:
+					return
+				case <-ctx.Done():
+					return
+				}
+				continue
+			}
+
+			d := &memoryDelivery{msg: msg, consumer: c}
+			c.mu.Lock()
+			c.inflight[d] = struct{}{}
+			c.mu.Unlock()
+
+			select {
+			case out <- d:
+			case <-c.cancelled:
+				_ = d.Requeue(0)
+				return
+			case <-ctx.Done():
+				_ = d.Requeue(0)
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+func (c *MemoryConsumer) Cancel() error {
+	c.cancelOnce.Do(func() { close(c.cancelled) })
+	return nil
+}
+
+// Close returns unsettled deliveries to the queue, as a broker does when a
+// consumer's connection closes.
+func (c *MemoryConsumer) Close() error {
+	_ = c.Cancel()
+
+	c.mu.Lock()
+	unsettled := make([]*memoryDelivery, 0, len(c.inflight))
+	for d := range c.inflight {
+		unsettled = append(unsettled, d)
+	}
+	c.inflight = make(map[*memoryDelivery]struct{})
+	c.mu.Unlock()
+
+	for _, d := range unsettled {
+		c.queue.settle(d.msg, true, 0)
+	}
+	return nil
+}
+
+func (c *MemoryConsumer) inflightCount() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.inflight)
+}
+
+func (c *MemoryConsumer) settle(d *memoryDelivery, requeue bool, delay time.Duration) error {
+	c.mu.Lock()
+	if _, ok := c.inflight[d]; !ok {
+		c.mu.Unlock()
+		return errSettled
+	}
+	delete(c.inflight, d)
+	c.mu.Unlock()
+
+	c.queue.settle(d.msg, requeue, delay)
+	select {
+	case c.slot <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+type memoryDelivery struct {
+	msg      *memoryMessage
+	consumer *MemoryConsumer
+}
+
+func (d *memoryDelivery) Body() []byte { return d.msg.body }
+
+func (d *memoryDelivery) Ack() error { return d.consumer.settle(d, false, 0) }
+
+func (d *memoryDelivery) Reject() error { return d.consumer.settle(d, false, 0) }
+
+func (d *memoryDelivery) Requeue(delay time.Duration) error {
+	return d.consumer.settle(d, true, delay)
+}
+
+type memoryMessage struct {
+	body     []byte
+	priority uint8
+	seq      uint64
+}
+
+// memoryHeap orders messages by priority, highest first, then by arrival.
+type memoryHeap []*memoryMessage
+
+func (h memoryHeap) Len() int { return len(h) }
+
+func (h memoryHeap) Less(i, j int) bool {
+	if h[i].priority != h[j].priority {
+		return h[i].priority > h[j].priority
+	}
+	return h[i].seq < h[j].seq
+}
+
+func (h memoryHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *memoryHeap) Push(x any) { *h = append(*h, x.(*memoryMessage)) }
+
+func (h *memoryHeap) Pop() any {
+	old := *h
+	n := len(old)
+	m := old[n-1]
+	old[n-1] = nil
+	*h = old[:n-1]
+	return m
+}
@@ -0,0 +1,254 @@
+package broker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"go.uber.org/zap"
+)
+
+type NATSPublisher struct {
+	conn    *nats.Conn
+	js      jetstream.JetStream
+	subject string
+}
+
+// NewNATSPublisher connects and creates the work-queue stream if needed.
+func NewNATSPublisher(ctx context.Context, cfg Config) (*NATSPublisher, error) {
+	conn, js, err := connectNATS(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &NATSPublisher{conn: conn, js: js, subject: cfg.NATSSubject}, nil
+}
+
+// Publish waits for the stream to acknowledge the message, so it is stored
+// before the API answers the submission.
+func (p *NATSPublisher) Publish(ctx context.Context, body []byte, _ uint8) error {
+	if _, err := p.js.Publish(ctx, p.subject, body); err != nil {
+		return fmt.Errorf("broker.nats: publish: %w", err)
+	}
+	return nil
+}
+
+func (p *NATSPublisher) Close() error {
+	p.conn.Close()
+	return nil
+}
+
+type NATSConsumer struct {
+	conn     *nats.Conn
+	consumer jetstream.Consumer
+	prefetch int
+	ackWait  time.Duration
+	logger   *zap.Logger
+
+	mu       sync.Mutex
+	iter     jetstream.MessagesContext
+	inflight map[jetstream.Msg]struct{}
+}
+
+// NewNATSConsumer connects and creates the stream and the durable pull
+// consumer shared by all workers.
+func NewNATSConsumer(ctx context.Context, cfg Config, logger *zap.Logger) (*NATSConsumer, error) {
+	conn, js, err := connectNATS(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	consumer, err := js.CreateOrUpdateConsumer(ctx, cfg.NATSStream, jetstream.ConsumerConfig{
+		Durable:       cfg.ConsumerName,
+		AckPolicy:     jetstream.AckExplicitPolicy,
+		AckWait:       cfg.AckWait,
+		MaxAckPending: cfg.Prefetch,
+		FilterSubject: cfg.NATSSubject,
+	})
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("broker.nats: create consumer: %w", err)
+	}
+
+	return &NATSConsumer{
+		conn:     conn,
+		consumer: consumer,
+		prefetch: cfg.Prefetch,
+		ackWait:  cfg.AckWait,
+		logger:   logger,
+		inflight: make(map[jetstream.Msg]struct{}),
+	}, nil
+}
+
+func (c *NATSConsumer) Consume(ctx context.Context) (<-chan Delivery, error) {
+	iter, err := c.consumer.Messages(jetstream.PullMaxMessages(c.prefetch))
+	if err != nil {
+		return nil, fmt.Errorf("broker.nats: start consume: %w", err)
+	}
+	c.mu.Lock()
+	c.iter = iter
+	c.mu.Unlock()
+
+	stopKeepalive := make(chan struct{})
+	go c.keepalive(stopKeepalive)
+
+	out := make(chan Delivery)
+	go func() {
+		defer close(out)
+		defer close(stopKeepalive)
+		for {
+			msg, err := iter.Next()
+			if err != nil {
+				if !errors.Is(err, jetstream.ErrMsgIteratorClosed) {
+					c.logger.Error("broker.nats: receive", zap.Error(err))
+				}
+				return
+			}
+			c.track(msg, true)
+
+			d := &natsDelivery{msg: msg, consumer: c}
+			select {
+			case out <- d:
+			case <-ctx.Done():
+				_ = d.Requeue(0)
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+// Cancel stops pulling. Messages buffered but not yet handed out are
+// redelivered once AckWait passes.
+func (c *NATSConsumer) Cancel() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.iter != nil {
+		c.iter.Stop()
+	}
+	return nil
+}
+
+func (c *NATSConsumer) Close() error {
+	_ = c.Cancel()
+	c.conn.Close()
+	return nil
+}
+
+// keepalive tells the server that received messages are still being worked
+// on, so a job that outlives AckWait is not redelivered to another worker.
+func (c *NATSConsumer) keepalive(stop <-chan struct{}) {
+	ticker := time.NewTicker(c.ackWait / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+
+		c.mu.Lock()
+		msgs := make([]jetstream.Msg, 0, len(c.inflight))
+		for msg := range c.inflight {
+			msgs = append(msgs, msg)
+		}
+		c.mu.Unlock()
+
+		for _, msg := range msgs {
+			if err := msg.InProgress(); err != nil {
+				c.logger.Warn("broker.nats: extend ack deadline", zap.Error(err))
+			}
+		}
+	}
+}
+
+func (c *NATSConsumer) track(msg jetstream.Msg, add bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if add {
+		c.inflight[msg] = struct{}{}
+	} else {
+		delete(c.inflight, msg)
+	}
+}
+
+type natsDelivery struct {
+	msg      jetstream.Msg
+	consumer *NATSConsumer
+}
+
+func (d *natsDelivery) Body() []byte { return d.msg.Data() }
+
+func (d *natsDelivery) Ack() error {
+	d.consumer.track(d.msg, false)
+	return d.msg.Ack()
+}
+
+// Reject terminates the message so it is never redelivered.
+func (d *natsDelivery) Reject() error {
+	d.consumer.track(d.msg, false)
+	return d.msg.Term()
+}
+
+func (d *natsDelivery) Requeue(delay time.Duration) error {
+	d.consumer.track(d.msg, false)
+	if delay <= 0 {
+		return d.msg.Nak()
+	}
+	return d.msg.NakWithDelay(delay)
+}
+
+// connectNATS connects and creates or updates the stream. The stream uses
+// work-queue retention: a message is removed once a consumer acknowledges it.
+func connectNATS(ctx context.Context, cfg Config) (*nats.Conn, jetstream.JetStream, error) {
+	conn, err := nats.Connect(cfg.NATSURL, nats.MaxReconnects(-1))
+	if err != nil {
+		return nil, nil, fmt.Errorf("broker.nats: connect: %w", err)
+	}
+	js, err := jetstream.New(conn)
+	if err != nil {
+		conn.Close()
+		return nil, nil, fmt.Errorf("broker.nats: jetstream: %w", err)
+	}
+
+	if _, err := js.CreateOrUpdateStream(ctx, jetstream.StreamConfig{
+		Name:      cfg.NATSStream,
+		Subjects:  []string{cfg.NATSSubject},
+		Retention: jetstream.WorkQueuePolicy,
+		Storage:   jetstream.FileStorage,
+	}); err != nil {
+		conn.Close()
+		return nil, nil, fmt.Errorf("broker.nats: create stream %q: %w", cfg.NATSStream, err)
+	}
+	return conn, js, nil
+}
+
+func natsStats(ctx context.Context, cfg Config) (Stats, error) {
+	conn, err := nats.Connect(cfg.NATSURL)
+	if err != nil {
+		return Stats{}, fmt.Errorf("broker.nats: connect: %w", err)
+	}
+	defer conn.Close()
+	js, err := jetstream.New(conn)
+	if err != nil {
+		return Stats{}, fmt.Errorf("broker.nats: jetstream: %w", err)
+	}
+
+	consumer, err := js.Consumer(ctx, cfg.NATSStream, cfg.ConsumerName)
+	if err != nil {
+		return Stats{}, fmt.Errorf("broker.nats: inspect consumer %q: %w", cfg.ConsumerName, err)
+	}
+	info, err := consumer.Info(ctx)
+	if err != nil {
+		return Stats{}, fmt.Errorf("broker.nats: inspect consumer %q: %w", cfg.ConsumerName, err)
+	}
+	return Stats{
+		Name:      cfg.NATSStream,
+		Ready:     int(info.NumPending),
+		Pending:   info.NumAckPending,
+		Consumers: info.NumWaiting,
+	}, nil
+}
@@ -0,0 +1,378 @@
+package broker
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+// The job_queue table, its index and the trigger notifying postgresChannel
+// are created by worker-service migration 0015.
+const postgresChannel = "job_queue"
+
+// postgresPollInterval bounds how long an idle consumer waits without a
+// notification. Rows whose next_attempt_at or lock expires raise none.
+const postgresPollInterval = time.Second
+
+type PostgresPublisher struct {
+	db *sql.DB
+}
+
+func NewPostgresPublisher(ctx context.Context, cfg Config) (*PostgresPublisher, error) {
+	db, err := connectPostgres(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &PostgresPublisher{db: db}, nil
+}
+
+// Publish inserts a row; the table's trigger notifies waiting workers when
+// the insert commits. Higher priorities are claimed first.
+func (p *PostgresPublisher) Publish(ctx context.Context, body []byte, priority uint8) error {
+	_, err := p.db.ExecContext(ctx,
+		`INSERT INTO job_queue (body, priority) VALUES ($1, $2)`, body, int(priority))
+	if err != nil {
+		return fmt.Errorf("broker.postgres: publish: %w", err)
+	}
+	return nil
+}
+
+func (p *PostgresPublisher) Close() error {
+	return p.db.Close()
+}
+
+// PostgresConsumer claims rows with FOR UPDATE SKIP LOCKED, so workers never
+// receive the same row twice while its lock holds. A claimed row is locked
+// for AckWait and the lock is extended every AckWait/2 until the row is
+// settled; rows of a worker that stops responding are claimed by others once
+// their lock lapses.
+type PostgresConsumer struct {
+	db       *sql.DB
+	listener *pq.Listener
+	name     string
+	prefetch int
+	ackWait  time.Duration
+	logger   *zap.Logger
+
+	// wake is signalled, without blocking, for each notification so the
+	// listener never backs up while every slot is busy.
+	wake chan struct{}
+
+	cancelOnce sync.Once
+	cancelled  chan struct{}
+
+	mu       sync.Mutex
+	inflight map[int64]struct{}
+}
+
+func NewPostgresConsumer(ctx context.Context, cfg Config, logger *zap.Logger) (*PostgresConsumer, error) {
+	db, err := connectPostgres(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	listener := pq.NewListener(cfg.PostgresURL, time.Second, time.Minute,
+		func(ev pq.ListenerEventType, err error) {
+			if err != nil {
+				logger.Warn("broker.postgres: listener", zap.Error(err))
+			}
+		})
+	if err := listener.Listen(postgresChannel); err != nil {
+		_ = listener.Close()
+		_ = db.Close()
+		return nil, fmt.Errorf("broker.postgres: listen: %w", err)
+	}
+
+	host, _ := os.Hostname()
+	c := &PostgresConsumer{
+		db:        db,
+		listener:  listener,
+		name:      cfg.ConsumerName + "-" + host + "-" + strconv.Itoa(os.Getpid()),
+		prefetch:  cfg.Prefetch,
+		ackWait:   cfg.AckWait,
+		logger:    logger,
+		wake:      make(chan struct{}, 1),
+		cancelled: make(chan struct{}),
+		inflight:  make(map[int64]struct{}),
+	}
+	go func() {
+		// Notify is closed when the listener is.
+		for range listener.Notify {
+			select {
+			case c.wake <- struct{}{}:
+			default:
+			}
+		}
+	}()
+	return c, nil
+}
+
+func (c *PostgresConsumer) Consume(ctx context.Context) (<-chan Delivery, error) {
+	stopKeepalive := make(chan struct{})
+	go c.keepalive(stopKeepalive)
+
+	out := make(chan Delivery)
+	go func() {
+		defer close(out)
+		defer close(stopKeepalive)
+
+		for {
+			select {
+			case <-c.cancelled:
+				return
+			case <-ctx.Done():
+				return
+			default:
+			}
+
+			free := c.prefetch - c.inflightCount()
+			if free <= 0 {
+				// Wait for settled rows to free a slot.
+				select {
+				case <-time.After(100 * time.Millisecond):
+				case <-c.cancelled:
+					return
+				}
+				continue
+			}
+
+			rows, err := c.claim(ctx, free)
+			if err != nil && ctx.Err() == nil {
+				c.logger.Error("broker.postgres: claim", zap.Error(err))
+			}
+
+			for i, d := range rows {
+				select {
+				case out <- d:
+				case <-c.cancelled:
+					c.requeueAll(rows[i:])
+					return
+				case <-ctx.Done():
+					c.requeueAll(rows[i:])
+					return
+				}
+			}
+
+			// A full batch suggests more rows are waiting.
+			if err == nil && len(rows) == free {
+				continue
+			}
+			select {
+			case <-c.wake:
+			case <-time.After(postgresPollInterval):
+			case <-c.cancelled:
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+// claim locks up to limit claimable rows for this consumer, highest priority
+// first, skipping rows other workers are claiming at the same moment.
+func (c *PostgresConsumer) claim(ctx context.Context, limit int) ([]*postgresDelivery, error) {
+	rows, err := c.db.QueryContext(ctx, `
+		UPDATE job_queue SET
+			locked_by = $1,
+			locked_until = NOW() + $2::interval
+		WHERE id IN (
+			SELECT id FROM job_queue
+			WHERE next_attempt_at <= NOW()
+			  AND (locked_until IS NULL OR locked_until < NOW())
+			ORDER BY priority DESC, next_attempt_at, id
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, body, priority`,
+		c.name, pgInterval(c.ackWait), limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var claimed []*postgresDelivery
+	for rows.Next() {
+		d := &postgresDelivery{consumer: c}
+		if err := rows.Scan(&d.id, &d.body, &d.priority); err != nil {
+			return nil, err
+		}
+		claimed = append(claimed, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// UPDATE ... RETURNING does not keep the subquery's order.
+	sort.Slice(claimed, func(i, j int) bool {
+		if claimed[i].priority != claimed[j].priority {
+			return claimed[i].priority > claimed[j].priority
+		}
+		return claimed[i].id < claimed[j].id
+	})
+	c.mu.Lock()
+	for _, d := range claimed {
+		c.inflight[d.id] = struct{}{}
+	}
+	c.mu.Unlock()
+	return claimed, nil
+}
+
+// keepalive extends the lock of every row this consumer holds, so a job that
+// outlives AckWait is not claimed by another worker.
+func (c *PostgresConsumer) keepalive(stop <-chan struct{}) {
+	ticker := time.NewTicker(c.ackWait / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+
+		ids := c.inflightIDs()
+		if len(ids) == 0 {
+			continue
+		}
+		_, err := c.db.Exec(`
+			UPDATE job_queue SET locked_until = NOW() + $2::interval
+			WHERE locked_by = $1 AND id = ANY($3)`,
+			c.name, pgInterval(c.ackWait), pq.Array(ids),
+		)
+		if err != nil {
+			c.logger.Warn("broker.postgres: extend locks", zap.Error(err))
+		}
+	}
+}
+
+// Cancel stops claiming. Rows claimed but not yet handed out are released.
+func (c *PostgresConsumer) Cancel() error {
+	c.cancelOnce.Do(func() { close(c.cancelled) })
+	return nil
+}
+
+func (c *PostgresConsumer) Close() error {
+	_ = c.Cancel()
+	_ = c.listener.Close()
+	return c.db.Close()
+}
+
+func (c *PostgresConsumer) requeueAll(ds []*postgresDelivery) {
+	for _, d := range ds {
+		if err := d.Requeue(0); err != nil {
+			c.logger.Warn("broker.postgres: requeue", zap.Int64("id", d.id), zap.Error(err))
+		}
+	}
+}
+
+// settle runs query against row id, provided this consumer still holds it.
+// A row whose lock lapsed may have been claimed by another worker, which now
+// owns it.
+func (c *PostgresConsumer) settle(id int64, query string, args ...any) error {
+	c.mu.Lock()
+	delete(c.inflight, id)
+	c.mu.Unlock()
+
+	res, err := c.db.Exec(query, append([]any{id, c.name}, args...)...)
+	if err != nil {
+		return fmt.Errorf("broker.postgres: settle %d: %w", id, err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("broker.postgres: settle %d: lock lost to another consumer", id)
+	}
+	return nil
+}
+
+func (c *PostgresConsumer) inflightCount() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.inflight)
+}
+
+func (c *PostgresConsumer) inflightIDs() []int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ids := make([]int64, 0, len(c.inflight))
+	for id := range c.inflight {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+type postgresDelivery struct {
+	id       int64
+	body     []byte
+	priority int
+	consumer *PostgresConsumer
+}
+
+func (d *postgresDelivery) Body() []byte { return d.body }
+
+func (d *postgresDelivery) Ack() error {
+	return d.consumer.settle(d.id, `DELETE FROM job_queue WHERE id = $1 AND locked_by = $2`)
+}
+
+func (d *postgresDelivery) Reject() error {
+	return d.consumer.settle(d.id, `DELETE FROM job_queue WHERE id = $1 AND locked_by = $2`)
+}
+
+// Requeue releases the row and schedules it no sooner than delay from now.
+func (d *postgresDelivery) Requeue(delay time.Duration) error {
+	return d.consumer.settle(d.id, `
+		UPDATE job_queue SET
+			locked_by = NULL,
+			locked_until = NULL,
+			next_attempt_at = NOW() + $3::interval
+		WHERE id = $1 AND locked_by = $2`,
+		pgInterval(max(delay, 0)),
+	)
+}
+
+func pgInterval(d time.Duration) string {
+	return strconv.FormatInt(d.Milliseconds(), 10) + " milliseconds"
+}
+
+func connectPostgres(ctx context.Context, cfg Config) (*sql.DB, error) {
+	db, err := sql.Open("postgres", cfg.PostgresURL)
+	if err != nil {
+		return nil, fmt.Errorf("broker.postgres: open: %w", err)
+	}
+	if err := db.PingContext(ctx); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("broker.postgres: connect: %w", err)
+	}
+	return db, nil
+}
+
+// postgresStats counts rows by state. Consumers counts only workers holding
+// at least one row.
+func postgresStats(ctx context.Context, cfg Config) (Stats, error) {
+	db, err := connectPostgres(ctx, cfg)
+	if err != nil {
+		return Stats{}, err
+	}
+	defer db.Close()
+
+	stats := Stats{Name: "job_queue"}
+	err = db.QueryRowContext(ctx, `
+		SELECT
+			COUNT(*) FILTER (WHERE next_attempt_at <= NOW() AND (locked_until IS NULL OR locked_until < NOW())),
+			COUNT(*) FILTER (WHERE locked_until >= NOW()),
+			COUNT(DISTINCT locked_by) FILTER (WHERE locked_until >= NOW())
+		FROM job_queue`,
+	).Scan(&stats.Ready, &stats.Pending, &stats.Consumers)
+	if err != nil {
+		return Stats{}, fmt.Errorf("broker.postgres: inspect job_queue: %w", err)
+	}
+	return stats, nil
+}
@@ -0,0 +1,346 @@
+package broker
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"go.uber.org/zap"
+)
+
+type RabbitPublisher struct {
+	conn       *amqp.Connection
+	ch         *amqp.Channel
+	exchange   string
+	routingKey string
+}
+
+// NewRabbitPublisher connects, retrying with backoff while the broker starts
+// up, and declares the job queue.
+func NewRabbitPublisher(cfg Config, logger *zap.Logger) (*RabbitPublisher, error) {
+	var conn *amqp.Connection
+	var err error
+
+	for i := 0; i < 10; i++ {
+		conn, err = amqp.Dial(cfg.RabbitURL)
+		if err == nil {
+			break
+		}
+		backoff := time.Duration(1<<i) * time.Second
+		if backoff > 30*time.Second {
+			backoff = 30 * time.Second
+		}
+		logger.Warn("broker.rabbit: dial failed, retrying",
+			zap.Error(err),
+			zap.Duration("backoff", backoff),
+		)
+		time.Sleep(backoff)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("broker.rabbit: connect: %w", err)
+	}
+
+	ch, err := conn.Channel()
+	if err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("broker.rabbit: channel: %w", err)
+	}
+
+	if err := ch.Confirm(false); err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("broker.rabbit: confirm mode: %w", err)
+	}
+
+	if err := declareRabbit(ch, cfg); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	return &RabbitPublisher{
+		conn:       conn,
+		ch:         ch,
+		exchange:   cfg.RabbitExchange,
+		routingKey: cfg.RabbitRoutingKey,
+	}, nil
+}
+
+func (p *RabbitPublisher) Publish(ctx context.Context, body []byte, priority uint8) error {
+	confirm, err := p.ch.PublishWithDeferredConfirmWithContext(
+		ctx,
+		p.exchange,
+		p.routingKey,
+		false,
+		false,
+		amqp.Publishing{
+			ContentType:  "application/json",
+			Body:         body,
+			DeliveryMode: amqp.Persistent,
+			Priority:     priority,
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("broker.rabbit: publish: %w", err)
+	}
+
+	ok, err := confirm.WaitContext(ctx)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("broker.rabbit: publish not acknowledged")
+	}
+	return nil
+}
+
+func (p *RabbitPublisher) Close() error {
+	if err := p.ch.Close(); err != nil {
+		_ = p.conn.Close()
+		return err
+	}
+	return p.conn.Close()
+}
+
+type RabbitConsumer struct {
+	conn       *amqp.Connection
+	ch         *amqp.Channel
+	queue      string
+	exchange   string
+	routingKey string
+	tag        string
+	logger     *zap.Logger
+}
+
+func NewRabbitConsumer(cfg Config, logger *zap.Logger) (*RabbitConsumer, error) {
+	conn, err := amqp.Dial(cfg.RabbitURL)
+	if err != nil {
+		return nil, fmt.Errorf("broker.rabbit: connect: %w", err)
+	}
+
+	ch, err := conn.Channel()
+	if err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("broker.rabbit: channel: %w", err)
+	}
+
+	// Delayed requeues are republished, and must be confirmed before the
+	// original is acknowledged.
+	if err := ch.Confirm(false); err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("broker.rabbit: confirm mode: %w", err)
+	}
+
+	if err := ch.Qos(cfg.Prefetch, 0, false); err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("broker.rabbit: qos: %w", err)
+	}
+
+	if err := declareRabbit(ch, cfg); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	return &RabbitConsumer{
+		conn:       conn,
+		ch:         ch,
+		queue:      cfg.RabbitQueue,
+		exchange:   cfg.RabbitExchange,
+		routingKey: cfg.RabbitRoutingKey,
+		tag:        cfg.ConsumerName,
+		logger:     logger,
+	}, nil
+}
+
+func (c *RabbitConsumer) Consume(ctx context.Context) (<-chan Delivery, error) {
+	deliveries, err := c.ch.Consume(
+		c.queue,
+		c.tag,
+		false,
+		false,
+		false,
+		false,
+		nil,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("broker.rabbit: start consume: %w", err)
+	}
+
+	out := make(chan Delivery)
+	go func() {
+		defer close(out)
+		for d := range deliveries {
+			select {
+			case out <- &rabbitDelivery{d: d, consumer: c}:
+			case <-ctx.Done():
+				// Unsettled deliveries go back to the queue on Close.
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+func (c *RabbitConsumer) Cancel() error {
+	if err := c.ch.Cancel(c.tag, false); err != nil {
+		return fmt.Errorf("broker.rabbit: cancel consumer: %w", err)
+	}
+	return nil
+}
+
+// Close closes the channel; RabbitMQ returns prefetched deliveries that were
+// never settled to the queue.
+func (c *RabbitConsumer) Close() error {
+	if err := c.ch.Close(); err != nil {
+		_ = c.conn.Close()
+		return err
+	}
+	return c.conn.Close()
+}
+
+type rabbitDelivery struct {
+	d        amqp.Delivery
+	consumer *RabbitConsumer
+}
+
+func (d *rabbitDelivery) Body() []byte  { return d.d.Body }
+func (d *rabbitDelivery) Ack() error    { return d.d.Ack(false) }
+func (d *rabbitDelivery) Reject() error { return d.d.Nack(false, false) }
+
+// Requeue with a delay republishes the message to a delay queue and then
+// acknowledges it. RabbitMQ has no per-message delay without a plugin, so
+// the message waits in a delay queue until its expiration dead-letters it
+// back to the job exchange. There is one delay queue per power of two
+// seconds up to maxDelayBucket, each holding the delays up to its own TTL,
+// and delay queues are deleted once unused for twice their TTL. If the
+// republish fails, the message is requeued without delay rather than held.
+func (d *rabbitDelivery) Requeue(delay time.Duration) error {
+	if delay <= 0 {
+		return d.d.Nack(false, true)
+	}
+	if err := d.consumer.publishDelayed(d.d, delay); err != nil {
+		d.consumer.logger.Warn("broker.rabbit: delayed requeue failed, requeueing now", zap.Error(err))
+		return d.d.Nack(false, true)
+	}
+	return d.d.Ack(false)
+}
+
+// maxDelayBucket is the TTL of the longest delay queue. A longer delay comes
+// back after it, early; the worker then puts the message back for the rest.
+const maxDelayBucket = 4096 * time.Second
+
+// delayBucket returns the TTL of the delay queue for delay: the smallest
+// power of two seconds not below it, at most maxDelayBucket.
+func delayBucket(delay time.Duration) time.Duration {
+	bucket := time.Second
+	for bucket < delay && bucket < maxDelayBucket {
+		bucket *= 2
+	}
+	return bucket
+}
+
+// publishDelayed republishes d to its delay bucket, expiring after delay.
+// RabbitMQ only expires the message at the head of a queue, so one can wait
+// behind a longer delay in the same bucket, though never past the bucket's
+// TTL: it comes back less than twice its delay later.
+func (c *RabbitConsumer) publishDelayed(d amqp.Delivery, delay time.Duration) error {
+	bucket := delayBucket(delay)
+	expiration := (min(delay, bucket) + time.Millisecond - 1) / time.Millisecond
+	name := fmt.Sprintf("%s.delay.%ds", c.queue, int64(bucket/time.Second))
+	if _, err := c.ch.QueueDeclare(name, true, false, false, false, amqp.Table{
+		"x-message-ttl":             bucket.Milliseconds(),
+		"x-expires":                 2*bucket.Milliseconds() + 60_000,
+		"x-dead-letter-exchange":    c.exchange,
+		"x-dead-letter-routing-key": c.routingKey,
+	}); err != nil {
+		return fmt.Errorf("broker.rabbit: declare delay queue: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	confirm, err := c.ch.PublishWithDeferredConfirmWithContext(ctx, "", name, false, false, amqp.Publishing{
+		ContentType:  d.ContentType,
+		Headers:      d.Headers,
+		Body:         d.Body,
+		DeliveryMode: amqp.Persistent,
+		Priority:     d.Priority,
+		Expiration:   strconv.FormatInt(int64(expiration), 10),
+	})
+	if err != nil {
+		return fmt.Errorf("broker.rabbit: publish delayed: %w", err)
+	}
+	ok, err := confirm.WaitContext(ctx)
+	if err != nil {
+		return fmt.Errorf("broker.rabbit: publish delayed: %w", err)
+	}
+	if !ok {
+		return fmt.Errorf("broker.rabbit: delayed publish not acknowledged")
+	}
+	return nil
+}
+
+// declareRabbit declares the exchange and job queue and binds them. A
+// positive RabbitMaxPriority makes it a priority queue (x-max-priority); the
+// API and worker must agree on it, as RabbitMQ refuses to redeclare a queue
+// with different arguments.
+func declareRabbit(ch *amqp.Channel, cfg Config) error {
+	if err := ch.ExchangeDeclare(
+		cfg.RabbitExchange,
+		"topic",
+		true,
+		false,
+		false,
+		false,
+		nil,
+	); err != nil {
+		return fmt.Errorf("broker.rabbit: declare exchange: %w", err)
+	}
+
+	var args amqp.Table
+	if cfg.RabbitMaxPriority > 0 {
+		args = amqp.Table{"x-max-priority": int32(cfg.RabbitMaxPriority)}
+	}
+
+	q, err := ch.QueueDeclare(
+		cfg.RabbitQueue,
+		true,
+		false,
+		false,
+		false,
+		args,
+	)
+	if err != nil {
+		return fmt.Errorf("broker.rabbit: declare queue: %w", err)
+	}
+
+	if err := ch.QueueBind(
+		q.Name,
+		cfg.RabbitRoutingKey,
+		cfg.RabbitExchange,
+		false,
+		nil,
+	); err != nil {
+		return fmt.Errorf("broker.rabbit: bind queue: %w", err)
+	}
+	return nil
+}
+
+// rabbitStats inspects the queue passively. RabbitMQ does not report
+// unacknowledged messages here, so Pending is -1.
+func rabbitStats(cfg Config) (Stats, error) {
+	conn, err := amqp.Dial(cfg.RabbitURL)
+	if err != nil {
+		return Stats{}, fmt.Errorf("broker.rabbit: connect: %w", err)
+	}
+	defer conn.Close()
+	ch, err := conn.Channel()
+	if err != nil {
+		return Stats{}, fmt.Errorf("broker.rabbit: channel: %w", err)
+	}
+
+	q, err := ch.QueueDeclarePassive(cfg.RabbitQueue, true, false, false, false, nil)
+	if err != nil {
+		return Stats{}, fmt.Errorf("broker.rabbit: inspect queue %q: %w", cfg.RabbitQueue, err)
+	}
+	return Stats{Name: q.Name, Ready: q.Messages, Pending: -1, Consumers: q.Consumers}, nil
+}
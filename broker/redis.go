@@ -0,0 +1,382 @@
+package broker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// Messages are stream entries with a single "body" field. Entries delayed by
+// Requeue wait in a sorted set next to the stream, scored by due time, as
+// "<entry id> <body>" so identical bodies do not collapse into one member.
+const redisBodyField = "body"
+
+func redisDelayedKey(stream string) string { return stream + ":delayed" }
+
+// promoteScript moves due delayed entries back onto the stream atomically.
+var promoteScript = redis.NewScript(`
+local due = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, 100)
+for _, member in ipairs(due) do
+	redis.call('ZREM', KEYS[1], member)
+	local sep = string.find(member, ' ', 1, true)
+	redis.call('XADD', KEYS[2], '*', 'body', string.sub(member, sep + 1))
+end
+return #due
+`)
+
+type RedisPublisher struct {
+	client *redis.Client
+	stream string
+}
+
+func NewRedisPublisher(ctx context.Context, cfg Config) (*RedisPublisher, error) {
+	client, err := connectRedis(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &RedisPublisher{client: client, stream: cfg.RedisStream}, nil
+}
+
+func (p *RedisPublisher) Publish(ctx context.Context, body []byte, _ uint8) error {
+	err := p.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: p.stream,
+		Values: map[string]any{redisBodyField: body},
+	}).Err()
+	if err != nil {
+		return fmt.Errorf("broker.redis: publish: %w", err)
+	}
+	return nil
+}
+
+func (p *RedisPublisher) Close() error {
+	return p.client.Close()
+}
+
+// RedisConsumer reads the stream through a consumer group shared by all
+// workers. Each worker is its own group member; entries left pending by a
+// member that stops responding for AckWait are claimed by the others.
+type RedisConsumer struct {
+	client   *redis.Client
+	stream   string
+	group    string
+	name     string
+	prefetch int
+	ackWait  time.Duration
+	logger   *zap.Logger
+
+	cancelOnce sync.Once
+	cancelled  chan struct{}
+
+	mu       sync.Mutex
+	inflight map[string]struct{}
+}
+
+func NewRedisConsumer(ctx context.Context, cfg Config, logger *zap.Logger) (*RedisConsumer, error) {
+	client, err := connectRedis(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	err = client.XGroupCreateMkStream(ctx, cfg.RedisStream, cfg.ConsumerName, "0").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		_ = client.Close()
+		return nil, fmt.Errorf("broker.redis: create group: %w", err)
+	}
+
+	host, _ := os.Hostname()
+	return &RedisConsumer{
+		client:    client,
+		stream:    cfg.RedisStream,
+		group:     cfg.ConsumerName,
+		name:      cfg.ConsumerName + "-" + host + "-" + strconv.Itoa(os.Getpid()),
+		prefetch:  cfg.Prefetch,
+		ackWait:   cfg.AckWait,
+		logger:    logger,
+		cancelled: make(chan struct{}),
+		inflight:  make(map[string]struct{}),
+	}, nil
+}
+
+func (c *RedisConsumer) Consume(ctx context.Context) (<-chan Delivery, error) {
+	stopMaintenance := make(chan struct{})
+	go func() {
+		c.maintain(ctx)
+		ticker := time.NewTicker(c.ackWait / 2)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stopMaintenance:
+				return
+			case <-ticker.C:
+				c.maintain(ctx)
+			}
+		}
+	}()
+
+	out := make(chan Delivery)
+	go func() {
+		defer close(out)
+		defer close(stopMaintenance)
+
+		for {
+			select {
+			case <-c.cancelled:
+				return
+			case <-ctx.Done():
+				return
+			default:
+			}
+
+			msgs, err := c.receive(ctx)
+			if err != nil {
+				c.logger.Error("broker.redis: receive", zap.Error(err))
+				select {
+				case <-time.After(time.Second):
+				case <-c.cancelled:
+					return
+				}
+				continue
+			}
+
+			for i, msg := range msgs {
+				d := &redisDelivery{id: msg.ID, body: redisBody(msg), consumer: c}
+				select {
+				case out <- d:
+				case <-c.cancelled:
+					c.requeueAll(msgs[i:])
+					return
+				case <-ctx.Done():
+					c.requeueAll(msgs[i:])
+					return
+				}
+			}
+		}
+	}()
+	return out, nil
+}
+
+// receive reads new entries, up to the number of free prefetch slots. With
+// no free slot it waits briefly so settled messages can free one.
+func (c *RedisConsumer) receive(ctx context.Context) ([]redis.XMessage, error) {
+	free := c.prefetch - c.inflightCount()
+	if free <= 0 {
+		time.Sleep(100 * time.Millisecond)
+		return nil, nil
+	}
+
+	streams, err := c.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+		Group:    c.group,
+		Consumer: c.name,
+		Streams:  []string{c.stream, ">"},
+		Count:    int64(free),
+		Block:    time.Second,
+	}).Result()
+	if errors.Is(err, redis.Nil) || errors.Is(err, context.Canceled) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var msgs []redis.XMessage
+	for _, s := range streams {
+		msgs = append(msgs, s.Messages...)
+	}
+	c.track(msgs)
+	return msgs, nil
+}
+
+// maintain runs every AckWait/2, apart from reading so that a worker busy
+// with every slot does not hold it up. It resets the idle time of this
+// worker's own entries so others do not claim them, requeues entries other
+// workers left idle for longer than AckWait, and moves due delayed entries
+// back onto the stream.
+func (c *RedisConsumer) maintain(ctx context.Context) {
+	if ids := c.inflightIDs(); len(ids) > 0 {
+		err := c.client.XClaimJustID(ctx, &redis.XClaimArgs{
+			Stream:   c.stream,
+			Group:    c.group,
+			Consumer: c.name,
+			Messages: ids,
+		}).Err()
+		if err != nil {
+			c.logger.Warn("broker.redis: extend ownership", zap.Error(err))
+		}
+	}
+
+	abandoned, _, err := c.client.XAutoClaim(ctx, &redis.XAutoClaimArgs{
+		Stream:   c.stream,
+		Group:    c.group,
+		Consumer: c.name,
+		MinIdle:  c.ackWait,
+		Start:    "0-0",
+		Count:    100,
+	}).Result()
+	if err != nil {
+		c.logger.Warn("broker.redis: claim abandoned entries", zap.Error(err))
+	} else if len(abandoned) > 0 {
+		c.logger.Info("broker.redis: requeueing abandoned entries", zap.Int("count", len(abandoned)))
+		c.requeueAll(abandoned)
+	}
+
+	now := strconv.FormatInt(time.Now().UnixMilli(), 10)
+	keys := []string{redisDelayedKey(c.stream), c.stream}
+	if err := promoteScript.Run(ctx, c.client, keys, now).Err(); err != nil && !errors.Is(err, redis.Nil) {
+		c.logger.Warn("broker.redis: promote delayed entries", zap.Error(err))
+	}
+}
+
+// Cancel stops reading. Entries read but not yet handed out are requeued.
+func (c *RedisConsumer) Cancel() error {
+	c.cancelOnce.Do(func() { close(c.cancelled) })
+	return nil
+}
+
+func (c *RedisConsumer) Close() error {
+	_ = c.Cancel()
+	return c.client.Close()
+}
+
+func (c *RedisConsumer) requeueAll(msgs []redis.XMessage) {
+	for _, msg := range msgs {
+		c.track([]redis.XMessage{msg})
+		d := &redisDelivery{id: msg.ID, body: redisBody(msg), consumer: c}
+		if err := d.Requeue(0); err != nil {
+			c.logger.Warn("broker.redis: requeue", zap.String("id", msg.ID), zap.Error(err))
+		}
+	}
+}
+
+// settle removes id from the stream and the group's pending list, running
+// extra in the same transaction.
+func (c *RedisConsumer) settle(id string, extra func(redis.Pipeliner)) error {
+	ctx := context.Background()
+	_, err := c.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		if extra != nil {
+			extra(pipe)
+		}
+		pipe.XAck(ctx, c.stream, c.group, id)
+		pipe.XDel(ctx, c.stream, id)
+		return nil
+	})
+
+	c.mu.Lock()
+	delete(c.inflight, id)
+	c.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("broker.redis: settle %s: %w", id, err)
+	}
+	return nil
+}
+
+func (c *RedisConsumer) track(msgs []redis.XMessage) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, msg := range msgs {
+		c.inflight[msg.ID] = struct{}{}
+	}
+}
+
+func (c *RedisConsumer) inflightCount() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.inflight)
+}
+
+func (c *RedisConsumer) inflightIDs() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ids := make([]string, 0, len(c.inflight))
+	for id := range c.inflight {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+type redisDelivery struct {
+	id       string
+	body     []byte
+	consumer *RedisConsumer
+}
+
+func (d *redisDelivery) Body() []byte { return d.body }
+
+func (d *redisDelivery) Ack() error {
+	return d.consumer.settle(d.id, nil)
+}
+
+func (d *redisDelivery) Reject() error {
+	return d.consumer.settle(d.id, nil)
+}
+
+// Requeue appends a copy of the entry to the stream, or to the delayed set
+// when delay is positive, and removes the original.
+func (d *redisDelivery) Requeue(delay time.Duration) error {
+	c := d.consumer
+	return c.settle(d.id, func(pipe redis.Pipeliner) {
+		ctx := context.Background()
+		if delay <= 0 {
+			pipe.XAdd(ctx, &redis.XAddArgs{
+				Stream: c.stream,
+				Values: map[string]any{redisBodyField: d.body},
+			})
+			return
+		}
+		pipe.ZAdd(ctx, redisDelayedKey(c.stream), redis.Z{
+			Score:  float64(time.Now().Add(delay).UnixMilli()),
+			Member: d.id + " " + string(d.body),
+		})
+	})
+}
+
+func redisBody(msg redis.XMessage) []byte {
+	body, _ := msg.Values[redisBodyField].(string)
+	return []byte(body)
+}
+
+func connectRedis(ctx context.Context, cfg Config) (*redis.Client, error) {
+	opts, err := redis.ParseURL(cfg.RedisURL)
+	if err != nil {
+		return nil, fmt.Errorf("broker.redis: parse url: %w", err)
+	}
+	client := redis.NewClient(opts)
+	if err := client.Ping(ctx).Err(); err != nil {
+		_ = client.Close()
+		return nil, fmt.Errorf("broker.redis: connect: %w", err)
+	}
+	return client, nil
+}
+
+// redisStats reads the consumer group. Ready (the group's lag) needs Redis 7
+// or later.
+func redisStats(ctx context.Context, cfg Config) (Stats, error) {
+	client, err := connectRedis(ctx, cfg)
+	if err != nil {
+		return Stats{}, err
+	}
+	defer client.Close()
+
+	groups, err := client.XInfoGroups(ctx, cfg.RedisStream).Result()
+	if err != nil {
+		return Stats{}, fmt.Errorf("broker.redis: inspect stream %q: %w", cfg.RedisStream, err)
+	}
+	for _, g := range groups {
+		if g.Name == cfg.ConsumerName {
+			return Stats{
+				Name:      cfg.RedisStream,
+				Ready:     int(g.Lag),
+				Pending:   int(g.Pending),
+				Consumers: int(g.Consumers),
+			}, nil
+		}
+	}
+	return Stats{}, fmt.Errorf("broker.redis: stream %q has no group %q", cfg.RedisStream, cfg.ConsumerName)
+}
@@ -0,0 +1,162 @@
+// Package contract defines the job message api-service publishes and
+// worker-service consumes. Both services depend on it instead of on each
+// other's models, so the wire format is written down in one place.
+//
+// A Message is a JSON object: the envelope fields (envelope_version,
+// message_id, tenant_id, attempt, headers) sit next to the job fields rather
+// than wrapping them. Workers built before the envelope therefore read new
+// messages as plain jobs, and new workers read their messages as version 0.
+//
+// Compatibility rules, checked by the tests in this package:
+//
+//   - Version is raised whenever a field is added. Fields are never removed,
+//     renamed or given a new meaning; such a change needs a new message type.
+//   - Readers accept every version. Older messages lack the newer fields,
+//     which decode as zero values; newer messages carry fields the reader
+//     does not know, which are ignored.
+//
+// So either service can be upgraded first.
+package contract
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Bharat1Rajput/blob"
+)
+
+// Version is the envelope version written by this package.
+//
+//	0: no envelope; a bare job, as published before this package existed
+//	1: envelope_version, message_id, attempt and headers
+const Version = 1
+
+// ErrInvalid is returned by Encode and Decode for messages missing a
+// required field.
+var ErrInvalid = errors.New("contract: invalid message")
+
+// Status is a job status. Only pending is ever published; the others are
+// the statuses the worker records, listed so both services share one set.
+type Status string
+
+const (
+	StatusPending    Status = "pending"
+	StatusProcessing Status = "processing"
+	StatusSuccess    Status = "success"
+	StatusFailed     Status = "failed"
+	StatusExpired    Status = "expired"
+	StatusCancelled  Status = "cancelled"
+)
+
+// Classification is the retry decision the worker derived from a job's last
+// attempt.
+type Classification string
+
+const (
+	ClassificationSuccess   Classification = "success"
+	ClassificationRetryable Classification = "retryable"
+	ClassificationPermanent Classification = "permanent"
+)
+
+// BlobRef points at a payload held in a blob store instead of inline.
+type BlobRef = blob.Ref
+
+// TraceHeaders are the W3C Trace Context headers a publisher may put in
+// Message.Headers. The worker sends them with the delivery, so that it joins
+// the trace of the submission.
+var TraceHeaders = []string{"traceparent", "tracestate"}
+
+// Job is the webhook to deliver.
+type Job struct {
+	ID            string `json:"id"`
+	EventType     string `json:"event_type,omitempty"`
+	SchemaVersion int    `json:"schema_version,omitempty"`
+	// Body holds the exact bytes to deliver, unless PayloadRef is set.
+	Body        []byte `json:"body,omitempty"`
+	ContentType string `json:"content_type,omitempty"`
+	// Payload is the string-encoded body of messages published before
+	// Body existed. It is never written, only read from old messages.
+	Payload        string         `json:"payload,omitempty"`
+	PayloadRef     *BlobRef       `json:"payload_ref,omitempty"`
+	ClientURL      string         `json:"client_url"`
+	Status         Status         `json:"status"`
+	Error          string         `json:"error,omitempty"`
+	RetryCount     int            `json:"retry_count"`
+	Classification Classification `json:"classification,omitempty"`
+	RetryPolicy    string         `json:"retry_policy,omitempty"`
+	OrderingKey    string         `json:"ordering_key,omitempty"`
+	Priority       int            `json:"priority,omitempty"`
+	ExpiresAt      *time.Time     `json:"expires_at,omitempty"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+
+	StatusCallbackURL string `json:"status_callback_url,omitempty"`
+}
+
+// Message is a Job in its envelope, as it travels through the queue.
+type Message struct {
+	// Version is the envelope version the message was written with.
+	Version int `json:"envelope_version,omitempty"`
+	// MessageID is unique per publish: a job published twice, say once on
+	// submission and again when retried from the dashboard, has two.
+	MessageID string `json:"message_id,omitempty"`
+	TenantID  string `json:"tenant_id,omitempty"`
+	// Attempt is the number of delivery attempts recorded for the job when
+	// it was published: 0 on submission, more when it is retried by hand.
+	// The worker numbers the next attempt after it.
+	Attempt int `json:"attempt,omitempty"`
+	// Headers carry metadata from the publisher to the worker, keyed by
+	// lower-case name. Of them only TraceHeaders are sent to the client.
+	Headers map[string]string `json:"headers,omitempty"`
+
+	Job
+}
+
+// NewMessage wraps job for publishing, with a fresh MessageID.
+func NewMessage(job Job, tenantID string, attempt int) *Message {
+	return &Message{
+		Version:   Version,
+		MessageID: uuid.NewString(),
+		TenantID:  tenantID,
+		Attempt:   attempt,
+		Job:       job,
+	}
+}
+
+// Encode returns m as published to the queue.
+func Encode(m *Message) ([]byte, error) {
+	if m.Version < 1 || m.MessageID == "" {
+		return nil, fmt.Errorf("%w: no envelope, use NewMessage", ErrInvalid)
+	}
+	if err := m.validate(); err != nil {
+		return nil, err
+	}
+	return json.Marshal(m)
+}
+
+// Decode reads a message of any version. Messages without an envelope
+// decode with Version 0.
+func Decode(data []byte) (*Message, error) {
+	var m Message
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("contract: decode message: %w", err)
+	}
+	if err := m.validate(); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+func (m *Message) validate() error {
+	switch {
+	case m.ID == "":
+		return fmt.Errorf("%w: id is required", ErrInvalid)
+	case m.ClientURL == "":
+		return fmt.Errorf("%w: client_url is required", ErrInvalid)
+	}
+	return nil
+}
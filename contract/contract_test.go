@@ -0,0 +1,252 @@
+package contract
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// legacyJob is worker-service's job model before this package existed, as
+// still run by workers that have not been upgraded. It must keep reading
+// every message version.
+type legacyJob struct {
+	ID             string     `json:"id"`
+	TenantID       string     `json:"tenant_id,omitempty"`
+	EventType      string     `json:"event_type,omitempty"`
+	SchemaVersion  int        `json:"schema_version,omitempty"`
+	Body           []byte     `json:"body,omitempty"`
+	ContentType    string     `json:"content_type,omitempty"`
+	Payload        string     `json:"payload,omitempty"`
+	PayloadRef     *BlobRef   `json:"payload_ref,omitempty"`
+	ClientURL      string     `json:"client_url"`
+	Status         string     `json:"status"`
+	Error          string     `json:"error"`
+	RetryCount     int        `json:"retry_count"`
+	Classification string     `json:"classification"`
+	RetryPolicy    string     `json:"retry_policy,omitempty"`
+	OrderingKey    string     `json:"ordering_key,omitempty"`
+	Priority       int        `json:"priority,omitempty"`
+	ExpiresAt      *time.Time `json:"expires_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+
+	StatusCallbackURL string `json:"status_callback_url,omitempty"`
+}
+
+func readFixture(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile("testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func mustTime(t *testing.T, s string) time.Time {
+	t.Helper()
+	ts, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ts
+}
+
+// v1Message is the message stored in testdata/v1.json.
+func v1Message(t *testing.T) *Message {
+	expires := mustTime(t, "2025-01-02T03:04:05Z")
+	created := mustTime(t, "2025-01-01T00:00:00Z")
+	return &Message{
+		Version:   1,
+		MessageID: "9a0e1c2d-3b4a-4f5e-8d7c-6b5a4f3e2d1c",
+		TenantID:  "acme",
+		Attempt:   3,
+		Headers:   map[string]string{"traceparent": "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"},
+		Job: Job{
+			ID:                "0b6f3a5e-4a51-4c8e-9d8e-2f1a7c3b9e10",
+			EventType:         "user.created",
+			SchemaVersion:     2,
+			ContentType:       "application/json",
+			PayloadRef:        &BlobRef{Key: "payloads/0b6f3a5e.bin", Size: 1048576, SHA256: "ab12"},
+			ClientURL:         "https://example.com/hook",
+			Status:            StatusPending,
+			RetryPolicy:       "aggressive",
+			OrderingKey:       "user-42",
+			Priority:          5,
+			ExpiresAt:         &expires,
+			CreatedAt:         created,
+			UpdatedAt:         created,
+			StatusCallbackURL: "https://example.com/status",
+		},
+	}
+}
+
+// TestEncodeMatchesFixture pins the version 1 wire format. If it fails, the
+// format changed: either restore it or raise Version and add a fixture.
+func TestEncodeMatchesFixture(t *testing.T) {
+	got, err := Encode(v1Message(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var gotFields, wantFields map[string]any
+	if err := json.Unmarshal(got, &gotFields); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(readFixture(t, "v1.json"), &wantFields); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(gotFields, wantFields) {
+		t.Fatalf("encoded message differs from testdata/v1.json:\n got %s", got)
+	}
+}
+
+func TestDecodeCurrentVersion(t *testing.T) {
+	m, err := Decode(readFixture(t, "v1.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := v1Message(t); !reflect.DeepEqual(m, want) {
+		t.Fatalf("Decode = %+v, want %+v", m, want)
+	}
+}
+
+// TestDecodeBeforeEnvelope covers an upgraded worker reading what an API
+// without this package published.
+func TestDecodeBeforeEnvelope(t *testing.T) {
+	m, err := Decode(readFixture(t, "v0_api.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Version != 0 || m.MessageID != "" || m.Attempt != 0 {
+		t.Errorf("envelope = (%d, %q, %d), want zero values", m.Version, m.MessageID, m.Attempt)
+	}
+	if m.TenantID != "acme" {
+		t.Errorf("TenantID = %q, want acme", m.TenantID)
+	}
+	if string(m.Body) != `{"id":"42"}` {
+		t.Errorf("Body = %q", m.Body)
+	}
+	if m.Priority != 5 || m.OrderingKey != "user-42" || m.RetryPolicy != "aggressive" {
+		t.Errorf("delivery options = (%d, %q, %q)", m.Priority, m.OrderingKey, m.RetryPolicy)
+	}
+	if m.ExpiresAt == nil || !m.ExpiresAt.Equal(mustTime(t, "2025-01-02T03:04:05Z")) {
+		t.Errorf("ExpiresAt = %v", m.ExpiresAt)
+	}
+}
+
+func TestDecodeStringPayload(t *testing.T) {
+	m, err := Decode(readFixture(t, "v0_string_payload.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Body != nil || m.Payload != `{"id":"42"}` {
+		t.Fatalf("Body = %q, Payload = %q", m.Body, m.Payload)
+	}
+}
+
+// TestDecodeNewerVersion covers a worker reading what a newer API
+// published: fields it does not know are ignored.
+func TestDecodeNewerVersion(t *testing.T) {
+	m, err := Decode(readFixture(t, "v2.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Version != 2 || m.MessageID != "c3d4e5f6-a7b8-4c9d-8e0f-1a2b3c4d5e6f" || m.Attempt != 1 {
+		t.Errorf("envelope = (%d, %q, %d)", m.Version, m.MessageID, m.Attempt)
+	}
+	if m.ID != "0b6f3a5e-4a51-4c8e-9d8e-2f1a7c3b9e10" || string(m.Body) != `{"id":"42"}` {
+		t.Errorf("job = (%q, %q)", m.ID, m.Body)
+	}
+}
+
+// TestLegacyWorkerReadsEveryVersion covers a worker without this package
+// reading what an upgraded API publishes.
+func TestLegacyWorkerReadsEveryVersion(t *testing.T) {
+	for _, name := range []string{"v0_api.json", "v0_string_payload.json", "v1.json", "v2.json"} {
+		t.Run(name, func(t *testing.T) {
+			data := readFixture(t, name)
+			m, err := Decode(data)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var old legacyJob
+			if err := json.Unmarshal(data, &old); err != nil {
+				t.Fatal(err)
+			}
+			if old.ID != m.ID || old.ClientURL != m.ClientURL || old.TenantID != m.TenantID {
+				t.Errorf("legacy job = (%q, %q, %q), want (%q, %q, %q)",
+					old.ID, old.ClientURL, old.TenantID, m.ID, m.ClientURL, m.TenantID)
+			}
+			if string(old.Body) != string(m.Body) || old.Payload != m.Payload {
+				t.Errorf("legacy body = (%q, %q), want (%q, %q)", old.Body, old.Payload, m.Body, m.Payload)
+			}
+			if !reflect.DeepEqual(old.PayloadRef, m.PayloadRef) {
+				t.Errorf("legacy payload ref = %+v, want %+v", old.PayloadRef, m.PayloadRef)
+			}
+			if old.Priority != m.Priority || old.OrderingKey != m.OrderingKey || old.RetryPolicy != m.RetryPolicy {
+				t.Errorf("legacy delivery options differ")
+			}
+			if old.StatusCallbackURL != m.StatusCallbackURL {
+				t.Errorf("legacy status callback = %q, want %q", old.StatusCallbackURL, m.StatusCallbackURL)
+			}
+		})
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	want := NewMessage(Job{
+		ID:        "job-1",
+		Body:      []byte{0xff, 0x00, 'x'},
+		ClientURL: "https://example.com/hook",
+		Status:    StatusPending,
+		CreatedAt: time.Now().UTC().Truncate(time.Microsecond),
+	}, "acme", 0)
+	want.UpdatedAt = want.CreatedAt
+	want.Headers = map[string]string{"k": "v"}
+
+	data, err := Encode(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := Decode(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("round trip = %+v, want %+v", got, want)
+	}
+	if got.Version != Version || got.MessageID == "" {
+		t.Fatalf("envelope = (%d, %q)", got.Version, got.MessageID)
+	}
+}
+
+func TestNewMessageIDsAreUnique(t *testing.T) {
+	job := Job{ID: "job-1", ClientURL: "https://example.com/hook"}
+	if NewMessage(job, "", 0).MessageID == NewMessage(job, "", 0).MessageID {
+		t.Fatal("two publishes of one job share a message id")
+	}
+}
+
+func TestInvalidMessages(t *testing.T) {
+	for name, data := range map[string]string{
+		"no id":         `{"envelope_version":1,"message_id":"m","client_url":"https://example.com"}`,
+		"no client url": `{"id":"job-1"}`,
+	} {
+		if _, err := Decode([]byte(data)); !errors.Is(err, ErrInvalid) {
+			t.Errorf("Decode(%s) error = %v, want ErrInvalid", name, err)
+		}
+	}
+
+	if _, err := Decode([]byte(`{`)); err == nil || errors.Is(err, ErrInvalid) {
+		t.Errorf("Decode(malformed) error = %v, want a syntax error", err)
+	}
+
+	unwrapped := &Message{Job: Job{ID: "job-1", ClientURL: "https://example.com"}}
+	if _, err := Encode(unwrapped); !errors.Is(err, ErrInvalid) {
+		t.Errorf("Encode without envelope error = %v, want ErrInvalid", err)
+	}
+}
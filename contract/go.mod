@@ -0,0 +1,10 @@
+module github.com/Bharat1Rajput/contract
+
+go 1.22
+
+require (
+	github.com/Bharat1Rajput/blob v0.0.0
+	github.com/google/uuid v1.6.0
+)
+
+replace github.com/Bharat1Rajput/blob => ../blob
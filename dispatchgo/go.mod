@@ -0,0 +1,52 @@
+module github.com/Bharat1Rajput/dispatchgo
+
+go 1.22
+
+require (
+	github.com/Bharat1Rajput/apiService v0.0.0
+	github.com/Bharat1Rajput/broker v0.0.0
+	github.com/Bharat1Rajput/workerService v0.0.0
+	github.com/joho/godotenv v1.5.1
+	github.com/lib/pq v1.11.2
+	go.uber.org/zap v1.27.0
+	modernc.org/sqlite v1.34.5
+)
+
+require (
+	github.com/Bharat1Rajput/blob v0.0.0 // indirect
+	github.com/Bharat1Rajput/contract v0.0.0 // indirect
+	github.com/Bharat1Rajput/jobstore v0.0.0 // indirect
+	github.com/Bharat1Rajput/ssrf v0.0.0 // indirect
+	github.com/cespare/xxhash/v2 v2.2.0 // indirect
+	github.com/dgryski/go-rendezvous v0.0.0-20200823014737-9f7001d12a5f // indirect
+	github.com/dustin/go-humanize v1.0.1 // indirect
+	github.com/go-chi/chi/v5 v5.0.12 // indirect
+	github.com/google/uuid v1.6.0 // indirect
+	github.com/klauspost/compress v1.17.9 // indirect
+	github.com/mattn/go-isatty v0.0.20 // indirect
+	github.com/nats-io/nats.go v1.38.0 // indirect
+	github.com/nats-io/nkeys v0.4.9 // indirect
+	github.com/nats-io/nuid v1.0.1 // indirect
+	github.com/ncruces/go-strftime v0.1.9 // indirect
+	github.com/rabbitmq/amqp091-go v1.10.0 // indirect
+	github.com/redis/go-redis/v9 v9.7.0 // indirect
+	github.com/remyoudompheng/bigfft v0.0.0-20230129092748-24d4a6f8daec // indirect
+	github.com/santhosh-tekuri/jsonschema/v5 v5.3.1 // indirect
+	go.uber.org/multierr v1.10.0 // indirect
+	golang.org/x/crypto v0.31.0 // indirect
+	golang.org/x/sys v0.28.0 // indirect
+	golang.org/x/text v0.21.0 // indirect
+	modernc.org/libc v1.55.3 // indirect
+	modernc.org/mathutil v1.6.0 // indirect
+	modernc.org/memory v1.8.0 // indirect
+)
+
+replace (
+	github.com/Bharat1Rajput/apiService => ../api-service
+	github.com/Bharat1Rajput/blob => ../blob
+	github.com/Bharat1Rajput/broker => ../broker
+	github.com/Bharat1Rajput/contract => ../contract
+	github.com/Bharat1Rajput/jobstore => ../jobstore
+	github.com/Bharat1Rajput/ssrf => ../ssrf
+	github.com/Bharat1Rajput/workerService => ../worker-service
+)
@@ -0,0 +1,199 @@
+// Command dispatchgo runs the HTTP API and the worker pool in one process,
+// for edge deployments and demos. It reads the settings of both services
+// from one environment, and additionally accepts BROKER=memory, an
+// in-process queue, and STORAGE=sqlite, job history in the file at
+// SQLITE_PATH. The split api-service and worker-service deployment remains
+// the default.
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/joho/godotenv"
+	_ "github.com/lib/pq"
+	"go.uber.org/zap"
+	_ "modernc.org/sqlite"
+
+	"github.com/Bharat1Rajput/apiService/server"
+	"github.com/Bharat1Rajput/broker"
+	"github.com/Bharat1Rajput/workerService/migrations"
+	"github.com/Bharat1Rajput/workerService/worker"
+)
+
+func main() {
+	if err := run(); err != nil {
+		fmt.Fprintln(os.Stderr, "dispatchgo:", err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	_ = godotenv.Load()
+
+	logger, err := zap.NewProduction()
+	if err != nil {
+		return err
+	}
+	defer logger.Sync()
+
+	apiCfg, err := server.LoadConfig()
+	if err != nil {
+		return fmt.Errorf("load api config: %w", err)
+	}
+	workerCfg, err := worker.LoadConfig()
+	if err != nil {
+		return fmt.Errorf("load worker config: %w", err)
+	}
+
+	db, err := openStorage(context.Background(), workerCfg, logger)
+	if err != nil {
+		return fmt.Errorf("open %s storage: %w", workerCfg.Storage, err)
+	}
+	defer db.Close()
+
+	var (
+		apiStore    *server.Storage
+		workerStore *worker.Storage
+	)
+	switch workerCfg.Storage {
+	case worker.StorageSQLite:
+		apiStore = server.SQLiteStorage(db)
+		workerStore = worker.SQLiteStorage(db)
+	default:
+		apiStore = server.PostgresStorage(db, workerCfg.DatabaseURL)
+		workerStore = worker.PostgresStorage(db)
+	}
+
+	// With BROKER=memory both sides get the same in-process queue.
+	pub, err := broker.NewPublisher(context.Background(), apiCfg.BrokerConfig(), logger)
+	if err != nil {
+		return fmt.Errorf("create %s publisher: %w", apiCfg.Broker, err)
+	}
+	defer pub.Close()
+
+	source, err := broker.NewConsumer(context.Background(), workerCfg.BrokerConfig(), logger)
+	if err != nil {
+		return fmt.Errorf("create %s consumer: %w", workerCfg.Broker, err)
+	}
+
+	srv, err := server.New(apiCfg, pub, apiStore, logger.Named("api"))
+	if err != nil {
+		source.Close()
+		return fmt.Errorf("build api: %w", err)
+	}
+	w, err := worker.New(workerCfg, source, workerStore, logger.Named("worker"))
+	if err != nil {
+		source.Close()
+		return fmt.Errorf("build worker pool: %w", err)
+	}
+	defer w.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// The API stops first so that nothing is accepted that the workers,
+	// stopping after it, would leave behind in an in-process queue.
+	apiCtx, stopAPI := context.WithCancel(ctx)
+	defer stopAPI()
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+
+	apiDone := make(chan error, 1)
+	go func() {
+		apiDone <- srv.Run(apiCtx)
+	}()
+	workerDone := make(chan error, 1)
+	go func() {
+		workerDone <- w.Run(workerCtx)
+	}()
+
+	var apiErr, workerErr error
+	select {
+	case <-ctx.Done():
+		logger.Info("shutdown signal received")
+		apiErr = <-apiDone
+		stopWorkers()
+		workerErr = <-workerDone
+	case apiErr = <-apiDone:
+		stopWorkers()
+		workerErr = <-workerDone
+	case workerErr = <-workerDone:
+		stopAPI()
+		apiErr = <-apiDone
+	}
+	if apiErr != nil {
+		apiErr = fmt.Errorf("api: %w", apiErr)
+	}
+	if workerErr != nil {
+		workerErr = fmt.Errorf("worker: %w", workerErr)
+	}
+	return errors.Join(apiErr, workerErr)
+}
+
+// openStorage connects to the job history database selected with STORAGE
+// and brings its schema up to date.
+func openStorage(ctx context.Context, cfg *worker.Config, logger *zap.Logger) (*sql.DB, error) {
+	if cfg.Storage == worker.StorageSQLite {
+		dsn := "file:" + cfg.SQLitePath +
+			"?_time_format=sqlite&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_pragma=foreign_keys(1)"
+		db, err := sql.Open("sqlite", dsn)
+		if err != nil {
+			return nil, err
+		}
+		// One connection serialises writers, which SQLite would otherwise
+		// fail with SQLITE_BUSY under load.
+		db.SetMaxOpenConns(1)
+		if _, err := db.ExecContext(ctx, migrations.SQLiteSchema); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("apply sqlite schema: %w", err)
+		}
+		if err := addSQLiteColumns(ctx, db); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("apply sqlite schema: %w", err)
+		}
+		return db, nil
+	}
+
+	db, err := sql.Open("postgres", cfg.DatabaseURL)
+	if err != nil {
+		return nil, err
+	}
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, err
+	}
+	if cfg.MigrateOnStart {
+		if err := worker.Migrate(ctx, db, logger); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("apply migrations: %w", err)
+		}
+	}
+	return db, nil
+}
+
+// addSQLiteColumns adds the columns of migrations.SQLiteColumns that a
+// database created by an older dispatchgo lacks.
+func addSQLiteColumns(ctx context.Context, db *sql.DB) error {
+	for _, c := range migrations.SQLiteColumns {
+		var n int
+		err := db.QueryRowContext(ctx,
+			`SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?`, c.Table, c.Column).Scan(&n)
+		if err != nil {
+			return fmt.Errorf("inspect %s: %w", c.Table, err)
+		}
+		if n > 0 {
+			continue
+		}
+		stmt := fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", c.Table, c.Column, c.Definition)
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("add %s.%s: %w", c.Table, c.Column, err)
+		}
+	}
+	return nil
+}
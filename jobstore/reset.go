@@ -0,0 +1,110 @@
+// Package jobstore holds the changes to webhook_jobs that api-service and
+// worker-service both make, so the dashboard and dispatchctl cannot drift
+// apart on them. Each service keeps its own reads and its own job model.
+package jobstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrNotFound     = errors.New("jobstore: job not found")
+	ErrNotRetryable = errors.New("jobstore: job is pending, being processed or already succeeded")
+)
+
+// Dialect selects the placeholder syntax of the database holding the jobs.
+type Dialect int
+
+const (
+	Postgres Dialect = iota
+	SQLite
+)
+
+// Querier runs a query returning a single row. *sql.DB and *sql.Tx both
+// satisfy it.
+type Querier interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// ResetForRetry moves a failed, expired or cancelled job back to pending
+// with a fresh retry budget, no expiry and no scheduled attempt, then calls
+// publish with the transaction so the caller can read the reset job and
+// queue it. The reset is only committed if publish succeeds, so a job is
+// never left pending without a message. Until then the row stays locked,
+// and a worker receiving the message waits for the commit instead of
+// skipping the job as finished.
+//
+// Placeholder rows left by cancelling a queued job have no client_url or
+// payload, cannot be redelivered and are ErrNotRetryable.
+func ResetForRetry(ctx context.Context, db *sql.DB, dialect Dialect, id string, publish func(Querier) error) error {
+	const query = `
+		UPDATE webhook_jobs
+		SET status = 'pending',
+		    error = '',
+		    classification = '',
+		    retry_count = 0,
+		    expires_at = NULL,
+		    next_attempt_at = NULL,
+		    updated_at = ?
+		WHERE id = ?
+		  AND status IN ('failed', 'expired', 'cancelled')
+		  AND client_url <> ''
+	`
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("jobstore: reset for retry: %w", err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, rebind(dialect, query), time.Now().UTC(), id)
+	if err != nil {
+		return fmt.Errorf("jobstore: reset for retry: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("jobstore: reset for retry: %w", err)
+	}
+	if n == 0 {
+		var found int
+		err := tx.QueryRowContext(ctx, rebind(dialect, `SELECT 1 FROM webhook_jobs WHERE id = ?`), id).Scan(&found)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrNotFound
+		case err != nil:
+			return fmt.Errorf("jobstore: reset for retry: %w", err)
+		}
+		return ErrNotRetryable
+	}
+
+	if err := publish(tx); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("jobstore: reset for retry: %w", err)
+	}
+	return nil
+}
+
+// rebind rewrites query's ? placeholders for dialect.
+func rebind(dialect Dialect, query string) string {
+	if dialect != Postgres {
+		return query
+	}
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
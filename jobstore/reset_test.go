@@ -0,0 +1,118 @@
+package jobstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	_ "modernc.org/sqlite"
+)
+
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "jobs.db")+"?_pragma=busy_timeout(5000)")
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	const schema = `
+		CREATE TABLE webhook_jobs (
+			id              TEXT PRIMARY KEY,
+			client_url      TEXT NOT NULL,
+			status          TEXT NOT NULL,
+			error           TEXT NOT NULL DEFAULT '',
+			classification  TEXT NOT NULL DEFAULT '',
+			retry_count     INTEGER NOT NULL DEFAULT 0,
+			expires_at      TIMESTAMP,
+			next_attempt_at TIMESTAMP,
+			updated_at      TIMESTAMP
+		);
+		INSERT INTO webhook_jobs (id, client_url, status, error, retry_count, expires_at) VALUES
+			('failed', 'https://example.com/', 'failed', 'status 503', 3, '2026-01-01T00:00:00Z'),
+			('success', 'https://example.com/', 'success', '', 0, NULL),
+			('pending', 'https://example.com/', 'pending', '', 0, NULL),
+			('placeholder', '', 'cancelled', 'cancelled before delivery', 0, NULL);
+	`
+	if _, err := db.Exec(schema); err != nil {
+		t.Fatalf("create schema: %v", err)
+	}
+	return db
+}
+
+func TestResetForRetry(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+
+	var published string
+	err := ResetForRetry(ctx, db, SQLite, "failed", func(q Querier) error {
+		return q.QueryRowContext(ctx, `SELECT status FROM webhook_jobs WHERE id = 'failed'`).Scan(&published)
+	})
+	if err != nil {
+		t.Fatalf("reset: %v", err)
+	}
+	if published != "pending" {
+		t.Errorf("status seen by publish: got %q, want pending", published)
+	}
+
+	var (
+		status    string
+		retries   int
+		expiresAt sql.NullString
+	)
+	row := db.QueryRow(`SELECT status, retry_count, expires_at FROM webhook_jobs WHERE id = 'failed'`)
+	if err := row.Scan(&status, &retries, &expiresAt); err != nil {
+		t.Fatalf("read job: %v", err)
+	}
+	if status != "pending" || retries != 0 || expiresAt.Valid {
+		t.Errorf("reset job: got status %q, %d retries, expiry %v", status, retries, expiresAt)
+	}
+}
+
+func TestResetForRetryRollsBackFailedPublish(t *testing.T) {
+	db := openTestDB(t)
+	errPublish := errors.New("broker down")
+
+	err := ResetForRetry(context.Background(), db, SQLite, "failed", func(Querier) error { return errPublish })
+	if !errors.Is(err, errPublish) {
+		t.Fatalf("reset: got %v, want %v", err, errPublish)
+	}
+	var status string
+	if err := db.QueryRow(`SELECT status FROM webhook_jobs WHERE id = 'failed'`).Scan(&status); err != nil {
+		t.Fatalf("read job: %v", err)
+	}
+	if status != "failed" {
+		t.Errorf("status: got %q, want failed", status)
+	}
+}
+
+func TestResetForRetryRefuses(t *testing.T) {
+	db := openTestDB(t)
+	publish := func(Querier) error {
+		t.Error("publish called")
+		return nil
+	}
+
+	for id, want := range map[string]error{
+		"success":     ErrNotRetryable,
+		"pending":     ErrNotRetryable,
+		"placeholder": ErrNotRetryable,
+		"missing":     ErrNotFound,
+	} {
+		if err := ResetForRetry(context.Background(), db, SQLite, id, publish); !errors.Is(err, want) {
+			t.Errorf("%s: got %v, want %v", id, err, want)
+		}
+	}
+}
+
+func TestRebind(t *testing.T) {
+	const query = `UPDATE t SET a = ? WHERE b = ? AND c = 'x'`
+	if got, want := rebind(Postgres, query), `UPDATE t SET a = $1 WHERE b = $2 AND c = 'x'`; got != want {
+		t.Errorf("postgres: got %q, want %q", got, want)
+	}
+	if got := rebind(SQLite, query); got != query {
+		t.Errorf("sqlite: got %q, want it unchanged", got)
+	}
+}
@@ -0,0 +1,173 @@
+// Package ssrf keeps webhook deliveries away from internal networks. The API
+// checks destinations when a job is submitted and the worker checks them again
+// at dial time; both use this package so the rules cannot drift apart.
+package ssrf
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+)
+
+var ErrBlocked = errors.New("ssrf: destination not allowed")
+
+// blockedPrefixes are ranges never reachable from outside: loopback, RFC 1918
+// and ULA private space, link-local (cloud metadata lives at 169.254.169.254),
+// CGNAT, multicast and "this network". The deprecated IPv4-compatible (::/96)
+// and Teredo (2001::/32) forms, and local-use NAT64, are blocked outright.
+var blockedPrefixes = mustPrefixes(
+	"0.0.0.0/8",
+	"10.0.0.0/8",
+	"100.64.0.0/10",
+	"127.0.0.0/8",
+	"169.254.0.0/16",
+	"172.16.0.0/12",
+	"192.0.0.0/24",
+	"192.168.0.0/16",
+	"198.18.0.0/15",
+	"224.0.0.0/4",
+	"240.0.0.0/4",
+	"::/96",
+	"::1/128",
+	"2001::/32",
+	"64:ff9b:1::/48",
+	"fc00::/7",
+	"fe80::/10",
+	"ff00::/8",
+)
+
+var (
+	nat64     = netip.MustParsePrefix("64:ff9b::/96")
+	sixToFour = netip.MustParsePrefix("2002::/16")
+)
+
+// Guard decides whether an outbound destination address may be contacted.
+// Explicit deny ranges win over allow ranges, which win over the built-in blocklist.
+type Guard struct {
+	deny  []netip.Prefix
+	allow []netip.Prefix
+}
+
+func New(denyCIDRs, allowCIDRs []string) (*Guard, error) {
+	deny, err := parsePrefixes(denyCIDRs)
+	if err != nil {
+		return nil, err
+	}
+	allow, err := parsePrefixes(allowCIDRs)
+	if err != nil {
+		return nil, err
+	}
+	return &Guard{deny: deny, allow: allow}, nil
+}
+
+// CheckIP rejects ip if it is blocked. NAT64 and 6to4 addresses reach the
+// IPv4 address embedded in them, so that address is checked as well.
+func (g *Guard) CheckIP(ip netip.Addr) error {
+	ip = ip.Unmap()
+	if contains(g.deny, ip) {
+		return fmt.Errorf("%w: %s is in a denied range", ErrBlocked, ip)
+	}
+	if contains(g.allow, ip) {
+		return nil
+	}
+	if contains(blockedPrefixes, ip) {
+		return fmt.Errorf("%w: %s is a private or reserved address", ErrBlocked, ip)
+	}
+	if v4, ok := embeddedIPv4(ip); ok {
+		if err := g.CheckIP(v4); err != nil {
+			return fmt.Errorf("%w (embedded in %s)", err, ip)
+		}
+	}
+	return nil
+}
+
+// embeddedIPv4 returns the IPv4 address a NAT64 (RFC 6052 well-known prefix)
+// or 6to4 (RFC 3056) address translates to.
+func embeddedIPv4(ip netip.Addr) (netip.Addr, bool) {
+	b := ip.As16()
+	switch {
+	case nat64.Contains(ip):
+		return netip.AddrFrom4([4]byte(b[12:16])), true
+	case sixToFour.Contains(ip):
+		return netip.AddrFrom4([4]byte(b[2:6])), true
+	}
+	return netip.Addr{}, false
+}
+
+// CheckURL resolves the host of u and rejects it if any resolved address is
+// blocked. The worker enforces the same rules at dial time, so a hostname that
+// later re-resolves to a private address is still refused.
+func (g *Guard) CheckURL(ctx context.Context, u *url.URL) error {
+	host := u.Hostname()
+	if host == "" {
+		return fmt.Errorf("%w: missing host", ErrBlocked)
+	}
+
+	if ip, err := netip.ParseAddr(host); err == nil {
+		return g.CheckIP(ip)
+	}
+
+	ips, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return fmt.Errorf("ssrf: resolve %s: %w", host, err)
+	}
+	for _, ip := range ips {
+		if err := g.CheckIP(ip); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Control is a net.Dialer Control hook. It runs after DNS resolution with the
+// concrete address being dialed, which closes the DNS-rebinding gap left by
+// validating hostnames at submission time.
+func (g *Guard) Control(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return fmt.Errorf("ssrf: split address %q: %w", address, err)
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return fmt.Errorf("ssrf: parse address %q: %w", host, err)
+	}
+	return g.CheckIP(ip)
+}
+
+func contains(prefixes []netip.Prefix, ip netip.Addr) bool {
+	for _, p := range prefixes {
+		if p.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func parsePrefixes(cidrs []string) ([]netip.Prefix, error) {
+	out := make([]netip.Prefix, 0, len(cidrs))
+	for _, c := range cidrs {
+		c = strings.TrimSpace(c)
+		if c == "" {
+			continue
+		}
+		p, err := netip.ParsePrefix(c)
+		if err != nil {
+			return nil, fmt.Errorf("ssrf: parse range %q: %w", c, err)
+		}
+		out = append(out, p.Masked())
+	}
+	return out, nil
+}
+
+func mustPrefixes(cidrs ...string) []netip.Prefix {
+	p, err := parsePrefixes(cidrs)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
@@ -0,0 +1,56 @@
+package ssrf
+
+import (
+	"errors"
+	"net/netip"
+	"testing"
+)
+
+func TestCheckIP(t *testing.T) {
+	g, err := New([]string{"203.0.113.0/24"}, []string{"10.1.0.0/16"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		ip      string
+		blocked bool
+	}{
+		{"93.184.216.34", false},
+		{"2606:2800:220:1::1", false},
+		{"127.0.0.1", true},
+		{"169.254.169.254", true},
+		{"::ffff:127.0.0.1", true},
+		{"::1", true},
+		{"::", true},
+		{"fd00::1", true},
+
+		// IPv6 forms that reach an IPv4 address.
+		{"64:ff9b::7f00:1", true},
+		{"64:ff9b::a9fe:a9fe", true},
+		{"64:ff9b::5db8:d822", false},
+		{"64:ff9b:1::5db8:d822", true},
+		{"2002:7f00:1::1", true},
+		{"2002:a9fe:a9fe::", true},
+		{"2002:5db8:d822::1", false},
+		{"::7f00:1", true},
+		{"::5db8:d822", true},
+		{"2001:0:4136:e378:8000:63bf:3fff:fdd2", true},
+
+		// Allow and deny ranges apply to embedded addresses too.
+		{"10.1.2.3", false},
+		{"64:ff9b::a01:203", false},
+		{"203.0.113.5", true},
+		{"2002:cb00:7105::", true},
+	} {
+		err := g.CheckIP(netip.MustParseAddr(tc.ip))
+		if blocked := errors.Is(err, ErrBlocked); blocked != tc.blocked {
+			t.Errorf("CheckIP(%s) = %v, want blocked %v", tc.ip, err, tc.blocked)
+		}
+	}
+}
+
+func TestNewRejectsBadRange(t *testing.T) {
+	if _, err := New([]string{"10.0.0.0/33"}, nil); err == nil {
+		t.Fatal("New accepted an invalid range")
+	}
+}
@@ -0,0 +1,318 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Bharat1Rajput/workerService/internal/model"
+	"github.com/Bharat1Rajput/workerService/internal/repository"
+)
+
+// filterFlags registers the job filter flags shared by list and requeue.
+func filterFlags(fs *flag.FlagSet, f *repository.JobFilter) func() error {
+	status := fs.String("status", "", "job status (pending, processing, success, failed, expired, cancelled)")
+	fs.StringVar(&f.TenantID, "tenant", "", "tenant ID")
+	fs.StringVar(&f.URLPrefix, "url", "", "client_url prefix")
+	fs.StringVar(&f.Error, "error", "", "substring of the last error")
+	since := fs.Duration("since", 0, "only jobs created within this long ago, e.g. 24h")
+	fs.IntVar(&f.Limit, "limit", 50, "maximum number of jobs")
+
+	return func() error {
+		f.Status = model.JobStatus(*status)
+		if *since < 0 {
+			return fmt.Errorf("-since must be positive")
+		}
+		if *since > 0 {
+			f.Since = time.Now().Add(-*since)
+		}
+		return nil
+	}
+}
+
+func runList(ctx context.Context, args []string) error {
+	var filter repository.JobFilter
+	fs := flag.NewFlagSet("list", flag.ExitOnError)
+	finish := filterFlags(fs, &filter)
+	_ = fs.Parse(args)
+	if err := finish(); err != nil {
+		return err
+	}
+
+	repo, closeRepo, err := openRepo()
+	if err != nil {
+		return err
+	}
+	defer closeRepo()
+
+	jobs, err := repo.ListJobs(ctx, filter)
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tSTATUS\tRETRIES\tTENANT\tCREATED\tCLIENT URL\tERROR")
+	for _, j := range jobs {
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%s\t%s\t%s\t%s\n",
+			j.ID, j.Status, j.RetryCount, j.TenantID,
+			j.CreatedAt.Local().Format(time.DateTime), j.ClientURL, truncate(j.Error, 60))
+	}
+	return tw.Flush()
+}
+
+func runShow(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("show", flag.ExitOnError)
+	fs.Usage = func() { fmt.Fprintln(fs.Output(), "usage: dispatchctl show <job-id>") }
+	_ = fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	repo, closeRepo, err := openRepo()
+	if err != nil {
+		return err
+	}
+	defer closeRepo()
+
+	job, err := repo.GetJob(ctx, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	attempts, err := repo.ListAttempts(ctx, job.ID)
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	row := func(k string, v any) { fmt.Fprintf(tw, "%s:\t%v\n", k, v) }
+	row("ID", job.ID)
+	row("Status", job.Status)
+	row("Classification", job.Classification)
+	row("Client URL", job.ClientURL)
+	row("Tenant", job.TenantID)
+	row("Event type", job.EventType)
+	row("Retry policy", job.RetryPolicy)
+	row("Retries", job.RetryCount)
+	row("Priority", job.Priority)
+	row("Ordering key", job.OrderingKey)
+	if job.StatusCallbackURL != "" {
+		row("Status callback", job.StatusCallbackURL)
+	}
+	if job.ExpiresAt != nil {
+		row("Expires", job.ExpiresAt.Local().Format(time.DateTime))
+	}
+	row("Created", job.CreatedAt.Local().Format(time.DateTime))
+	row("Updated", job.UpdatedAt.Local().Format(time.DateTime))
+	row("Content type", job.MediaType())
+	if job.PayloadRef != nil {
+		row("Payload", fmt.Sprintf("blob %s (%d bytes)", job.PayloadRef.Key, job.PayloadRef.Size))
+	} else {
+		row("Payload", truncate(string(job.Content()), 200))
+	}
+	row("Error", job.Error)
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Println()
+	tw = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ATTEMPT\tSTARTED\tDURATION\tSTATUS\tCLASS\tERROR")
+	for _, a := range attempts {
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%d\t%s\t%s\n",
+			a.Number, a.StartedAt.Local().Format(time.DateTime), a.Duration,
+			a.StatusCode, a.ErrorClass, truncate(a.Error, 60))
+	}
+	return tw.Flush()
+}
+
+func runRetry(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("retry", flag.ExitOnError)
+	fs.Usage = func() { fmt.Fprintln(fs.Output(), "usage: dispatchctl retry <job-id>...") }
+	_ = fs.Parse(args)
+	if fs.NArg() == 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	repo, closeRepo, err := openRepo()
+	if err != nil {
+		return err
+	}
+	defer closeRepo()
+	pub, err := openPublisher(ctx)
+	if err != nil {
+		return err
+	}
+	defer pub.Close()
+
+	var failed int
+	for _, id := range fs.Args() {
+		if err := retryJob(ctx, repo, pub, id); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", id, err)
+			failed++
+			continue
+		}
+		fmt.Printf("%s: requeued\n", id)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d jobs not retried", failed, fs.NArg())
+	}
+	return nil
+}
+
+// retryJob resets the job row and republishes it. If publishing fails the
+// reset is undone; retrying again is safe.
+func retryJob(ctx context.Context, repo repository.AdminRepository, pub *publisher, id string) error {
+	_, err := repo.ResetForRetry(ctx, id, func(job *model.WebhookJob) error {
+		attempts, err := repo.ListAttempts(ctx, id)
+		if err != nil {
+			return err
+		}
+		return pub.Publish(ctx, job, len(attempts))
+	})
+	return err
+}
+
+func runCancel(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("cancel", flag.ExitOnError)
+	queued := fs.Bool("queued", false, "record the cancellation of a job not yet picked up by a worker")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: dispatchctl cancel [-queued] <job-id>...")
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(args)
+	if fs.NArg() == 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	repo, closeRepo, err := openRepo()
+	if err != nil {
+		return err
+	}
+	defer closeRepo()
+
+	for _, id := range fs.Args() {
+		ok, err := repo.Cancel(ctx, id, *queued)
+		if errors.Is(err, repository.ErrNotFound) {
+			fmt.Printf("%s: not found; pass -queued if it is still in the queue\n", id)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if !ok {
+			fmt.Printf("%s: already finished, not cancelled\n", id)
+			continue
+		}
+		fmt.Printf("%s: cancelled\n", id)
+	}
+	return nil
+}
+
+func runCallback(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("callback", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: dispatchctl callback <job-id>...")
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(args)
+	if fs.NArg() == 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	repo, closeRepo, err := openRepo()
+	if err != nil {
+		return err
+	}
+	defer closeRepo()
+
+	for _, id := range fs.Args() {
+		ok, err := repo.ResendCallback(ctx, id)
+		if errors.Is(err, repository.ErrNotFound) {
+			fmt.Printf("%s: not found\n", id)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if !ok {
+			fmt.Printf("%s: not finished or no status_callback_url\n", id)
+			continue
+		}
+		fmt.Printf("%s: callback queued\n", id)
+	}
+	return nil
+}
+
+func runRequeue(ctx context.Context, args []string) error {
+	var filter repository.JobFilter
+	fs := flag.NewFlagSet("requeue", flag.ExitOnError)
+	finish := filterFlags(fs, &filter)
+	dryRun := fs.Bool("dry-run", false, "list the matching jobs without requeueing them")
+	_ = fs.Parse(args)
+	if err := finish(); err != nil {
+		return err
+	}
+	switch filter.Status {
+	case "":
+		filter.Status = model.StatusFailed
+	case model.StatusFailed, model.StatusExpired, model.StatusCancelled:
+	default:
+		return fmt.Errorf("requeue only applies to failed, expired or cancelled jobs")
+	}
+
+	repo, closeRepo, err := openRepo()
+	if err != nil {
+		return err
+	}
+	defer closeRepo()
+
+	jobs, err := repo.ListJobs(ctx, filter)
+	if err != nil {
+		return err
+	}
+	if *dryRun {
+		for _, j := range jobs {
+			fmt.Printf("%s\t%s\t%s\n", j.ID, j.ClientURL, truncate(j.Error, 60))
+		}
+		fmt.Printf("%d jobs would be requeued\n", len(jobs))
+		return nil
+	}
+
+	pub, err := openPublisher(ctx)
+	if err != nil {
+		return err
+	}
+	defer pub.Close()
+
+	var requeued int
+	for _, j := range jobs {
+		err := retryJob(ctx, repo, pub, j.ID)
+		if errors.Is(err, repository.ErrNotRetryable) {
+			// Picked up by someone else since it was listed.
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w (%d requeued before the error)", j.ID, err, requeued)
+		}
+		requeued++
+	}
+	fmt.Printf("%d jobs requeued\n", requeued)
+	return nil
+}
+
+func truncate(s string, n int) string {
+	s = strings.ReplaceAll(s, "\n", " ")
+	if len(s) <= n {
+		return s
+	}
+	return s[:n-3] + "..."
+}
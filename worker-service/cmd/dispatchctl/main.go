@@ -0,0 +1,86 @@
+// Command dispatchctl is the operator CLI for DispatchGo: it inspects,
+// retries and cancels jobs, reports queue depth and sends signed test
+// submissions to api-service. It reads the same environment as the worker
+// (DATABASE_URL, BROKER and its settings) plus HMAC_SECRET and API_URL for send.
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"github.com/joho/godotenv"
+	_ "github.com/lib/pq"
+
+	"github.com/Bharat1Rajput/workerService/internal/config"
+	"github.com/Bharat1Rajput/workerService/internal/repository"
+)
+
+const usage = `usage: dispatchctl <command> [flags]
+
+commands:
+  list      list and search jobs
+  show      show a job and its delivery attempts
+  retry     reset failed, expired or cancelled jobs and queue them again
+  cancel    stop pending or retrying jobs
+  callback  send the status callbacks of finished jobs again
+  requeue   retry every job matching a filter
+  queue     show queue depth and consumers
+  send      sign and submit a test webhook to api-service
+
+Run "dispatchctl <command> -h" for a command's flags.
+`
+
+type command func(ctx context.Context, args []string) error
+
+func main() {
+	_ = godotenv.Load()
+
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	commands := map[string]command{
+		"list":     runList,
+		"show":     runShow,
+		"retry":    runRetry,
+		"cancel":   runCancel,
+		"callback": runCallback,
+		"requeue":  runRequeue,
+		"queue":    runQueue,
+		"send":     runSend,
+	}
+	cmd, ok := commands[os.Args[1]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "dispatchctl: unknown command %q\n\n%s", os.Args[1], usage)
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if err := cmd(ctx, os.Args[2:]); err != nil {
+		fmt.Fprintln(os.Stderr, "dispatchctl:", err)
+		os.Exit(1)
+	}
+}
+
+// openRepo connects to DATABASE_URL; the returned func closes the pool.
+func openRepo() (*repository.PostgresJobRepository, func(), error) {
+	dbURL, err := config.LoadDatabaseURL()
+	if err != nil {
+		return nil, nil, err
+	}
+	db, err := sql.Open("postgres", dbURL)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, nil, fmt.Errorf("connect database: %w", err)
+	}
+	return repository.NewPostgresJobRepository(db), func() { db.Close() }, nil
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+
+	"go.uber.org/zap"
+
+	"github.com/Bharat1Rajput/broker"
+	"github.com/Bharat1Rajput/contract"
+	"github.com/Bharat1Rajput/workerService/internal/config"
+	"github.com/Bharat1Rajput/workerService/internal/model"
+)
+
+// publisher puts jobs back on the queue the API publishes to.
+type publisher struct {
+	pub broker.Publisher
+}
+
+func openPublisher(ctx context.Context) (*publisher, error) {
+	cfg, err := config.LoadBroker()
+	if err != nil {
+		return nil, err
+	}
+	pub, err := broker.NewPublisher(ctx, cfg.BrokerConfig(), zap.NewNop())
+	if err != nil {
+		return nil, err
+	}
+	return &publisher{pub: pub}, nil
+}
+
+// Publish queues job, which has attempt delivery attempts recorded.
+func (p *publisher) Publish(ctx context.Context, job *model.WebhookJob, attempt int) error {
+	body, err := contract.Encode(job.Message(attempt))
+	if err != nil {
+		return fmt.Errorf("encode job: %w", err)
+	}
+	return p.pub.Publish(ctx, body, uint8(job.Priority))
+}
+
+func (p *publisher) Close() error {
+	return p.pub.Close()
+}
+
+func runQueue(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("queue", flag.ExitOnError)
+	_ = fs.Parse(args)
+
+	cfg, err := config.LoadBroker()
+	if err != nil {
+		return err
+	}
+	stats, err := broker.QueueStats(ctx, cfg.BrokerConfig())
+	if err != nil {
+		return err
+	}
+	fmt.Printf("broker:     %s\n", cfg.Broker)
+	fmt.Printf("queue:      %s\n", stats.Name)
+	fmt.Printf("ready:      %d\n", stats.Ready)
+	if stats.Pending >= 0 {
+		fmt.Printf("unacked:    %d\n", stats.Pending)
+	}
+	fmt.Printf("consumers:  %d\n", stats.Consumers)
+	return nil
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// runSend submits a webhook to api-service signed the way HMACAuth expects:
+// X-Signature: sha256=<hex HMAC-SHA256 of the raw body>.
+func runSend(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("send", flag.ExitOnError)
+	apiURL := fs.String("api", envOr("API_URL", "http://localhost:8080"), "api-service base URL")
+	secret := fs.String("secret", os.Getenv("HMAC_SECRET"), "HMAC secret (default $HMAC_SECRET)")
+	clientURL := fs.String("url", "", "client_url to deliver to (required)")
+	payload := fs.String("payload", `{"event":"dispatchctl.test"}`, "JSON payload")
+	eventType := fs.String("event-type", "", "event_type")
+	tenant := fs.String("tenant", "", "tenant_id")
+	retryPolicy := fs.String("retry-policy", "", "retry_policy")
+	orderingKey := fs.String("ordering-key", "", "ordering_key")
+	priority := fs.Int("priority", 0, "priority 0-9")
+	ttl := fs.String("ttl", "", `ttl, e.g. "5m"`)
+	callbackURL := fs.String("status-callback", "", "status_callback_url")
+	_ = fs.Parse(args)
+
+	if *clientURL == "" {
+		return fmt.Errorf("send: -url is required")
+	}
+	if *secret == "" {
+		return fmt.Errorf("send: -secret or HMAC_SECRET is required")
+	}
+	if !json.Valid([]byte(*payload)) {
+		return fmt.Errorf("send: -payload must be valid JSON")
+	}
+
+	body, err := json.Marshal(struct {
+		Payload     json.RawMessage `json:"payload"`
+		ClientURL   string          `json:"client_url"`
+		EventType   string          `json:"event_type,omitempty"`
+		TenantID    string          `json:"tenant_id,omitempty"`
+		RetryPolicy string          `json:"retry_policy,omitempty"`
+		OrderingKey string          `json:"ordering_key,omitempty"`
+		Priority    int             `json:"priority,omitempty"`
+		TTL         string          `json:"ttl,omitempty"`
+		CallbackURL string          `json:"status_callback_url,omitempty"`
+	}{
+		Payload:     json.RawMessage(*payload),
+		ClientURL:   *clientURL,
+		EventType:   *eventType,
+		TenantID:    *tenant,
+		RetryPolicy: *retryPolicy,
+		OrderingKey: *orderingKey,
+		Priority:    *priority,
+		TTL:         *ttl,
+		CallbackURL: *callbackURL,
+	})
+	if err != nil {
+		return err
+	}
+
+	mac := hmac.New(sha256.New, []byte(*secret))
+	mac.Write(body)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimRight(*apiURL, "/")+"/webhooks", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("send: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	fmt.Printf("%s\n%s", resp.Status, respBody)
+	if resp.StatusCode != http.StatusAccepted {
+		return fmt.Errorf("send: api returned %s", resp.Status)
+	}
+	return nil
+}
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
@@ -2,19 +2,23 @@ package main
 
 import (
 	"context"
+	"database/sql"
+	"fmt"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
+	"time"
 
 	"github.com/joho/godotenv"
-
-	"github.com/Bharat1Rajput/workerService/internal/config"
-	"github.com/Bharat1Rajput/workerService/internal/consumer"
-	"github.com/Bharat1Rajput/workerService/internal/processor"
-	"github.com/Bharat1Rajput/workerService/internal/repository"
-	"database/sql"
 	_ "github.com/lib/pq"
 	"go.uber.org/zap"
+
+	"github.com/Bharat1Rajput/broker"
+	"github.com/Bharat1Rajput/workerService/internal/config"
+	"github.com/Bharat1Rajput/workerService/internal/migrate"
+	"github.com/Bharat1Rajput/workerService/migrations"
+	"github.com/Bharat1Rajput/workerService/worker"
 )
 
 func main() {
@@ -32,10 +36,17 @@ func run() error {
 	}
 	defer logger.Sync()
 
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		return runMigrate(logger, os.Args[2:])
+	}
+
 	cfg, err := config.Load()
 	if err != nil {
 		logger.Fatal("failed to load config", zap.Error(err))
 	}
+	if cfg.Storage != config.StoragePostgres || cfg.Broker == broker.BackendMemory {
+		logger.Fatal("STORAGE=sqlite and BROKER=memory only work in one process, run dispatchgo instead")
+	}
 	logger.Info("database url", zap.String("url", cfg.DatabaseURL))
 	db, err := sql.Open("postgres", cfg.DatabaseURL)
 	if err != nil {
@@ -46,36 +57,82 @@ func run() error {
 	}
 	defer db.Close()
 
-	repo := repository.NewPostgresJobRepository(db)
-	proc := processor.New(cfg, repo, logger)
+	if cfg.MigrateOnStart {
+		runner, err := migrate.New(db, migrations.FS, logger)
+		if err != nil {
+			logger.Fatal("failed to load migrations", zap.Error(err))
+		}
+		if err := runner.Up(context.Background()); err != nil {
+			logger.Fatal("failed to apply migrations", zap.Error(err))
+		}
+	}
+
+	source, err := broker.NewConsumer(context.Background(), cfg.BrokerConfig(), logger)
+	if err != nil {
+		logger.Fatal("failed to create consumer", zap.Error(err), zap.String("broker", cfg.Broker))
+	}
 
-	cons, err := consumer.New(cfg, proc, logger)
+	w, err := worker.New(cfg, source, worker.PostgresStorage(db), logger)
 	if err != nil {
-		logger.Fatal("failed to create consumer", zap.Error(err))
+		source.Close()
+		logger.Fatal("failed to build worker pool", zap.Error(err))
 	}
-	defer cons.Close()
+	defer w.Close()
 
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	errCh := make(chan error, 1)
-	go func() {
-		if err := cons.Start(ctx); err != nil {
-			errCh <- err
-		}
-	}()
+	return w.Run(ctx)
+}
 
-	stop := make(chan os.Signal, 1)
-	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+// runMigrate implements "worker-service migrate [up | down [steps] | status]".
+func runMigrate(logger *zap.Logger, args []string) error {
+	dbURL, err := config.LoadDatabaseURL()
+	if err != nil {
+		return err
+	}
+	db, err := sql.Open("postgres", dbURL)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
 
-	select {
-	case <-stop:
-		logger.Info("shutdown signal received")
-		cancel()
-	case err := <-errCh:
+	runner, err := migrate.New(db, migrations.FS, logger)
+	if err != nil {
 		return err
 	}
 
-	return nil
-}
+	ctx := context.Background()
+	cmd := "up"
+	if len(args) > 0 {
+		cmd = args[0]
+	}
 
+	switch cmd {
+	case "up":
+		return runner.Up(ctx)
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps < 1 {
+				return fmt.Errorf("migrate: steps must be a positive integer")
+			}
+		}
+		return runner.Down(ctx, steps)
+	case "status":
+		statuses, err := runner.Status(ctx)
+		if err != nil {
+			return err
+		}
+		for _, s := range statuses {
+			applied := "pending"
+			if s.AppliedAt != nil {
+				applied = s.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Printf("%04d  %-40s %s\n", s.Version, s.Name, applied)
+		}
+		return nil
+	default:
+		return fmt.Errorf("migrate: unknown command %q (want up, down or status)", cmd)
+	}
+}
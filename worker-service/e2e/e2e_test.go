@@ -0,0 +1,588 @@
+// Package e2e runs a submission through the whole pipeline in one process:
+// api-service's HTTP API publishes to an in-memory queue, a worker
+// consumes it and delivers to an httptest.Server, and the outcome is read
+// back from an in-memory repository.
+package e2e
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zaptest"
+
+	"github.com/Bharat1Rajput/apiService/server"
+	"github.com/Bharat1Rajput/broker"
+	"github.com/Bharat1Rajput/contract"
+	"github.com/Bharat1Rajput/ssrf"
+	"github.com/Bharat1Rajput/workerService/internal/callback"
+	"github.com/Bharat1Rajput/workerService/internal/config"
+	"github.com/Bharat1Rajput/workerService/internal/consumer"
+	"github.com/Bharat1Rajput/workerService/internal/endpoint"
+	"github.com/Bharat1Rajput/workerService/internal/httpclient"
+	"github.com/Bharat1Rajput/workerService/internal/model"
+	"github.com/Bharat1Rajput/workerService/internal/processor"
+	"github.com/Bharat1Rajput/workerService/internal/repository"
+	"github.com/Bharat1Rajput/workerService/internal/retry"
+)
+
+const secret = "e2e-secret"
+
+// harness is one API and one worker sharing a queue and a repository. The
+// worker can be restarted, keeping both.
+type harness struct {
+	t     *testing.T
+	api   *httptest.Server
+	queue *broker.MemoryQueue
+	repo  *repository.MemoryJobRepository
+	stop  func()
+}
+
+func newHarness(t *testing.T) *harness {
+	t.Helper()
+	logger := zaptest.NewLogger(t)
+
+	queue := broker.NewMemoryQueue()
+	// Loopback client URLs are allowed so jobs can target an
+	// httptest.Server.
+	apiCfg := &server.Config{HMACSecret: secret, SSRFAllowCIDRs: []string{"127.0.0.0/8", "::1/128"}}
+	apiSrv, err := server.New(apiCfg, queue, nil, logger)
+	if err != nil {
+		t.Fatalf("build api: %v", err)
+	}
+	api := httptest.NewServer(apiSrv.Handler())
+	t.Cleanup(api.Close)
+
+	h := &harness{t: t, api: api, queue: queue, repo: repository.NewMemoryJobRepository()}
+	h.stop = h.startWorker()
+	t.Cleanup(func() { h.stop() })
+	return h
+}
+
+// startWorker starts a worker on the harness's queue and repository and
+// returns a function that stops it, waiting for its deliveries to finish.
+func (h *harness) startWorker() (stop func()) {
+	t := h.t
+	t.Helper()
+	logger := zaptest.NewLogger(t)
+
+	cfg := &config.Config{
+		MaxRetries:           3,
+		BackoffBaseMS:        10,
+		HTTPClientTimeoutSec: 5,
+		WorkerConcurrency:    4,
+		DrainTimeoutSec:      5,
+		ProcessingLeaseSec:   60,
+		AttemptCaptureBytes:  2048,
+		RetryAfterMaxSec:     60,
+	}
+	policies, err := retry.Load("", retry.DefaultPolicy(cfg.MaxRetries, 10*time.Millisecond))
+	if err != nil {
+		t.Fatalf("load retry policies: %v", err)
+	}
+	endpoints, err := endpoint.Load("")
+	if err != nil {
+		t.Fatalf("load endpoints: %v", err)
+	}
+	guard, err := ssrf.New(nil, []string{"127.0.0.0/8", "::1/128"})
+	if err != nil {
+		t.Fatalf("build ssrf guard: %v", err)
+	}
+	clients := httpclient.NewPool(5*time.Second, guard, time.Minute, logger)
+	callbacks := callback.New(clients.Default(), secret, 3, 10*time.Millisecond, 2, 100, h.repo, logger)
+
+	proc := processor.New(cfg, h.repo, policies, endpoints, clients, nil, callbacks, logger)
+	cons := consumer.New(cfg, h.queue.NewConsumer(cfg.WorkerConcurrency), proc, logger)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- cons.Start(ctx) }()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			cancel()
+			if err := <-done; err != nil {
+				t.Errorf("consumer: %v", err)
+			}
+			_ = callbacks.Close(context.Background())
+			_ = cons.Close()
+		})
+	}
+}
+
+// restartWorker stops the running worker and starts a new one, as a deploy
+// would, so that only the queue and the repository carry over.
+func (h *harness) restartWorker() {
+	h.t.Helper()
+	h.stop()
+	h.stop = h.startWorker()
+}
+
+// submit signs and posts req to POST /webhooks, returning the status code
+// and, when accepted, the job id.
+func (h *harness) submit(req map[string]any) (int, string) {
+	h.t.Helper()
+	return h.submitWithHeaders(req, nil)
+}
+
+// submitWithHeaders is submit with extra request headers.
+func (h *harness) submitWithHeaders(req map[string]any, header http.Header) (int, string) {
+	h.t.Helper()
+	body, err := json.Marshal(req)
+	if err != nil {
+		h.t.Fatalf("marshal submission: %v", err)
+	}
+	httpReq, err := http.NewRequest(http.MethodPost, h.api.URL+"/webhooks", bytes.NewReader(body))
+	if err != nil {
+		h.t.Fatalf("build submission: %v", err)
+	}
+	for name, values := range header {
+		httpReq.Header[name] = values
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("X-Signature", "sha256="+sign(body))
+
+	resp, err := http.DefaultClient.Do(httpReq)
+	if err != nil {
+		h.t.Fatalf("submit: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var accepted struct {
+		JobID string `json:"job_id"`
+	}
+	if resp.StatusCode == http.StatusAccepted {
+		if err := json.NewDecoder(resp.Body).Decode(&accepted); err != nil {
+			h.t.Fatalf("decode submission response: %v", err)
+		}
+	}
+	return resp.StatusCode, accepted.JobID
+}
+
+// await waits for job id to reach a terminal status and returns it.
+func (h *harness) await(id string) *model.WebhookJob {
+	h.t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for time.Now().Before(deadline) {
+		job, err := h.repo.GetJob(context.Background(), id)
+		if err == nil {
+			switch job.Status {
+			case model.StatusSuccess, model.StatusFailed, model.StatusExpired, model.StatusCancelled:
+				return job
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	h.t.Fatalf("job %s did not finish", id)
+	return nil
+}
+
+func (h *harness) attempts(id string) []model.Attempt {
+	h.t.Helper()
+	attempts, err := h.repo.ListAttempts(context.Background(), id)
+	if err != nil {
+		h.t.Fatalf("list attempts: %v", err)
+	}
+	return attempts
+}
+
+// receiver answers deliveries with the scripted status codes, repeating the
+// last one, and records what it was sent. Failed deliveries are answered
+// with retryAfter as their Retry-After header when it is set.
+type receiver struct {
+	*httptest.Server
+
+	mu         sync.Mutex
+	statuses   []int
+	retryAfter string
+	requests   []*http.Request
+	bodies     [][]byte
+}
+
+func newReceiver(t *testing.T, statuses ...int) *receiver {
+	rc := &receiver{statuses: statuses}
+	rc.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		rc.mu.Lock()
+		n := len(rc.requests)
+		rc.requests = append(rc.requests, r)
+		rc.bodies = append(rc.bodies, body)
+		retryAfter := rc.retryAfter
+		rc.mu.Unlock()
+
+		status := rc.statuses[min(n, len(rc.statuses)-1)]
+		if status >= 300 && retryAfter != "" {
+			w.Header().Set("Retry-After", retryAfter)
+		}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(rc.Close)
+	return rc
+}
+
+func (rc *receiver) calls() int {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	return len(rc.requests)
+}
+
+func sign(body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func statusCodes(attempts []model.Attempt) []int {
+	codes := make([]int, len(attempts))
+	for i, a := range attempts {
+		codes[i] = a.StatusCode
+	}
+	return codes
+}
+
+func TestDeliverySucceeds(t *testing.T) {
+	h := newHarness(t)
+	rc := newReceiver(t, http.StatusOK)
+
+	code, id := h.submit(map[string]any{
+		"client_url": rc.URL,
+		"payload":    map[string]any{"order": 42},
+		"tenant_id":  "acme",
+	})
+	if code != http.StatusAccepted {
+		t.Fatalf("submit: got status %d, want %d", code, http.StatusAccepted)
+	}
+
+	job := h.await(id)
+	if job.Status != model.StatusSuccess {
+		t.Fatalf("status: got %q, want %q (error %q)", job.Status, model.StatusSuccess, job.Error)
+	}
+	if job.RetryCount != 0 || job.TenantID != "acme" {
+		t.Errorf("job: got retry_count %d tenant %q, want 0 and %q", job.RetryCount, job.TenantID, "acme")
+	}
+	if n := len(h.attempts(id)); n != 1 {
+		t.Errorf("attempts: got %d, want 1", n)
+	}
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if got := string(rc.bodies[0]); got != `{"order":42}` {
+		t.Errorf("delivered body: got %s", got)
+	}
+	if got := rc.requests[0].Header.Get("X-Webhook-Job-Id"); got != id {
+		t.Errorf("X-Webhook-Job-Id: got %q, want %q", got, id)
+	}
+	if got := rc.requests[0].Header.Get("Content-Type"); got != model.ContentTypeJSON {
+		t.Errorf("Content-Type: got %q, want %q", got, model.ContentTypeJSON)
+	}
+}
+
+func TestPayloadIsDeliveredVerbatim(t *testing.T) {
+	h := newHarness(t)
+	rc := newReceiver(t, http.StatusOK)
+
+	// Duplicate and unsorted keys and a NUL escape, which a JSONB column
+	// would rewrite or refuse.
+	const payload = `{"b":1,"a":{"z":true,"y":null},"b":2,"s":"a\u0000b"}`
+	_, id := h.submit(map[string]any{"client_url": rc.URL, "payload": json.RawMessage(payload)})
+	if job := h.await(id); job.Status != model.StatusSuccess {
+		t.Fatalf("status: got %q, want %q (error %q)", job.Status, model.StatusSuccess, job.Error)
+	}
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if got := string(rc.bodies[0]); got != payload {
+		t.Errorf("delivered body: got %s, want %s", got, payload)
+	}
+}
+
+func TestRetriesUntilSuccess(t *testing.T) {
+	h := newHarness(t)
+	rc := newReceiver(t, http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusOK)
+
+	_, id := h.submit(map[string]any{"client_url": rc.URL, "payload": map[string]any{"n": 1}})
+
+	job := h.await(id)
+	if job.Status != model.StatusSuccess {
+		t.Fatalf("status: got %q, want %q (error %q)", job.Status, model.StatusSuccess, job.Error)
+	}
+	if job.RetryCount != 2 {
+		t.Errorf("retry_count: got %d, want 2", job.RetryCount)
+	}
+	codes := statusCodes(h.attempts(id))
+	want := []int{503, 503, 200}
+	if len(codes) != len(want) || codes[0] != want[0] || codes[1] != want[1] || codes[2] != want[2] {
+		t.Errorf("attempt status codes: got %v, want %v", codes, want)
+	}
+}
+
+// TestOrderingKeyWaitsForRetry checks that a job waits behind an earlier one
+// with the same ordering key while that one is retried.
+func TestOrderingKeyWaitsForRetry(t *testing.T) {
+	h := newHarness(t)
+	rc := newReceiver(t, http.StatusServiceUnavailable, http.StatusOK)
+
+	_, first := h.submit(map[string]any{"client_url": rc.URL, "payload": map[string]any{"n": 1}, "ordering_key": "order-7"})
+	_, second := h.submit(map[string]any{"client_url": rc.URL, "payload": map[string]any{"n": 2}, "ordering_key": "order-7"})
+
+	for _, id := range []string{first, second} {
+		if job := h.await(id); job.Status != model.StatusSuccess {
+			t.Fatalf("job %s status: got %q, want %q (error %q)", id, job.Status, model.StatusSuccess, job.Error)
+		}
+	}
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	var got []string
+	for _, b := range rc.bodies {
+		got = append(got, string(b))
+	}
+	want := []string{`{"n":1}`, `{"n":1}`, `{"n":2}`}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] || got[2] != want[2] {
+		t.Errorf("delivery order: got %v, want %v", got, want)
+	}
+}
+
+// TestOrderingKeyWaitsAcrossRestart checks that a job submitted after a
+// worker restart still waits for an earlier one with the same ordering key
+// that the previous worker left waiting to be retried.
+func TestOrderingKeyWaitsAcrossRestart(t *testing.T) {
+	h := newHarness(t)
+	rc := newReceiver(t, http.StatusServiceUnavailable, http.StatusOK)
+	rc.retryAfter = "1"
+
+	_, first := h.submit(map[string]any{"client_url": rc.URL, "payload": map[string]any{"n": 1}, "ordering_key": "order-7"})
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		job, err := h.repo.GetJob(context.Background(), first)
+		if err == nil && job.Status == model.StatusPending && job.RetryCount == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("job %s was not scheduled for a retry", first)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	h.restartWorker()
+	_, second := h.submit(map[string]any{"client_url": rc.URL, "payload": map[string]any{"n": 2}, "ordering_key": "order-7"})
+
+	for _, id := range []string{first, second} {
+		if job := h.await(id); job.Status != model.StatusSuccess {
+			t.Fatalf("job %s status: got %q, want %q (error %q)", id, job.Status, model.StatusSuccess, job.Error)
+		}
+	}
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	var got []string
+	for _, b := range rc.bodies {
+		got = append(got, string(b))
+	}
+	want := []string{`{"n":1}`, `{"n":1}`, `{"n":2}`}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] || got[2] != want[2] {
+		t.Errorf("delivery order: got %v, want %v", got, want)
+	}
+}
+
+func TestRetriesExhausted(t *testing.T) {
+	h := newHarness(t)
+	rc := newReceiver(t, http.StatusInternalServerError)
+
+	_, id := h.submit(map[string]any{"client_url": rc.URL, "payload": map[string]any{"n": 1}})
+
+	job := h.await(id)
+	if job.Status != model.StatusFailed {
+		t.Fatalf("status: got %q, want %q", job.Status, model.StatusFailed)
+	}
+	if job.Classification != model.ClassificationRetryable {
+		t.Errorf("classification: got %q, want %q", job.Classification, model.ClassificationRetryable)
+	}
+	if job.RetryCount != 3 || len(h.attempts(id)) != 3 || rc.calls() != 3 {
+		t.Errorf("got retry_count %d, %d attempts and %d calls, want 3 of each",
+			job.RetryCount, len(h.attempts(id)), rc.calls())
+	}
+}
+
+func TestDuplicateDeliveryIsSkipped(t *testing.T) {
+	h := newHarness(t)
+	rc := newReceiver(t, http.StatusOK)
+
+	_, id := h.submit(map[string]any{"client_url": rc.URL, "payload": map[string]any{"n": 1}})
+	job := h.await(id)
+
+	body, err := contract.Encode(job.Message(1))
+	if err != nil {
+		t.Fatalf("encode job: %v", err)
+	}
+	if err := h.queue.Publish(context.Background(), body, 0); err != nil {
+		t.Fatalf("publish duplicate: %v", err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for stats := h.queue.Stats(); (stats.Ready > 0 || stats.Pending > 0) && time.Now().Before(deadline); stats = h.queue.Stats() {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if rc.calls() != 1 {
+		t.Errorf("calls: got %d, want 1", rc.calls())
+	}
+	if job := h.await(id); job.Status != model.StatusSuccess {
+		t.Errorf("status: got %q, want %q", job.Status, model.StatusSuccess)
+	}
+}
+
+func TestTraceContextIsForwarded(t *testing.T) {
+	h := newHarness(t)
+	rc := newReceiver(t, http.StatusOK)
+
+	const traceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	header := http.Header{}
+	header.Set("Traceparent", traceparent)
+	header.Set("Baggage", "user=alice")
+	_, id := h.submitWithHeaders(map[string]any{"client_url": rc.URL, "payload": map[string]any{"n": 1}}, header)
+	h.await(id)
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if got := rc.requests[0].Header.Get("Traceparent"); got != traceparent {
+		t.Errorf("traceparent: got %q, want %q", got, traceparent)
+	}
+	if got := rc.requests[0].Header.Get("Baggage"); got != "" {
+		t.Errorf("baggage: got %q, want it not forwarded", got)
+	}
+}
+
+func TestAttemptNumberingContinuesFromMessage(t *testing.T) {
+	h := newHarness(t)
+	rc := newReceiver(t, http.StatusOK)
+
+	now := time.Now().UTC()
+	job := &model.WebhookJob{Job: contract.Job{
+		ID:        "republished-job",
+		Body:      []byte(`{"n":1}`),
+		ClientURL: rc.URL,
+		Status:    model.StatusPending,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}}
+	body, err := contract.Encode(job.Message(3))
+	if err != nil {
+		t.Fatalf("encode job: %v", err)
+	}
+	if err := h.queue.Publish(context.Background(), body, 0); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+	h.await(job.ID)
+
+	attempts := h.attempts(job.ID)
+	if len(attempts) != 1 || attempts[0].Number != 4 {
+		t.Fatalf("attempts: got %+v, want one numbered 4", attempts)
+	}
+}
+
+func TestPermanentFailureIsNotRetried(t *testing.T) {
+	h := newHarness(t)
+	rc := newReceiver(t, http.StatusBadRequest)
+
+	_, id := h.submit(map[string]any{"client_url": rc.URL, "payload": map[string]any{"n": 1}})
+
+	job := h.await(id)
+	if job.Status != model.StatusFailed || job.Classification != model.ClassificationPermanent {
+		t.Fatalf("got status %q classification %q, want %q and %q",
+			job.Status, job.Classification, model.StatusFailed, model.ClassificationPermanent)
+	}
+	if rc.calls() != 1 {
+		t.Errorf("calls: got %d, want 1", rc.calls())
+	}
+}
+
+func TestStatusCallback(t *testing.T) {
+	h := newHarness(t)
+	rc := newReceiver(t, http.StatusServiceUnavailable, http.StatusOK)
+	cb := newReceiver(t, http.StatusNoContent)
+
+	_, id := h.submit(map[string]any{
+		"client_url":          rc.URL,
+		"payload":             map[string]any{"n": 1},
+		"status_callback_url": cb.URL,
+	})
+	h.await(id)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for cb.calls() == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if cb.calls() != 1 {
+		t.Fatalf("callbacks: got %d, want 1", cb.calls())
+	}
+	// The outcome is recorded once the response is in.
+	for time.Now().Before(deadline) {
+		if status, _ := h.repo.Callback(context.Background(), id); status != model.CallbackPending {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if status, attempts := h.repo.Callback(context.Background(), id); status != model.CallbackSent || attempts != 1 {
+		t.Errorf("recorded callback: got %q after %d requests, want %q after 1", status, attempts, model.CallbackSent)
+	}
+
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	header := cb.requests[0].Header
+	timestamp := header.Get("X-Signature-Timestamp")
+	if sent, err := strconv.ParseInt(timestamp, 10, 64); err != nil || time.Since(time.Unix(sent, 0)) > time.Minute {
+		t.Errorf("callback timestamp: got %q", timestamp)
+	}
+	if got := header.Get("X-Signature"); got != callback.Sign([]byte(secret), timestamp, cb.bodies[0]) {
+		t.Errorf("callback signature: got %q", got)
+	}
+	var status callback.Status
+	if err := json.Unmarshal(cb.bodies[0], &status); err != nil {
+		t.Fatalf("decode callback: %v", err)
+	}
+	if status.JobID != id || status.Status != model.StatusSuccess || status.Attempts != 2 {
+		t.Errorf("callback: got job %q status %q attempts %d, want %q, %q and 2",
+			status.JobID, status.Status, status.Attempts, id, model.StatusSuccess)
+	}
+}
+
+func TestRejectedSubmissionIsNotQueued(t *testing.T) {
+	h := newHarness(t)
+	rc := newReceiver(t, http.StatusOK)
+
+	body := []byte(`{"client_url":"` + rc.URL + `","payload":{}}`)
+	resp, err := http.Post(h.api.URL+"/webhooks", "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("submit: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("unsigned submission: got status %d, want %d", resp.StatusCode, http.StatusUnauthorized)
+	}
+
+	if code, _ := h.submit(map[string]any{"client_url": "ftp://example.com", "payload": map[string]any{}}); code != http.StatusBadRequest {
+		t.Errorf("invalid client_url: got status %d, want %d", code, http.StatusBadRequest)
+	}
+	keyed := map[string]any{"client_url": rc.URL, "payload": map[string]any{}, "ordering_key": "order-7", "priority": 5}
+	if code, _ := h.submit(keyed); code != http.StatusBadRequest {
+		t.Errorf("priority with ordering_key: got status %d, want %d", code, http.StatusBadRequest)
+	}
+
+	if stats := h.queue.Stats(); stats.Ready != 0 || stats.Pending != 0 {
+		t.Errorf("queue: got %d ready and %d pending, want none", stats.Ready, stats.Pending)
+	}
+	if rc.calls() != 0 {
+		t.Errorf("receiver: got %d calls, want none", rc.calls())
+	}
+}
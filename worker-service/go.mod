@@ -3,10 +3,37 @@ module github.com/Bharat1Rajput/workerService
 go 1.22
 
 require (
+	github.com/Bharat1Rajput/blob v0.0.0
+	github.com/Bharat1Rajput/broker v0.0.0
+	github.com/Bharat1Rajput/contract v0.0.0
+	github.com/Bharat1Rajput/jobstore v0.0.0
+	github.com/Bharat1Rajput/ssrf v0.0.0
+	github.com/go-chi/chi/v5 v5.0.12
 	github.com/joho/godotenv v1.5.1
 	github.com/lib/pq v1.11.2
-	github.com/rabbitmq/amqp091-go v1.10.0
 	go.uber.org/zap v1.27.0
 )
 
-require go.uber.org/multierr v1.10.0 // indirect
+require (
+	github.com/cespare/xxhash/v2 v2.2.0 // indirect
+	github.com/dgryski/go-rendezvous v0.0.0-20200823014737-9f7001d12a5f // indirect
+	github.com/google/uuid v1.6.0 // indirect
+	github.com/klauspost/compress v1.17.9 // indirect
+	github.com/nats-io/nats.go v1.38.0 // indirect
+	github.com/nats-io/nkeys v0.4.9 // indirect
+	github.com/nats-io/nuid v1.0.1 // indirect
+	github.com/rabbitmq/amqp091-go v1.10.0 // indirect
+	github.com/redis/go-redis/v9 v9.7.0 // indirect
+	go.uber.org/multierr v1.10.0 // indirect
+	golang.org/x/crypto v0.31.0 // indirect
+	golang.org/x/sys v0.28.0 // indirect
+	golang.org/x/text v0.21.0 // indirect
+)
+
+replace (
+	github.com/Bharat1Rajput/blob => ../blob
+	github.com/Bharat1Rajput/broker => ../broker
+	github.com/Bharat1Rajput/contract => ../contract
+	github.com/Bharat1Rajput/jobstore => ../jobstore
+	github.com/Bharat1Rajput/ssrf => ../ssrf
+)
@@ -0,0 +1,131 @@
+package admin
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/Bharat1Rajput/workerService/internal/endpoint"
+	"github.com/Bharat1Rajput/workerService/internal/transform"
+)
+
+// maxPreviewBytes bounds sample payloads sent to the preview routes.
+const maxPreviewBytes = 1 << 20
+
+// Handler serves the worker's operator API. It is meant for an internal
+// port; when token is non-empty every route except /health requires
+// "Authorization: Bearer <token>".
+type Handler struct {
+	endpoints *endpoint.Registry
+	token     string
+}
+
+func NewHandler(endpoints *endpoint.Registry, token string) *Handler {
+	return &Handler{
+		endpoints: endpoints,
+		token:     token,
+	}
+}
+
+func (h *Handler) Routes() chi.Router {
+	r := chi.NewRouter()
+	r.Get("/health", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	})
+	r.Group(func(r chi.Router) {
+		r.Use(h.requireToken)
+		r.Post("/transform/preview", h.handlePreview)
+		r.Post("/endpoints/{name}/transform/preview", h.handleEndpointPreview)
+	})
+	return r
+}
+
+func (h *Handler) requireToken(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if h.token != "" {
+			got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || subtle.ConstantTimeCompare([]byte(got), []byte(h.token)) != 1 {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+type previewRequest struct {
+	Transform *transform.Spec `json:"transform"`
+	Payload   json.RawMessage `json:"payload"`
+}
+
+type previewResponse struct {
+	Endpoint    string `json:"endpoint,omitempty"`
+	ContentType string `json:"content_type"`
+	Body        string `json:"body"`
+}
+
+// handlePreview dry-runs an ad-hoc transformation, so a spec can be tried out
+// before it is added to ENDPOINTS_FILE.
+func (h *Handler) handlePreview(w http.ResponseWriter, r *http.Request) {
+	var req previewRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxPreviewBytes)).Decode(&req); err != nil {
+		http.Error(w, "invalid json body", http.StatusBadRequest)
+		return
+	}
+	if req.Transform == nil || len(req.Payload) == 0 {
+		http.Error(w, "transform and payload are required", http.StatusBadRequest)
+		return
+	}
+
+	t, err := transform.Compile(req.Transform)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	h.preview(w, "", t, req.Payload)
+}
+
+// handleEndpointPreview applies a configured endpoint's transformation to the
+// sample payload in the request body.
+func (h *Handler) handleEndpointPreview(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+	ep, ok := h.endpoints.Get(name)
+	if !ok {
+		http.Error(w, "endpoint not found", http.StatusNotFound)
+		return
+	}
+	if ep.Transformer() == nil {
+		http.Error(w, "endpoint has no transform", http.StatusNotFound)
+		return
+	}
+
+	payload, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxPreviewBytes))
+	if err != nil {
+		http.Error(w, "failed to read body", http.StatusBadRequest)
+		return
+	}
+	h.preview(w, name, ep.Transformer(), payload)
+}
+
+func (h *Handler) preview(w http.ResponseWriter, name string, t *transform.Transform, payload []byte) {
+	body, err := t.Apply(payload)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	writeJSON(w, http.StatusOK, previewResponse{
+		Endpoint:    name,
+		ContentType: t.ContentType(),
+		Body:        string(body),
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
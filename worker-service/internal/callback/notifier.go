@@ -0,0 +1,255 @@
+// Package callback tells submitters how their jobs ended by POSTing a signed
+// status document to the job's status_callback_url.
+package callback
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Bharat1Rajput/workerService/internal/model"
+)
+
+// Status is the document sent when a job reaches a terminal status.
+type Status struct {
+	JobID          string               `json:"job_id"`
+	TenantID       string               `json:"tenant_id,omitempty"`
+	EventType      string               `json:"event_type,omitempty"`
+	Status         model.JobStatus      `json:"status"`
+	Classification model.Classification `json:"classification,omitempty"`
+	Attempts       int                  `json:"attempts"`
+	LastError      string               `json:"last_error,omitempty"`
+	CompletedAt    time.Time            `json:"completed_at"`
+}
+
+// Recorder stores how each status callback ended. Callbacks it has no
+// outcome for, lost with the queue when it was full or the worker stopped
+// first, are left pending to be sent again.
+type Recorder interface {
+	FinishCallback(ctx context.Context, jobID string, status model.CallbackStatus, attempts int) error
+}
+
+// recordTimeout bounds storing a callback's outcome, which happens after
+// the delivery and may run while the Notifier is closing.
+const recordTimeout = 5 * time.Second
+
+// Notifier delivers Status documents in the background, so a slow callback
+// receiver holds up neither the job's worker slot nor its acknowledgement.
+//
+// Each request carries X-Signature-Timestamp, the Unix time it was sent, and
+// an X-Signature of "sha256=" and the hex HMAC-SHA256 of the timestamp, a
+// dot and the body. Receivers reject old timestamps to stop replays.
+type Notifier struct {
+	client      *http.Client
+	secret      []byte
+	maxAttempts int
+	backoff     time.Duration
+	recorder    Recorder
+	logger      *zap.Logger
+
+	mu     sync.RWMutex
+	closed bool
+	queue  chan notification
+	wg     sync.WaitGroup
+
+	// ctx ends retries still waiting when Close gives up.
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+type notification struct {
+	url    string
+	status Status
+}
+
+// New returns a Notifier sending through client, which should be guarded
+// against internal destinations. workers goroutines deliver up to queueSize
+// waiting documents. A request is tried up to maxAttempts times, waiting
+// backoff and then twice as long after each failure. Outcomes are stored
+// with recorder, if not nil.
+func New(client *http.Client, secret string, maxAttempts int, backoff time.Duration, workers, queueSize int, recorder Recorder, logger *zap.Logger) *Notifier {
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+	if workers < 1 {
+		workers = 1
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	n := &Notifier{
+		client:      client,
+		secret:      []byte(secret),
+		maxAttempts: maxAttempts,
+		backoff:     backoff,
+		recorder:    recorder,
+		logger:      logger,
+		queue:       make(chan notification, max(queueSize, 0)),
+		ctx:         ctx,
+		cancel:      cancel,
+	}
+	for i := 0; i < workers; i++ {
+		n.wg.Add(1)
+		go n.run()
+	}
+	return n
+}
+
+// Enqueue queues status for delivery to url and reports whether it was
+// accepted. It does not block: a document is refused when the queue is full
+// or the Notifier is closed.
+func (n *Notifier) Enqueue(url string, status Status) bool {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	if n.closed {
+		return false
+	}
+	select {
+	case n.queue <- notification{url: url, status: status}:
+		return true
+	default:
+		return false
+	}
+}
+
+// Close stops accepting documents and waits for the queued ones to be
+// delivered. Once ctx ends, deliveries still retrying are abandoned and
+// Close returns ctx's error.
+func (n *Notifier) Close(ctx context.Context) error {
+	n.mu.Lock()
+	if !n.closed {
+		n.closed = true
+		close(n.queue)
+	}
+	n.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		n.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		n.cancel()
+		return nil
+	case <-ctx.Done():
+		n.cancel()
+		<-done
+		return fmt.Errorf("callback: close: %w", ctx.Err())
+	}
+}
+
+func (n *Notifier) run() {
+	defer n.wg.Done()
+	for msg := range n.queue {
+		attempts, err := n.deliver(n.ctx, msg.url, msg.status)
+		if err != nil {
+			n.logger.Warn("callback: status callback failed",
+				zap.String("job_id", msg.status.JobID),
+				zap.Error(err),
+			)
+		}
+		n.record(msg.status.JobID, attempts, err)
+	}
+}
+
+// record stores the outcome of a delivery. One abandoned by Close is left
+// pending.
+func (n *Notifier) record(jobID string, attempts int, err error) {
+	if n.recorder == nil || (err != nil && n.ctx.Err() != nil) {
+		return
+	}
+	status := model.CallbackSent
+	if err != nil {
+		status = model.CallbackFailed
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), recordTimeout)
+	defer cancel()
+	if err := n.recorder.FinishCallback(ctx, jobID, status, attempts); err != nil {
+		n.logger.Warn("callback: recording status callback failed",
+			zap.String("job_id", jobID),
+			zap.Error(err),
+		)
+	}
+}
+
+// Notify POSTs status to url and waits for the result. Network errors, 429
+// and 5xx responses are retried; any other non-2xx response ends the
+// delivery.
+func (n *Notifier) Notify(ctx context.Context, url string, status Status) error {
+	_, err := n.deliver(ctx, url, status)
+	return err
+}
+
+// deliver is Notify, also returning the number of requests made.
+func (n *Notifier) deliver(ctx context.Context, url string, status Status) (int, error) {
+	body, err := json.Marshal(status)
+	if err != nil {
+		return 0, fmt.Errorf("callback: marshal status: %w", err)
+	}
+
+	wait := n.backoff
+	for attempt := 1; ; attempt++ {
+		retryable, err := n.post(ctx, url, body, status.JobID)
+		if err == nil {
+			return attempt, nil
+		}
+		if !retryable || attempt == n.maxAttempts {
+			return attempt, fmt.Errorf("callback: job %s after %d attempts: %w", status.JobID, attempt, err)
+		}
+
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return attempt, fmt.Errorf("callback: job %s: %w", status.JobID, ctx.Err())
+		}
+		wait *= 2
+	}
+}
+
+// Sign returns the X-Signature value for body sent at timestamp, a Unix
+// time in seconds.
+func Sign(secret []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func (n *Notifier) post(ctx context.Context, url string, body []byte, jobID string) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	// Each attempt is signed afresh so that a retry is not mistaken for
+	// a replay of the first request.
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", model.ContentTypeJSON)
+	req.Header.Set("X-Signature-Timestamp", timestamp)
+	req.Header.Set("X-Signature", Sign(n.secret, timestamp, body))
+	req.Header.Set("X-Webhook-Job-Id", jobID)
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	retryable := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+	return retryable, fmt.Errorf("non-2xx status %d", resp.StatusCode)
+}
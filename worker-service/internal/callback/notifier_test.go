@@ -0,0 +1,157 @@
+package callback
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zaptest"
+
+	"github.com/Bharat1Rajput/workerService/internal/model"
+)
+
+func TestCloseDeliversQueued(t *testing.T) {
+	type request struct {
+		timestamp, signature string
+		body                 []byte
+	}
+	got := make(chan request, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		got <- request{r.Header.Get("X-Signature-Timestamp"), r.Header.Get("X-Signature"), body}
+	}))
+	defer srv.Close()
+
+	n := New(srv.Client(), "secret", 1, time.Millisecond, 1, 10, nil, zaptest.NewLogger(t))
+	for i := 0; i < 3; i++ {
+		if !n.Enqueue(srv.URL, Status{JobID: strconv.Itoa(i)}) {
+			t.Fatalf("enqueue %d refused", i)
+		}
+	}
+	if err := n.Close(context.Background()); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	if n.Enqueue(srv.URL, Status{JobID: "late"}) {
+		t.Error("enqueue after close: accepted")
+	}
+
+	close(got)
+	count := 0
+	for req := range got {
+		count++
+		sent, err := strconv.ParseInt(req.timestamp, 10, 64)
+		if err != nil || time.Since(time.Unix(sent, 0)) > time.Minute {
+			t.Errorf("timestamp: got %q", req.timestamp)
+		}
+		if want := Sign([]byte("secret"), req.timestamp, req.body); req.signature != want {
+			t.Errorf("signature: got %q, want %q", req.signature, want)
+		}
+	}
+	if count != 3 {
+		t.Errorf("delivered: got %d, want 3", count)
+	}
+}
+
+func TestEnqueueRefusesWhenFull(t *testing.T) {
+	block := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-block
+	}))
+	defer srv.Close()
+
+	n := New(srv.Client(), "secret", 1, time.Millisecond, 1, 1, nil, zaptest.NewLogger(t))
+	defer func() {
+		close(block)
+		_ = n.Close(context.Background())
+	}()
+
+	accepted := 0
+	for i := 0; i < 5; i++ {
+		if n.Enqueue(srv.URL, Status{JobID: strconv.Itoa(i)}) {
+			accepted++
+		}
+	}
+	// One document is being delivered and one waits in the queue.
+	if accepted < 1 || accepted > 2 {
+		t.Errorf("accepted: got %d, want 1 or 2", accepted)
+	}
+}
+
+type outcome struct {
+	status   model.CallbackStatus
+	attempts int
+}
+
+type recorder struct {
+	mu       sync.Mutex
+	outcomes map[string]outcome
+}
+
+func (r *recorder) FinishCallback(_ context.Context, jobID string, status model.CallbackStatus, attempts int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.outcomes[jobID] = outcome{status, attempts}
+	return nil
+}
+
+func TestRecordsOutcome(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Header.Get("X-Webhook-Job-Id") {
+		case "gone":
+			w.WriteHeader(http.StatusGone)
+		case "down":
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	rec := &recorder{outcomes: make(map[string]outcome)}
+	n := New(srv.Client(), "secret", 3, time.Millisecond, 1, 10, rec, zaptest.NewLogger(t))
+	for _, id := range []string{"ok", "gone", "down"} {
+		if !n.Enqueue(srv.URL, Status{JobID: id}) {
+			t.Fatalf("enqueue %s refused", id)
+		}
+	}
+	if err := n.Close(context.Background()); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	want := map[string]outcome{
+		"ok":   {model.CallbackSent, 1},
+		"gone": {model.CallbackFailed, 1},
+		"down": {model.CallbackFailed, 3},
+	}
+	for id, w := range want {
+		if got := rec.outcomes[id]; got != w {
+			t.Errorf("job %s: got %+v, want %+v", id, got, w)
+		}
+	}
+}
+
+func TestAbandonedCallbackIsNotRecorded(t *testing.T) {
+	block := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-block
+	}))
+	defer srv.Close()
+	defer close(block)
+
+	rec := &recorder{outcomes: make(map[string]outcome)}
+	n := New(srv.Client(), "secret", 1, time.Millisecond, 1, 10, rec, zaptest.NewLogger(t))
+	if !n.Enqueue(srv.URL, Status{JobID: "slow"}) {
+		t.Fatal("enqueue refused")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := n.Close(ctx); err == nil {
+		t.Fatal("close: want an error for the abandoned callback")
+	}
+	if got, ok := rec.outcomes["slow"]; ok {
+		t.Errorf("abandoned callback recorded as %+v, want it left pending", got)
+	}
+}
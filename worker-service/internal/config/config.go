@@ -2,45 +2,220 @@ package config
 
 import (
 	"fmt"
+	"net"
 	"os"
 	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Bharat1Rajput/broker"
+)
+
+// ConsumerName identifies the workers to the broker: the RabbitMQ consumer
+// tag, the JetStream durable consumer, the Redis consumer group and the
+// prefix of the Postgres lock owner.
+const ConsumerName = "worker-service"
+
+// Storage backends for job history, selected with STORAGE.
+const (
+	StoragePostgres = "postgres"
+	// StorageSQLite keeps job history in a local file. Only dispatchgo
+	// supports it.
+	StorageSQLite = "sqlite"
 )
 
 type Config struct {
+	Storage              string
 	DatabaseURL          string
+	SQLitePath           string
+	Broker               string
 	RabbitURL            string
 	RabbitExchange       string
 	RabbitQueue          string
 	RabbitRoutingKey     string
+	RabbitMaxPriority    int
+	NATSURL              string
+	NATSStream           string
+	NATSSubject          string
+	RedisURL             string
+	RedisStream          string
+	BrokerAckWaitSec     int
 	MaxRetries           int
 	BackoffBaseMS        int
 	HTTPClientTimeoutSec int
 	WorkerConcurrency    int
+	DrainTimeoutSec      int
+	ProcessingLeaseSec   int
+	AttemptCaptureBytes  int
+	RetryAfterMaxSec     int
+	RetryPoliciesFile    string
+	EndpointsFile        string
+	SSRFDenyCIDRs        []string
+	SSRFAllowCIDRs       []string
+	TLSReloadCheckSec    int
+	MigrateOnStart       bool
+	RetentionEnabled     bool
+	RetentionSuccessDays int
+	RetentionFailedDays  int
+	RetentionTenantsFile string
+	RetentionIntervalSec int
+	RetentionBatchSize   int
+	RetentionArchiveDir  string
+	BlobStore            string
+	BlobLocalDir         string
+	AdminAddr            string
+	AdminToken           string
+	CallbackHMACSecret   string
+	CallbackMaxAttempts  int
+	CallbackBackoffMS    int
+	CallbackWorkers      int
+	CallbackQueueSize    int
 }
 
 func Load() (*Config, error) {
 	cfg := &Config{
+		Storage:              getEnv("STORAGE", StoragePostgres),
 		DatabaseURL:          os.Getenv("DATABASE_URL"),
-		RabbitURL:            os.Getenv("RABBITMQ_URL"),
-		RabbitExchange:       getEnv("RABBITMQ_EXCHANGE", "webhooks"),
-		RabbitQueue:          getEnv("RABBITMQ_QUEUE", "webhook.jobs"),
-		RabbitRoutingKey:     getEnv("RABBITMQ_ROUTING_KEY", "webhook.jobs"),
+		SQLitePath:           getEnv("SQLITE_PATH", "dispatchgo.db"),
 		MaxRetries:           getEnvInt("MAX_RETRIES", 3),
 		BackoffBaseMS:        getEnvInt("BACKOFF_BASE_MS", 1000),
 		HTTPClientTimeoutSec: getEnvInt("HTTP_CLIENT_TIMEOUT_SEC", 10),
 		WorkerConcurrency:    getEnvInt("WORKER_CONCURRENCY", 5),
+		DrainTimeoutSec:      getEnvInt("DRAIN_TIMEOUT_SEC", 30),
+		ProcessingLeaseSec:   getEnvInt("PROCESSING_LEASE_SEC", 300),
+		AttemptCaptureBytes:  getEnvInt("ATTEMPT_CAPTURE_BYTES", 2048),
+		RetryAfterMaxSec:     getEnvInt("RETRY_AFTER_MAX_SEC", 3600),
+		RetryPoliciesFile:    os.Getenv("RETRY_POLICIES_FILE"),
+		EndpointsFile:        os.Getenv("ENDPOINTS_FILE"),
+		SSRFDenyCIDRs:        getEnvList("SSRF_DENY_CIDRS"),
+		SSRFAllowCIDRs:       getEnvList("SSRF_ALLOW_CIDRS"),
+		TLSReloadCheckSec:    getEnvInt("TLS_RELOAD_CHECK_SEC", 30),
+		MigrateOnStart:       getEnvBool("MIGRATE_ON_START", true),
+		RetentionEnabled:     getEnvBool("RETENTION_ENABLED", false),
+		RetentionSuccessDays: getEnvInt("RETENTION_SUCCESS_DAYS", 7),
+		RetentionFailedDays:  getEnvInt("RETENTION_FAILED_DAYS", 30),
+		RetentionTenantsFile: os.Getenv("RETENTION_TENANTS_FILE"),
+		RetentionIntervalSec: getEnvInt("RETENTION_INTERVAL_SEC", 3600),
+		RetentionBatchSize:   getEnvInt("RETENTION_BATCH_SIZE", 1000),
+		RetentionArchiveDir:  os.Getenv("RETENTION_ARCHIVE_DIR"),
+		BlobStore:            os.Getenv("BLOB_STORE"),
+		BlobLocalDir:         os.Getenv("BLOB_LOCAL_DIR"),
+		AdminAddr:            getEnv("ADMIN_ADDR", "127.0.0.1:8081"),
+		AdminToken:           os.Getenv("ADMIN_TOKEN"),
+		CallbackHMACSecret:   os.Getenv("CALLBACK_HMAC_SECRET"),
+		CallbackMaxAttempts:  getEnvInt("CALLBACK_MAX_ATTEMPTS", 3),
+		CallbackBackoffMS:    getEnvInt("CALLBACK_BACKOFF_MS", 1000),
+		CallbackWorkers:      getEnvInt("CALLBACK_WORKERS", 4),
+		CallbackQueueSize:    getEnvInt("CALLBACK_QUEUE_SIZE", 1000),
 	}
 
-	if cfg.DatabaseURL == "" {
-		return nil, fmt.Errorf("config: DATABASE_URL is required")
+	switch cfg.Storage {
+	case StoragePostgres:
+		if cfg.DatabaseURL == "" {
+			return nil, fmt.Errorf("config: DATABASE_URL is required")
+		}
+	case StorageSQLite:
+	default:
+		return nil, fmt.Errorf("config: unknown STORAGE %q (postgres or sqlite)", cfg.Storage)
 	}
-	if cfg.RabbitURL == "" {
-		return nil, fmt.Errorf("config: RABBITMQ_URL is required")
+	if err := loadBroker(cfg); err != nil {
+		return nil, err
+	}
+	if cfg.ProcessingLeaseSec <= cfg.HTTPClientTimeoutSec {
+		return nil, fmt.Errorf("config: PROCESSING_LEASE_SEC must be longer than HTTP_CLIENT_TIMEOUT_SEC")
+	}
+	if cfg.AdminAddr != "" && cfg.AdminToken == "" && !isLoopback(cfg.AdminAddr) {
+		return nil, fmt.Errorf("config: ADMIN_TOKEN is required when ADMIN_ADDR %q is not a loopback address", cfg.AdminAddr)
 	}
 
 	return cfg, nil
 }
 
+// isLoopback reports whether addr, a listen address, only accepts local
+// connections. ":8081" listens on every interface, so it does not.
+func isLoopback(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// LoadDatabaseURL reads only DATABASE_URL, for commands such as migrate that
+// never touch RabbitMQ.
+func LoadDatabaseURL() (string, error) {
+	url := os.Getenv("DATABASE_URL")
+	if url == "" {
+		return "", fmt.Errorf("config: DATABASE_URL is required")
+	}
+	return url, nil
+}
+
+// LoadBroker reads only the broker settings, for tools such as dispatchctl
+// that publish to or inspect the job queue without running the worker.
+// DATABASE_URL is read too, as it locates the queue with BROKER=postgres.
+func LoadBroker() (*Config, error) {
+	cfg := &Config{DatabaseURL: os.Getenv("DATABASE_URL")}
+	if err := loadBroker(cfg); err != nil {
+		return nil, err
+	}
+	if cfg.Broker == broker.BackendMemory {
+		return nil, fmt.Errorf("config: BROKER=memory lives inside dispatchgo and cannot be reached from another process")
+	}
+	return cfg, nil
+}
+
+// loadBroker reads the broker settings into cfg and requires the connection
+// URL of the selected broker.
+func loadBroker(cfg *Config) error {
+	cfg.Broker = getEnv("BROKER", broker.BackendRabbitMQ)
+	cfg.RabbitURL = os.Getenv("RABBITMQ_URL")
+	cfg.RabbitExchange = getEnv("RABBITMQ_EXCHANGE", "webhooks")
+	cfg.RabbitQueue = getEnv("RABBITMQ_QUEUE", "webhook.jobs")
+	cfg.RabbitRoutingKey = getEnv("RABBITMQ_ROUTING_KEY", "webhook.jobs")
+	cfg.RabbitMaxPriority = getEnvInt("RABBITMQ_MAX_PRIORITY", 0)
+	cfg.NATSURL = os.Getenv("NATS_URL")
+	cfg.NATSStream = getEnv("NATS_STREAM", "WEBHOOKS")
+	cfg.NATSSubject = getEnv("NATS_SUBJECT", "webhook.jobs")
+	cfg.RedisURL = os.Getenv("REDIS_URL")
+	cfg.RedisStream = getEnv("REDIS_STREAM", "webhook.jobs")
+	cfg.BrokerAckWaitSec = getEnvInt("BROKER_ACK_WAIT_SEC", 60)
+
+	if err := cfg.BrokerConfig().Validate(); err != nil {
+		return err
+	}
+	if cfg.BrokerAckWaitSec <= 0 {
+		return fmt.Errorf("config: BROKER_ACK_WAIT_SEC must be positive")
+	}
+	return nil
+}
+
+// BrokerConfig returns the settings of the job queue. Workers hold at most
+// WorkerConcurrency messages unacknowledged.
+func (c *Config) BrokerConfig() broker.Config {
+	return broker.Config{
+		Backend:           c.Broker,
+		RabbitURL:         c.RabbitURL,
+		RabbitExchange:    c.RabbitExchange,
+		RabbitQueue:       c.RabbitQueue,
+		RabbitRoutingKey:  c.RabbitRoutingKey,
+		RabbitMaxPriority: c.RabbitMaxPriority,
+		NATSURL:           c.NATSURL,
+		NATSStream:        c.NATSStream,
+		NATSSubject:       c.NATSSubject,
+		RedisURL:          c.RedisURL,
+		RedisStream:       c.RedisStream,
+		PostgresURL:       c.DatabaseURL,
+		ConsumerName:      ConsumerName,
+		Prefetch:          c.WorkerConcurrency,
+		AckWait:           time.Duration(c.BrokerAckWaitSec) * time.Second,
+	}
+}
+
 func getEnv(key, fallback string) string {
 	if v := os.Getenv(key); v != "" {
 		return v
@@ -57,3 +232,19 @@ func getEnvInt(key string, fallback int) int {
 	return fallback
 }
 
+func getEnvBool(key string, fallback bool) bool {
+	if v := os.Getenv(key); v != "" {
+		if b, err := strconv.ParseBool(v); err == nil {
+			return b
+		}
+	}
+	return fallback
+}
+
+func getEnvList(key string) []string {
+	v := os.Getenv(key)
+	if v == "" {
+		return nil
+	}
+	return strings.Split(v, ",")
+}
@@ -2,115 +2,64 @@ package consumer
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"sync"
+	"time"
 
-	amqp "github.com/rabbitmq/amqp091-go"
 	"go.uber.org/zap"
 
+	"github.com/Bharat1Rajput/broker"
+	"github.com/Bharat1Rajput/contract"
 	"github.com/Bharat1Rajput/workerService/internal/config"
 	"github.com/Bharat1Rajput/workerService/internal/model"
+	"github.com/Bharat1Rajput/workerService/internal/ordering"
 	"github.com/Bharat1Rajput/workerService/internal/processor"
 )
 
 type Consumer struct {
 	cfg       *config.Config
+	source    broker.Consumer
 	processor *processor.Processor
 	logger    *zap.Logger
-	conn      *amqp.Connection
-	ch        *amqp.Channel
 	wg        sync.WaitGroup
 	sem       chan struct{}
+	sequencer *ordering.Sequencer
 }
 
-func New(cfg *config.Config, proc *processor.Processor, logger *zap.Logger) (*Consumer, error) {
-	conn, err := amqp.Dial(cfg.RabbitURL)
-	if err != nil {
-		return nil, fmt.Errorf("consumer: connect rabbit: %w", err)
-	}
-
-	ch, err := conn.Channel()
-	if err != nil {
-		_ = conn.Close()
-		return nil, fmt.Errorf("consumer: channel: %w", err)
-	}
-
-	if err := ch.Qos(cfg.WorkerConcurrency, 0, false); err != nil {
-		_ = ch.Close()
-		_ = conn.Close()
-		return nil, fmt.Errorf("consumer: qos: %w", err)
-	}
-
-	if err := ch.ExchangeDeclare(
-		cfg.RabbitExchange,
-		"topic",
-		true,
-		false,
-		false,
-		false,
-		nil,
-	); err != nil {
-		_ = ch.Close()
-		_ = conn.Close()
-		return nil, fmt.Errorf("consumer: declare exchange: %w", err)
-	}
-
-	q, err := ch.QueueDeclare(
-		cfg.RabbitQueue,
-		true,
-		false,
-		false,
-		false,
-		nil,
-	)
-	if err != nil {
-		_ = ch.Close()
-		_ = conn.Close()
-		return nil, fmt.Errorf("consumer: declare queue: %w", err)
-	}
-
-	if err := ch.QueueBind(
-		q.Name,
-		cfg.RabbitRoutingKey,
-		cfg.RabbitExchange,
-		false,
-		nil,
-	); err != nil {
-		_ = ch.Close()
-		_ = conn.Close()
-		return nil, fmt.Errorf("consumer: bind queue: %w", err)
-	}
-
+// New returns a Consumer processing jobs received from source, which it
+// closes on Close.
+func New(cfg *config.Config, source broker.Consumer, proc *processor.Processor, logger *zap.Logger) *Consumer {
 	return &Consumer{
 		cfg:       cfg,
+		source:    source,
 		processor: proc,
 		logger:    logger,
-		conn:      conn,
-		ch:        ch,
 		sem:       make(chan struct{}, cfg.WorkerConcurrency),
-	}, nil
+		sequencer: ordering.NewSequencer(),
+	}
 }
 
+// Start consumes until ctx is canceled, then drains: the broker consumer is
+// cancelled so no new deliveries arrive, and attempts in flight get up to
+// DrainTimeoutSec to finish before they are aborted and requeued. Jobs
+// waiting for a retry are already back on the queue. Start returns once
+// every job is settled.
 func (c *Consumer) Start(ctx context.Context) error {
-	deliveries, err := c.ch.Consume(
-		c.cfg.RabbitQueue,
-		"worker-service",
-		false,
-		false,
-		false,
-		false,
-		nil,
-	)
+	deliveries, err := c.source.Consume(ctx)
 	if err != nil {
 		return fmt.Errorf("consumer: start consume: %w", err)
 	}
 
+	// Jobs run under their own context so shutdown does not cut deliveries
+	// off mid-request; drain cancels it only once the deadline has passed.
+	jobCtx, abortJobs := context.WithCancel(context.Background())
+	defer abortJobs()
+
 	for {
 		select {
 		case <-ctx.Done():
-			c.logger.Info("consumer: context canceled, waiting for workers")
-			c.wg.Wait()
+			c.drain(abortJobs)
 			return nil
 		case d, ok := <-deliveries:
 			if !ok {
@@ -119,41 +68,140 @@ func (c *Consumer) Start(ctx context.Context) error {
 				return nil
 			}
 
-			c.sem <- struct{}{}
+			msg, err := contract.Decode(d.Body())
+			if err != nil {
+				c.logger.Error("consumer: decode job", zap.Error(err))
+				_ = d.Reject()
+				continue
+			}
+			job := model.FromMessage(msg)
+
+			// An expired job is only recorded, but that still touches the
+			// database, so it is done in a worker rather than here.
+			expired := job.Expired(time.Now())
+
+			// Jobs sharing an ordering key run in the order they were first
+			// received. One that is not yet first in line goes back on the
+			// queue instead of waiting here, so it holds no worker slot.
+			var key string
+			if job.OrderingKey != "" {
+				key = ordering.Key(job.ClientURL, job.OrderingKey)
+				if wait, ok := c.sequencer.Admit(key, job.ID, time.Now()); !ok && !expired {
+					_ = d.Requeue(wait)
+					continue
+				}
+			}
+
+			select {
+			case c.sem <- struct{}{}:
+			case <-ctx.Done():
+				if key != "" {
+					c.sequencer.Defer(key, job.ID, time.Now())
+				}
+				_ = d.Requeue(0)
+				c.drain(abortJobs)
+				return nil
+			}
 			c.wg.Add(1)
 
-			go func(d amqp.Delivery) {
+			go func(d broker.Delivery, job *model.WebhookJob, key string, expired bool) {
 				defer c.wg.Done()
 				defer func() { <-c.sem }()
 
-				var job model.WebhookJob
-				if err := json.Unmarshal(d.Body, &job); err != nil {
-					c.logger.Error("consumer: unmarshal job", zap.Error(err))
-					_ = d.Nack(false, false)
+				if expired {
+					c.discard(jobCtx, d, job, key)
 					return
 				}
 
-				jobCtx := context.Background()
-				if err := c.processor.ProcessJob(jobCtx, &job); err != nil {
+				err := c.processor.ProcessJob(jobCtx, job)
+				var retry *processor.RetryError
+				switch {
+				case err == nil:
+					c.done(key, job)
+					if err := d.Ack(); err != nil {
+						c.logger.Error("consumer: ack failed", zap.Error(err))
+					}
+				case jobCtx.Err() != nil:
+					c.logger.Info("consumer: requeueing job on shutdown", zap.String("job_id", job.ID))
+					if key != "" {
+						c.sequencer.Defer(key, job.ID, time.Now())
+					}
+					_ = d.Requeue(0)
+				case errors.As(err, &retry):
+					// The job keeps its place in its ordering key's line
+					// while it waits in the queue for its next attempt,
+					// unless the job table says an earlier one goes first.
+					switch {
+					case key == "":
+					case errors.Is(err, processor.ErrPreceded):
+						c.done(key, job)
+					default:
+						c.sequencer.Defer(key, job.ID, time.Now().Add(retry.After))
+					}
+					if err := d.Requeue(retry.After); err != nil {
+						c.logger.Error("consumer: requeue for retry failed", zap.Error(err), zap.String("job_id", job.ID))
+					}
+				default:
+					c.done(key, job)
 					// permanent failure or retries exhausted
-					c.logger.Error("consumer: job processing failed", zap.Error(err), zap.String("job_id", job.ID))
-					_ = d.Nack(false, false)
-					return
+					c.logger.Error("consumer: job processing failed",
+						zap.Error(err),
+						zap.String("job_id", job.ID),
+						zap.String("message_id", msg.MessageID),
+						zap.Int("envelope_version", msg.Version),
+					)
+					_ = d.Reject()
 				}
-
-				if err := d.Ack(false); err != nil {
-					c.logger.Error("consumer: ack failed", zap.Error(err))
-				}
-			}(d)
+			}(d, job, key, expired)
 		}
 	}
 }
 
-func (c *Consumer) Close() error {
-	if err := c.ch.Close(); err != nil {
-		_ = c.conn.Close()
-		return err
+// drain stops new deliveries and waits for running jobs, aborting them once
+// the drain timeout expires. Prefetched deliveries that never reached a
+// worker are returned to the queue by the broker.
+func (c *Consumer) drain(abortJobs context.CancelFunc) {
+	timeout := time.Duration(c.cfg.DrainTimeoutSec) * time.Second
+	c.logger.Info("consumer: draining", zap.Duration("timeout", timeout))
+
+	if err := c.source.Cancel(); err != nil {
+		c.logger.Error("consumer: cancel consumer", zap.Error(err))
+	}
+
+	done := make(chan struct{})
+	go func() {
+		c.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		c.logger.Info("consumer: drained")
+	case <-time.After(timeout):
+		c.logger.Warn("consumer: drain timeout reached, aborting in-flight deliveries")
+		abortJobs()
+		<-done
+	}
+}
+
+// discard records an expired job without attempting it.
+func (c *Consumer) discard(ctx context.Context, d broker.Delivery, job *model.WebhookJob, key string) {
+	c.logger.Info("consumer: discarding expired job", zap.String("job_id", job.ID))
+	if err := c.processor.Discard(ctx, job); err != nil {
+		c.logger.Error("consumer: record expired job", zap.Error(err), zap.String("job_id", job.ID))
+	}
+	c.done(key, job)
+	_ = d.Ack()
+}
+
+// done lets the next job with key run, if job has an ordering key.
+func (c *Consumer) done(key string, job *model.WebhookJob) {
+	if key != "" {
+		c.sequencer.Done(key, job.ID, time.Now())
 	}
-	return c.conn.Close()
+}
+
+func (c *Consumer) Close() error {
+	return c.source.Close()
 }
 
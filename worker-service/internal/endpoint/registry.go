@@ -0,0 +1,148 @@
+package endpoint
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/Bharat1Rajput/workerService/internal/transform"
+)
+
+// Endpoint groups delivery settings for every client_url under URLPrefix:
+// same scheme and host, and a path equal to or below the prefix's path.
+type Endpoint struct {
+	Name        string          `json:"name"`
+	URLPrefix   string          `json:"url_prefix"`
+	RetryPolicy string          `json:"retry_policy"`
+	TLS         *TLSConfig      `json:"tls,omitempty"`
+	Transform   *transform.Spec `json:"transform,omitempty"`
+
+	transform *transform.Transform
+	prefix    *url.URL
+}
+
+// Transformer returns the compiled payload transformation, or nil when the
+// endpoint receives payloads unchanged.
+func (e *Endpoint) Transformer() *transform.Transform {
+	if e == nil {
+		return nil
+	}
+	return e.transform
+}
+
+// TLSConfig customises outbound TLS for an endpoint: a client certificate for
+// mTLS, a private CA bundle, a minimum protocol version and an SNI override.
+type TLSConfig struct {
+	CertFile   string `json:"cert_file"`
+	KeyFile    string `json:"key_file"`
+	CAFile     string `json:"ca_file"`
+	MinVersion string `json:"min_version"`
+	ServerName string `json:"server_name"`
+}
+
+// Registry resolves a client_url to the most specific configured Endpoint.
+type Registry struct {
+	endpoints []*Endpoint
+}
+
+// Load reads a JSON array of endpoints from path. An empty path yields an
+// empty registry, so every URL falls back to the global defaults.
+func Load(path string) (*Registry, error) {
+	r := &Registry{}
+	if path == "" {
+		return r, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("endpoint: read registry: %w", err)
+	}
+	if err := json.Unmarshal(data, &r.endpoints); err != nil {
+		return nil, fmt.Errorf("endpoint: decode registry: %w", err)
+	}
+
+	seen := make(map[string]bool, len(r.endpoints))
+	for _, e := range r.endpoints {
+		if e.Name == "" || e.URLPrefix == "" {
+			return nil, fmt.Errorf("endpoint: name and url_prefix are required")
+		}
+		prefix, err := url.Parse(e.URLPrefix)
+		if err != nil || prefix.Scheme == "" || prefix.Host == "" || prefix.RawQuery != "" || prefix.Fragment != "" {
+			return nil, fmt.Errorf("endpoint: %q: url_prefix must be an absolute URL without query or fragment", e.Name)
+		}
+		e.prefix = prefix
+		if e.TLS != nil && (e.TLS.CertFile == "") != (e.TLS.KeyFile == "") {
+			return nil, fmt.Errorf("endpoint: %q: cert_file and key_file must be set together", e.Name)
+		}
+		if e.Transform != nil {
+			t, err := transform.Compile(e.Transform)
+			if err != nil {
+				return nil, fmt.Errorf("endpoint: %q: %w", e.Name, err)
+			}
+			e.transform = t
+		}
+		if seen[e.Name] {
+			return nil, fmt.Errorf("endpoint: duplicate endpoint %q", e.Name)
+		}
+		seen[e.Name] = true
+	}
+	return r, nil
+}
+
+// Match returns the endpoint with the longest URLPrefix matching rawURL, or
+// nil. Scheme and host must be equal, so https://partner.com.evil.io never
+// matches https://partner.com, and paths match on whole segments, so
+// /hooks does not match /hooks-admin.
+func (r *Registry) Match(rawURL string) *Endpoint {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil
+	}
+	var best *Endpoint
+	for _, e := range r.endpoints {
+		if !under(u, e.prefix) {
+			continue
+		}
+		if best == nil || len(e.prefix.Path) > len(best.prefix.Path) {
+			best = e
+		}
+	}
+	return best
+}
+
+func under(u, prefix *url.URL) bool {
+	if !strings.EqualFold(u.Scheme, prefix.Scheme) || !strings.EqualFold(hostPort(u), hostPort(prefix)) {
+		return false
+	}
+	p := strings.TrimSuffix(prefix.Path, "/")
+	return p == "" || u.Path == p || strings.HasPrefix(u.Path, p+"/")
+}
+
+// hostPort returns u's host with the scheme's default port made explicit.
+func hostPort(u *url.URL) string {
+	if port := u.Port(); port != "" {
+		return u.Host
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "https":
+		return u.Host + ":443"
+	case "http":
+		return u.Host + ":80"
+	}
+	return u.Host
+}
+
+func (r *Registry) Get(name string) (*Endpoint, bool) {
+	for _, e := range r.endpoints {
+		if e.Name == name {
+			return e, true
+		}
+	}
+	return nil, false
+}
+
+func (r *Registry) All() []*Endpoint {
+	return r.endpoints
+}
@@ -0,0 +1,59 @@
+package endpoint
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "endpoints.json")
+	err := os.WriteFile(path, []byte(`[
+		{"name": "partner", "url_prefix": "https://partner.com"},
+		{"name": "partner-hooks", "url_prefix": "https://partner.com/hooks/"},
+		{"name": "local", "url_prefix": "http://localhost:8080/cb"}
+	]`), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for url, want := range map[string]string{
+		"https://partner.com":                  "partner",
+		"https://partner.com/":                 "partner",
+		"https://PARTNER.com:443/x":            "partner",
+		"https://partner.com/hooks":            "partner-hooks",
+		"https://partner.com/hooks/a?b=c":      "partner-hooks",
+		"https://partner.com/hooks-admin":      "partner",
+		"https://partner.com.evil.io/":         "",
+		"https://partner.com@evil.io/hooks/":   "",
+		"https://evil.io/?https://partner.com": "",
+		"http://partner.com/hooks/":            "",
+		"https://partner.com:8443/hooks/":      "",
+		"http://localhost:8080/cb/1":           "local",
+		"http://localhost:8080/cba":            "",
+		"http://localhost:80/cb":               "",
+		"not a url\x7f":                        "",
+	} {
+		got := ""
+		if e := r.Match(url); e != nil {
+			got = e.Name
+		}
+		if got != want {
+			t.Errorf("Match(%q) = %q, want %q", url, got, want)
+		}
+	}
+}
+
+func TestLoadRejectsRelativePrefix(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "endpoints.json")
+	if err := os.WriteFile(path, []byte(`[{"name": "x", "url_prefix": "partner.com/hooks"}]`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil {
+		t.Fatal("Load accepted a url_prefix without scheme and host")
+	}
+}
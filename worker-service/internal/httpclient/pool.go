@@ -0,0 +1,224 @@
+package httpclient
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Bharat1Rajput/ssrf"
+	"github.com/Bharat1Rajput/workerService/internal/endpoint"
+)
+
+// Pool hands out delivery clients: a shared default one, and one per endpoint
+// with custom TLS settings. Endpoint clients are rebuilt when any of their
+// certificate files change on disk, checked at most once per recheck interval.
+type Pool struct {
+	timeout time.Duration
+	guard   *ssrf.Guard
+	recheck time.Duration
+	logger  *zap.Logger
+
+	defaultClient *http.Client
+
+	mu      sync.Mutex
+	clients map[string]*entry
+}
+
+type entry struct {
+	client    *http.Client
+	files     map[string]fileStamp
+	checkedAt time.Time
+}
+
+type fileStamp struct {
+	size    int64
+	modTime time.Time
+}
+
+func NewPool(timeout time.Duration, guard *ssrf.Guard, recheck time.Duration, logger *zap.Logger) *Pool {
+	return &Pool{
+		timeout:       timeout,
+		guard:         guard,
+		recheck:       recheck,
+		logger:        logger,
+		defaultClient: newClient(timeout, guard, nil),
+		clients:       make(map[string]*entry),
+	}
+}
+
+// Default returns the shared client used for destinations without
+// endpoint settings.
+func (p *Pool) Default() *http.Client {
+	return p.defaultClient
+}
+
+// For returns the client to use for ep. A nil endpoint, or one without TLS
+// settings, gets the shared default client. If the certificate files changed
+// but cannot be loaded, the previous client is kept and the error logged, so
+// a bad rotation does not fail every delivery; without a previous client the
+// error is returned.
+func (p *Pool) For(ep *endpoint.Endpoint) (*http.Client, error) {
+	if ep == nil || ep.TLS == nil {
+		return p.defaultClient, nil
+	}
+
+	now := time.Now()
+	p.mu.Lock()
+	e, ok := p.clients[ep.Name]
+	if ok {
+		if now.Sub(e.checkedAt) < p.recheck {
+			p.mu.Unlock()
+			return e.client, nil
+		}
+		// Other callers keep using the current client while this one
+		// checks the files, without holding the lock for the file I/O.
+		e.checkedAt = now
+	}
+	p.mu.Unlock()
+
+	next, err := p.load(ep, e)
+	if err != nil {
+		if !ok {
+			return nil, err
+		}
+		p.logger.Error("httpclient: reload failed, keeping previous tls settings",
+			zap.String("endpoint", ep.Name), zap.Error(err))
+		return e.client, nil
+	}
+	if next == e {
+		return e.client, nil
+	}
+
+	p.mu.Lock()
+	if ok && p.clients[ep.Name] == e {
+		e.client.CloseIdleConnections()
+	}
+	p.clients[ep.Name] = next
+	p.mu.Unlock()
+	return next.client, nil
+}
+
+// load returns prev if ep's certificate files are unchanged since it was
+// built, and otherwise a new entry built from them.
+func (p *Pool) load(ep *endpoint.Endpoint, prev *entry) (*entry, error) {
+	files, err := stampFiles(ep.TLS)
+	if err != nil {
+		return nil, err
+	}
+	if prev != nil && sameStamps(prev.files, files) {
+		return prev, nil
+	}
+	tlsCfg, err := buildTLSConfig(ep.TLS)
+	if err != nil {
+		return nil, fmt.Errorf("httpclient: endpoint %q: %w", ep.Name, err)
+	}
+	return &entry{
+		client:    newClient(p.timeout, p.guard, tlsCfg),
+		files:     files,
+		checkedAt: time.Now(),
+	}, nil
+}
+
+// newClient builds a delivery client. Every connection, including redirects,
+// is checked by guard against the resolved IP, and proxies from the
+// environment are ignored so the check applies to the real destination.
+func newClient(timeout time.Duration, guard *ssrf.Guard, tlsCfg *tls.Config) *http.Client {
+	dialer := &net.Dialer{
+		Timeout:   10 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control:   guard.Control,
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	if tlsCfg != nil {
+		transport.TLSClientConfig = tlsCfg
+	}
+
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+	}
+}
+
+func buildTLSConfig(c *endpoint.TLSConfig) (*tls.Config, error) {
+	minVersion, err := parseVersion(c.MinVersion)
+	if err != nil {
+		return nil, err
+	}
+	cfg := &tls.Config{
+		MinVersion: minVersion,
+		ServerName: c.ServerName,
+	}
+
+	if c.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load client certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+
+	if c.CAFile != "" {
+		pem, err := os.ReadFile(c.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("read ca bundle: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("ca bundle %s contains no certificates", c.CAFile)
+		}
+		cfg.RootCAs = pool
+	}
+
+	return cfg, nil
+}
+
+func parseVersion(v string) (uint16, error) {
+	switch v {
+	case "", "1.2":
+		return tls.VersionTLS12, nil
+	case "1.3":
+		return tls.VersionTLS13, nil
+	case "1.1":
+		return tls.VersionTLS11, nil
+	case "1.0":
+		return tls.VersionTLS10, nil
+	default:
+		return 0, fmt.Errorf("unsupported min_version %q", v)
+	}
+}
+
+func stampFiles(c *endpoint.TLSConfig) (map[string]fileStamp, error) {
+	stamps := make(map[string]fileStamp, 3)
+	for _, path := range []string{c.CertFile, c.KeyFile, c.CAFile} {
+		if path == "" {
+			continue
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("httpclient: stat %s: %w", path, err)
+		}
+		stamps[path] = fileStamp{size: info.Size(), modTime: info.ModTime()}
+	}
+	return stamps, nil
+}
+
+func sameStamps(a, b map[string]fileStamp) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for path, s := range a {
+		if b[path] != s {
+			return false
+		}
+	}
+	return true
+}
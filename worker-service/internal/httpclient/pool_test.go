@@ -0,0 +1,92 @@
+package httpclient
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zaptest"
+
+	"github.com/Bharat1Rajput/ssrf"
+	"github.com/Bharat1Rajput/workerService/internal/endpoint"
+)
+
+func writeCA(t *testing.T, path string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestForKeepsClientWhenReloadFails(t *testing.T) {
+	guard, err := ssrf.New(nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	writeCA(t, caFile)
+
+	p := NewPool(time.Second, guard, 0, zaptest.NewLogger(t))
+	ep := &endpoint.Endpoint{Name: "partner", TLS: &endpoint.TLSConfig{CAFile: caFile}}
+
+	first, err := p.For(ep)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(caFile, []byte("not a certificate, and a different size"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	got, err := p.For(ep)
+	if err != nil {
+		t.Fatalf("For after a bad rotation: %v", err)
+	}
+	if got != first {
+		t.Fatal("For replaced the client after a failed reload")
+	}
+
+	writeCA(t, caFile)
+	got, err = p.For(ep)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got == first {
+		t.Fatal("For kept the old client after a good rotation")
+	}
+}
+
+func TestForFailsWithoutPreviousClient(t *testing.T) {
+	guard, err := ssrf.New(nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := NewPool(time.Second, guard, 0, zaptest.NewLogger(t))
+	ep := &endpoint.Endpoint{Name: "partner", TLS: &endpoint.TLSConfig{CAFile: filepath.Join(t.TempDir(), "missing.pem")}}
+	if _, err := p.For(ep); err == nil {
+		t.Fatal("For succeeded without certificate files")
+	}
+}
@@ -0,0 +1,396 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// lockKey is the advisory lock key serialising migration runs across every
+// worker instance sharing the database.
+const lockKey int64 = 7_364_201_951
+
+// lockPollInterval is how often a runner retries the advisory lock while
+// another instance holds it. Waiting in pg_advisory_lock instead would keep
+// a snapshot open, which CREATE INDEX CONCURRENTLY run by the holder waits
+// for, so the two would deadlock.
+const lockPollInterval = time.Second
+
+// noTransactionMarker, as the first line of a script, runs it outside a
+// transaction one statement at a time, as CREATE INDEX CONCURRENTLY requires.
+// Statements must end with ";" at the end of a line; a failed run is not
+// rolled back, so such scripts should be idempotent (IF NOT EXISTS). An
+// index left INVALID by a failed CREATE INDEX CONCURRENTLY IF NOT EXISTS is
+// dropped before the statement runs again, as IF NOT EXISTS would skip it.
+const noTransactionMarker = "-- migrate:no-transaction"
+
+// concurrentIndexPattern captures the index name of a CREATE INDEX
+// CONCURRENTLY IF NOT EXISTS statement.
+var concurrentIndexPattern = regexp.MustCompile(`(?i)^CREATE\s+(?:UNIQUE\s+)?INDEX\s+CONCURRENTLY\s+IF\s+NOT\s+EXISTS\s+("[^"]+"|[^\s(]+)`)
+
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status pairs a known migration with the time it was applied, if it was.
+type Status struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+type Runner struct {
+	db         *sql.DB
+	migrations []Migration
+	logger     *zap.Logger
+}
+
+// New parses the NNNN_name.up.sql / NNNN_name.down.sql files in fsys.
+func New(db *sql.DB, fsys fs.FS, logger *zap.Logger) (*Runner, error) {
+	migrations, err := load(fsys)
+	if err != nil {
+		return nil, err
+	}
+	return &Runner{db: db, migrations: migrations, logger: logger}, nil
+}
+
+// Up applies every pending migration in version order.
+func (r *Runner) Up(ctx context.Context) error {
+	return r.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, m := range r.migrations {
+			if _, ok := applied[m.Version]; ok {
+				continue
+			}
+			if err := r.apply(ctx, conn, m, m.Up, true); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Down rolls back the most recent steps applied migrations.
+func (r *Runner) Down(ctx context.Context, steps int) error {
+	return r.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for i := len(r.migrations) - 1; i >= 0 && steps > 0; i-- {
+			m := r.migrations[i]
+			if _, ok := applied[m.Version]; !ok {
+				continue
+			}
+			if m.Down == "" {
+				return fmt.Errorf("migrate: %04d_%s has no down migration", m.Version, m.Name)
+			}
+			if err := r.apply(ctx, conn, m, m.Down, false); err != nil {
+				return err
+			}
+			steps--
+		}
+		return nil
+	})
+}
+
+func (r *Runner) Status(ctx context.Context) ([]Status, error) {
+	var out []Status
+	err := r.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, m := range r.migrations {
+			s := Status{Migration: m}
+			if at, ok := applied[m.Version]; ok {
+				at := at
+				s.AppliedAt = &at
+			}
+			out = append(out, s)
+		}
+		return nil
+	})
+	return out, err
+}
+
+// withLock runs fn on a single connection holding the migration advisory
+// lock, so concurrent workers starting together apply each migration once.
+func (r *Runner) withLock(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("migrate: acquire connection: %w", err)
+	}
+	defer conn.Close()
+
+	if err := r.lock(ctx, conn); err != nil {
+		return err
+	}
+	defer func() {
+		if _, err := conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, lockKey); err != nil {
+			r.logger.Error("migrate: release lock", zap.Error(err))
+		}
+	}()
+
+	const createTable = `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version    BIGINT      PRIMARY KEY,
+			name       TEXT        NOT NULL,
+			applied_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		)
+	`
+	if _, err := conn.ExecContext(ctx, createTable); err != nil {
+		return fmt.Errorf("migrate: create schema_migrations: %w", err)
+	}
+
+	return fn(conn)
+}
+
+// lock takes the migration advisory lock on conn, polling while another
+// instance holds it.
+func (r *Runner) lock(ctx context.Context, conn *sql.Conn) error {
+	for logged := false; ; logged = true {
+		var locked bool
+		if err := conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock($1)`, lockKey).Scan(&locked); err != nil {
+			return fmt.Errorf("migrate: acquire lock: %w", err)
+		}
+		if locked {
+			return nil
+		}
+		if !logged {
+			r.logger.Info("migrate: waiting for another instance to finish migrating")
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("migrate: acquire lock: %w", ctx.Err())
+		case <-time.After(lockPollInterval):
+		}
+	}
+}
+
+func (r *Runner) apply(ctx context.Context, conn *sql.Conn, m Migration, script string, up bool) error {
+	if strings.HasPrefix(strings.TrimSpace(script), noTransactionMarker) {
+		return r.applyNoTx(ctx, conn, m, script, up)
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("migrate: begin %04d: %w", m.Version, err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		return fmt.Errorf("migrate: run %04d_%s: %w", m.Version, m.Name, err)
+	}
+
+	if up {
+		_, err = tx.ExecContext(ctx, `INSERT INTO schema_migrations (version, name) VALUES ($1, $2)`, m.Version, m.Name)
+	} else {
+		_, err = tx.ExecContext(ctx, `DELETE FROM schema_migrations WHERE version = $1`, m.Version)
+	}
+	if err != nil {
+		return fmt.Errorf("migrate: record %04d: %w", m.Version, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("migrate: commit %04d: %w", m.Version, err)
+	}
+
+	r.logApplied(m, up)
+	return nil
+}
+
+func (r *Runner) applyNoTx(ctx context.Context, conn *sql.Conn, m Migration, script string, up bool) error {
+	for _, stmt := range splitStatements(script) {
+		if name := concurrentIndexName(stmt); name != "" {
+			if err := dropInvalidIndex(ctx, conn, name); err != nil {
+				return fmt.Errorf("migrate: run %04d_%s: %w", m.Version, m.Name, err)
+			}
+		}
+		if _, err := conn.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("migrate: run %04d_%s: %w", m.Version, m.Name, err)
+		}
+	}
+
+	var err error
+	if up {
+		_, err = conn.ExecContext(ctx, `INSERT INTO schema_migrations (version, name) VALUES ($1, $2)`, m.Version, m.Name)
+	} else {
+		_, err = conn.ExecContext(ctx, `DELETE FROM schema_migrations WHERE version = $1`, m.Version)
+	}
+	if err != nil {
+		return fmt.Errorf("migrate: record %04d: %w", m.Version, err)
+	}
+
+	r.logApplied(m, up)
+	return nil
+}
+
+func (r *Runner) logApplied(m Migration, up bool) {
+	direction := "up"
+	if !up {
+		direction = "down"
+	}
+	r.logger.Info("migrate: applied",
+		zap.Int64("version", m.Version),
+		zap.String("name", m.Name),
+		zap.String("direction", direction),
+	)
+}
+
+// concurrentIndexName returns the index a CREATE INDEX CONCURRENTLY IF NOT
+// EXISTS statement builds, or "" for any other statement.
+func concurrentIndexName(stmt string) string {
+	var lines []string
+	for _, line := range strings.Split(stmt, "\n") {
+		if l := strings.TrimSpace(line); l != "" && !strings.HasPrefix(l, "--") {
+			lines = append(lines, l)
+		}
+	}
+	m := concurrentIndexPattern.FindStringSubmatch(strings.Join(lines, " "))
+	if m == nil {
+		return ""
+	}
+	return m[1]
+}
+
+// dropInvalidIndex drops index name if an earlier, failed CREATE INDEX
+// CONCURRENTLY left it INVALID.
+func dropInvalidIndex(ctx context.Context, conn *sql.Conn, name string) error {
+	var invalid bool
+	err := conn.QueryRowContext(ctx,
+		`SELECT NOT indisvalid FROM pg_index WHERE indexrelid = to_regclass($1)`, name).Scan(&invalid)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && !invalid) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("check index %s: %w", name, err)
+	}
+	if _, err := conn.ExecContext(ctx, `DROP INDEX CONCURRENTLY IF EXISTS `+name); err != nil {
+		return fmt.Errorf("drop invalid index %s: %w", name, err)
+	}
+	return nil
+}
+
+// splitStatements splits a script on semicolons that end a line, dropping
+// chunks that hold only comments.
+func splitStatements(script string) []string {
+	var (
+		stmts []string
+		cur   strings.Builder
+	)
+	flush := func() {
+		stmt := strings.TrimSpace(cur.String())
+		cur.Reset()
+		for _, line := range strings.Split(stmt, "\n") {
+			if l := strings.TrimSpace(line); l != "" && !strings.HasPrefix(l, "--") {
+				stmts = append(stmts, stmt)
+				return
+			}
+		}
+	}
+	for _, line := range strings.SplitAfter(script, "\n") {
+		cur.WriteString(line)
+		if strings.HasSuffix(strings.TrimSpace(line), ";") {
+			flush()
+		}
+	}
+	flush()
+	return stmts
+}
+
+func appliedVersions(ctx context.Context, conn *sql.Conn) (map[int64]time.Time, error) {
+	rows, err := conn.QueryContext(ctx, `SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, fmt.Errorf("migrate: list applied: %w", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[int64]time.Time)
+	for rows.Next() {
+		var (
+			version int64
+			at      time.Time
+		)
+		if err := rows.Scan(&version, &at); err != nil {
+			return nil, fmt.Errorf("migrate: scan applied: %w", err)
+		}
+		applied[version] = at
+	}
+	return applied, rows.Err()
+}
+
+func load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("migrate: read migrations: %w", err)
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, e := range entries {
+		name := e.Name()
+		var (
+			base string
+			up   bool
+		)
+		switch {
+		case strings.HasSuffix(name, ".up.sql"):
+			base, up = strings.TrimSuffix(name, ".up.sql"), true
+		case strings.HasSuffix(name, ".down.sql"):
+			base = strings.TrimSuffix(name, ".down.sql")
+		default:
+			continue
+		}
+
+		prefix, label, ok := strings.Cut(base, "_")
+		if !ok {
+			return nil, fmt.Errorf("migrate: %s: expected NNNN_name", name)
+		}
+		version, err := strconv.ParseInt(prefix, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("migrate: %s: bad version: %w", name, err)
+		}
+
+		body, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, fmt.Errorf("migrate: read %s: %w", name, err)
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: label}
+			byVersion[version] = m
+		} else if m.Name != label {
+			return nil, fmt.Errorf("migrate: version %d used by %q and %q", version, m.Name, label)
+		}
+		if up {
+			m.Up = string(body)
+		} else {
+			m.Down = string(body)
+		}
+	}
+
+	out := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migrate: %04d_%s has no up migration", m.Version, m.Name)
+		}
+		out = append(out, *m)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Version < out[j].Version })
+	return out, nil
+}
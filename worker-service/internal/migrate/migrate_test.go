@@ -0,0 +1,48 @@
+package migrate
+
+import (
+	"testing"
+
+	"github.com/Bharat1Rajput/workerService/migrations"
+)
+
+func TestConcurrentIndexName(t *testing.T) {
+	for _, tc := range []struct {
+		stmt string
+		want string
+	}{
+		{"CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_a\n    ON t (a);", "idx_a"},
+		{"-- comment\ncreate unique index concurrently if not exists idx_b on t (b);", "idx_b"},
+		{`CREATE INDEX CONCURRENTLY IF NOT EXISTS "Idx C" ON t (c);`, `"Idx C"`},
+		{"CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_d(a);", "idx_d"},
+		{"CREATE INDEX CONCURRENTLY idx_e ON t (e);", ""},
+		{"CREATE EXTENSION IF NOT EXISTS pg_trgm;", ""},
+	} {
+		if got := concurrentIndexName(tc.stmt); got != tc.want {
+			t.Errorf("concurrentIndexName(%q) = %q, want %q", tc.stmt, got, tc.want)
+		}
+	}
+}
+
+func TestNoTransactionMigrationsNameTheirIndexes(t *testing.T) {
+	runner, err := New(nil, migrations.FS, nil)
+	if err != nil {
+		t.Fatalf("load migrations: %v", err)
+	}
+	for _, m := range runner.migrations {
+		if m.Version != 12 {
+			continue
+		}
+		var names []string
+		for _, stmt := range splitStatements(m.Up) {
+			if name := concurrentIndexName(stmt); name != "" {
+				names = append(names, name)
+			}
+		}
+		if len(names) != 5 {
+			t.Errorf("%04d_%s: found indexes %v, want 5", m.Version, m.Name, names)
+		}
+		return
+	}
+	t.Fatal("migration 0012 not found")
+}
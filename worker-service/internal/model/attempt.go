@@ -0,0 +1,52 @@
+package model
+
+import (
+	"time"
+
+	"github.com/Bharat1Rajput/contract"
+)
+
+type ErrorClass string
+
+const (
+	ErrorClassNone      ErrorClass = ""
+	ErrorClassRequest   ErrorClass = "request"
+	ErrorClassBlocked   ErrorClass = "blocked"
+	ErrorClassTLSConfig ErrorClass = "tls_config"
+	ErrorClassBlobStore ErrorClass = "blob_store"
+	ErrorClassTimeout   ErrorClass = "timeout"
+	ErrorClassNetwork   ErrorClass = "network"
+	ErrorClassHTTP4xx   ErrorClass = "http_4xx"
+	ErrorClassHTTP5xx   ErrorClass = "http_5xx"
+	ErrorClassHTTPOther ErrorClass = "http_other"
+)
+
+// Classification is the retry decision derived from an attempt's outcome.
+type Classification = contract.Classification
+
+const (
+	ClassificationSuccess   = contract.ClassificationSuccess
+	ClassificationRetryable = contract.ClassificationRetryable
+	ClassificationPermanent = contract.ClassificationPermanent
+)
+
+// Attempt is a single delivery try of a WebhookJob, as stored in webhook_attempts.
+type Attempt struct {
+	JobID           string
+	StartedAt       time.Time
+	Duration        time.Duration
+	StatusCode      int
+	ResponseHeaders map[string]string
+	ResponseBody    string
+	Error           string
+	ErrorClass      ErrorClass
+
+	// Number is set by RecordAttempt, after the attempts on record for the
+	// job. Set beforehand, it is the number of attempts the job's message
+	// reports, and numbering continues after it if that is more.
+	Number int
+
+	// RetryAfter is the delay requested by the receiver via Retry-After.
+	// It drives the next backoff and is not persisted.
+	RetryAfter time.Duration
+}
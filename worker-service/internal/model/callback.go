@@ -0,0 +1,27 @@
+package model
+
+// CallbackStatus tracks a finished job's status callback, so that one lost
+// from the worker's in-memory queue, when it was full or the worker stopped,
+// is found and sent again.
+type CallbackStatus string
+
+const (
+	// CallbackNone is for jobs that asked for no callback or are not
+	// finished yet.
+	CallbackNone CallbackStatus = ""
+	// CallbackPending callbacks are queued or waiting to be queued again.
+	CallbackPending CallbackStatus = "pending"
+	CallbackSent    CallbackStatus = "sent"
+	// CallbackFailed callbacks ran out of attempts or were refused.
+	CallbackFailed CallbackStatus = "failed"
+	// CallbackSkipped callbacks were not sent because the worker has no
+	// CALLBACK_HMAC_SECRET to sign them with.
+	CallbackSkipped CallbackStatus = "skipped"
+)
+
+// PendingCallback is a finished job whose status callback is still to be
+// sent, with the number of delivery attempts recorded for it.
+type PendingCallback struct {
+	Job      *WebhookJob
+	Attempts int
+}
@@ -1,24 +1,90 @@
 package model
 
-import "time"
+import (
+	"mime"
+	"strings"
+	"time"
 
-type JobStatus string
+	"github.com/Bharat1Rajput/contract"
+)
+
+// JobStatus is a job status, as shared with the API.
+type JobStatus = contract.Status
 
 const (
-	StatusPending    JobStatus = "pending"
-	StatusProcessing JobStatus = "processing"
-	StatusSuccess    JobStatus = "success"
-	StatusFailed     JobStatus = "failed"
+	StatusPending    = JobStatus(contract.StatusPending)
+	StatusProcessing = JobStatus(contract.StatusProcessing)
+	StatusSuccess    = JobStatus(contract.StatusSuccess)
+	StatusFailed     = JobStatus(contract.StatusFailed)
+	StatusExpired    = JobStatus(contract.StatusExpired)
+	StatusCancelled  = JobStatus(contract.StatusCancelled)
 )
 
+const ContentTypeJSON = "application/json"
+
+// IsJSONContentType reports whether ct is application/json or a +json type.
+func IsJSONContentType(ct string) bool {
+	mediaType, _, err := mime.ParseMediaType(ct)
+	if err != nil {
+		return false
+	}
+	return mediaType == ContentTypeJSON || strings.HasSuffix(mediaType, "+json")
+}
+
+// WebhookJob is the queued job: the contract.Job carried by its message,
+// the tenant it was submitted for, and the message's Attempt and Headers.
+// Body holds the exact bytes to deliver; Payload is the string-encoded form
+// used by messages published before native payloads and is only read for
+// backwards compatibility.
 type WebhookJob struct {
-	ID         string    `json:"id"`
-	Payload    string    `json:"payload"`
-	ClientURL  string    `json:"client_url"`
-	Status     JobStatus `json:"status"`
-	Error      string    `json:"error"`
-	RetryCount int       `json:"retry_count"`
-	CreatedAt  time.Time `json:"created_at"`
-	UpdatedAt  time.Time `json:"updated_at"`
+	contract.Job
+	TenantID string `json:"tenant_id,omitempty"`
+
+	// Attempt and Headers are not stored; a job read back from the
+	// repository has neither.
+	Attempt int               `json:"-"`
+	Headers map[string]string `json:"-"`
+}
+
+// FromMessage returns the job carried by a queue message of any version.
+func FromMessage(m *contract.Message) *WebhookJob {
+	return &WebhookJob{
+		Job:      m.Job,
+		TenantID: m.TenantID,
+		Attempt:  m.Attempt,
+		Headers:  m.Headers,
+	}
 }
 
+// Message wraps the job for the queue, as republished by dispatchctl.
+// attempt is the number of delivery attempts already recorded for it. A
+// legacy string Payload is sent as Body.
+func (j *WebhookJob) Message(attempt int) *contract.Message {
+	job := j.Job
+	job.Body, job.Payload = nil, ""
+	if job.PayloadRef == nil {
+		job.Body = j.Content()
+	}
+	return contract.NewMessage(job, j.TenantID, attempt)
+}
+
+// Content returns the bytes to deliver.
+func (j *WebhookJob) Content() []byte {
+	if j.Body != nil {
+		return j.Body
+	}
+	return []byte(j.Payload)
+}
+
+// MediaType returns the Content-Type to deliver with, defaulting to JSON.
+func (j *WebhookJob) MediaType() string {
+	if j.ContentType == "" {
+		return ContentTypeJSON
+	}
+	return j.ContentType
+}
+
+// Expired reports whether the job has an expiry at or before now.
+func (j *WebhookJob) Expired(now time.Time) bool {
+	return j.ExpiresAt != nil && !now.Before(*j.ExpiresAt)
+}
@@ -0,0 +1,124 @@
+package ordering
+
+import (
+	"sync"
+	"time"
+)
+
+// Recheck is how long a job that is not first in its key's line is put back
+// on the queue before it is offered again.
+const Recheck = time.Second
+
+// staleAfter is how long a line waits for its first job to come back before
+// giving up on it, for instance because another worker received it.
+const staleAfter = 15 * time.Minute
+
+// Sequencer keeps jobs sharing a key in the order the consumer first
+// received them. Only the first job in a key's line may run; the consumer
+// puts the others back on the queue until their turn, so a key waiting on a
+// slow or retrying job holds neither a worker slot nor a prefetched message.
+type Sequencer struct {
+	mu    sync.Mutex
+	lines map[string]*line
+}
+
+type line struct {
+	jobs []string
+	// running is set while the first job is being worked on.
+	running bool
+	// due is when the first job is expected back while it is not running.
+	due time.Time
+}
+
+func NewSequencer() *Sequencer {
+	return &Sequencer{lines: make(map[string]*line)}
+}
+
+// Admit reports whether jobID may run now. A job seen for the first time
+// joins the back of key's line. It may run once it is first and not already
+// running; Admit then marks it running, and the caller must call Defer or
+// Done for it. Otherwise Admit returns how long to wait before offering the
+// job again.
+func (s *Sequencer) Admit(key, jobID string, now time.Time) (time.Duration, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	l, ok := s.lines[key]
+	if !ok {
+		l = &line{}
+		s.lines[key] = l
+	}
+	if !l.running && len(l.jobs) > 0 && l.jobs[0] != jobID && now.Sub(l.due) > staleAfter {
+		l.drop(now)
+	}
+	if !l.contains(jobID) {
+		if len(l.jobs) == 0 {
+			l.due = now
+		}
+		l.jobs = append(l.jobs, jobID)
+	}
+
+	if l.jobs[0] != jobID || l.running {
+		return max(l.due.Sub(now), Recheck), false
+	}
+	l.running = true
+	return 0, true
+}
+
+// Defer keeps a running job first in its line without running it, until it
+// comes back at due, for example to be retried.
+func (s *Sequencer) Defer(key, jobID string, due time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if l, ok := s.lines[key]; ok && len(l.jobs) > 0 && l.jobs[0] == jobID {
+		l.running = false
+		l.due = due
+	}
+}
+
+// Done takes jobID out of its line, letting the next job run.
+func (s *Sequencer) Done(key, jobID string, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	l, ok := s.lines[key]
+	if !ok {
+		return
+	}
+	for i, id := range l.jobs {
+		if id != jobID {
+			continue
+		}
+		if i == 0 {
+			l.drop(now)
+		} else {
+			l.jobs = append(l.jobs[:i], l.jobs[i+1:]...)
+		}
+		break
+	}
+	if len(l.jobs) == 0 {
+		delete(s.lines, key)
+	}
+}
+
+// drop removes the first job; the next one is expected back at once.
+func (l *line) drop(now time.Time) {
+	l.jobs = l.jobs[1:]
+	l.running = false
+	l.due = now
+}
+
+func (l *line) contains(jobID string) bool {
+	for _, id := range l.jobs {
+		if id == jobID {
+			return true
+		}
+	}
+	return false
+}
+
+// Key scopes an ordering key to the destination it is delivered to.
+func Key(clientURL, orderingKey string) string {
+	return clientURL + "\x00" + orderingKey
+}
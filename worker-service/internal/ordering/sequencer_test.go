@@ -0,0 +1,73 @@
+package ordering
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSequencerRunsJobsInArrivalOrder(t *testing.T) {
+	s := NewSequencer()
+	now := time.Now()
+
+	if _, ok := s.Admit("k", "a", now); !ok {
+		t.Fatal("first job not admitted")
+	}
+	if wait, ok := s.Admit("k", "b", now); ok || wait < Recheck {
+		t.Fatalf("Admit(b) = (%v, %v) while a runs, want a wait", wait, ok)
+	}
+	if _, ok := s.Admit("other", "x", now); !ok {
+		t.Fatal("job with another key not admitted")
+	}
+	if _, ok := s.Admit("k", "a", now); ok {
+		t.Fatal("a admitted twice while running")
+	}
+
+	s.Done("k", "a", now)
+	if _, ok := s.Admit("k", "c", now); ok {
+		t.Fatal("c admitted before b")
+	}
+	if _, ok := s.Admit("k", "b", now); !ok {
+		t.Fatal("b not admitted after a finished")
+	}
+	s.Done("k", "b", now)
+	if _, ok := s.Admit("k", "c", now); !ok {
+		t.Fatal("c not admitted after b finished")
+	}
+}
+
+func TestSequencerDeferKeepsPlace(t *testing.T) {
+	s := NewSequencer()
+	now := time.Now()
+	due := now.Add(time.Minute)
+
+	s.Admit("k", "a", now)
+	s.Admit("k", "b", now)
+	s.Defer("k", "a", due)
+
+	wait, ok := s.Admit("k", "b", now)
+	if ok {
+		t.Fatal("b admitted while a waits for a retry")
+	}
+	if wait != time.Minute {
+		t.Fatalf("wait = %v, want a's retry delay", wait)
+	}
+	if _, ok := s.Admit("k", "a", due); !ok {
+		t.Fatal("a not admitted for its retry")
+	}
+}
+
+func TestSequencerGivesUpOnStaleJob(t *testing.T) {
+	s := NewSequencer()
+	now := time.Now()
+
+	s.Admit("k", "a", now)
+	s.Admit("k", "b", now)
+	s.Defer("k", "a", now)
+
+	if _, ok := s.Admit("k", "b", now.Add(staleAfter/2)); ok {
+		t.Fatal("b admitted before a was stale")
+	}
+	if _, ok := s.Admit("k", "b", now.Add(staleAfter+time.Second)); !ok {
+		t.Fatal("b not admitted after a went stale")
+	}
+}
@@ -0,0 +1,89 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/Bharat1Rajput/ssrf"
+	"github.com/Bharat1Rajput/workerService/internal/model"
+)
+
+// recordAttempt stores the attempt history row. A failure here is logged but
+// never fails the delivery itself: history is diagnostic, the job status is not.
+func (p *Processor) recordAttempt(ctx context.Context, attempt *model.Attempt) {
+	if err := p.repo.RecordAttempt(ctx, attempt); err != nil {
+		p.logger.Error("processor: record attempt",
+			zap.String("job_id", attempt.JobID),
+			zap.Error(err),
+		)
+	}
+}
+
+func transportErrorClass(err error) model.ErrorClass {
+	if errors.Is(err, ssrf.ErrBlocked) {
+		return model.ErrorClassBlocked
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return model.ErrorClassTimeout
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return model.ErrorClassTimeout
+	}
+	return model.ErrorClassNetwork
+}
+
+func statusErrorClass(code int) model.ErrorClass {
+	switch {
+	case code >= 400 && code < 500:
+		return model.ErrorClassHTTP4xx
+	case code >= 500 && code < 600:
+		return model.ErrorClassHTTP5xx
+	default:
+		return model.ErrorClassHTTPOther
+	}
+}
+
+// captureHeaders flattens response headers into a map, stopping once the
+// accumulated size of names and values reaches limit bytes.
+func captureHeaders(h http.Header, limit int) map[string]string {
+	out := make(map[string]string, len(h))
+
+	names := make([]string, 0, len(h))
+	for name := range h {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	size := 0
+	for _, name := range names {
+		value := sanitize(strings.Join(h.Values(name), ", "))
+		if size+len(name)+len(value) > limit {
+			break
+		}
+		size += len(name) + len(value)
+		out[name] = value
+	}
+	return out
+}
+
+// captureBody reads at most limit bytes of the response body.
+func captureBody(r io.Reader, limit int) string {
+	if limit <= 0 {
+		return ""
+	}
+	b, _ := io.ReadAll(io.LimitReader(r, int64(limit)))
+	return sanitize(string(b))
+}
+
+// sanitize makes arbitrary response bytes safe for a Postgres TEXT column.
+func sanitize(s string) string {
+	return strings.ReplaceAll(strings.ToValidUTF8(s, "�"), "\x00", "")
+}
@@ -0,0 +1,99 @@
+package processor
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Bharat1Rajput/workerService/internal/callback"
+	"github.com/Bharat1Rajput/workerService/internal/model"
+)
+
+// expire records the job as expired and notifies its submitter.
+func (p *Processor) expire(ctx context.Context, job *model.WebhookJob, reason string, attempts int) error {
+	if err := p.repo.MarkExpired(ctx, job.ID, reason); err != nil {
+		return err
+	}
+	p.notify(ctx, job, model.StatusExpired, "", attempts, reason)
+	return nil
+}
+
+// callbackBatch is the most pending callbacks ResendCallbacks claims at a
+// time.
+const callbackBatch = 100
+
+// notify queues the job's status callback, if it asked for one. The
+// repository already holds the callback as pending, so one that does not fit
+// in the queue is sent later by ResendCallbacks. A callback that cannot be
+// delivered does not affect the job's outcome, which is already recorded.
+func (p *Processor) notify(ctx context.Context, job *model.WebhookJob, status model.JobStatus, class model.Classification, attempts int, lastErr string) {
+	if job.StatusCallbackURL == "" {
+		return
+	}
+	if p.callbacks == nil {
+		p.logger.Warn("processor: status callback skipped, CALLBACK_HMAC_SECRET is not set",
+			zap.String("job_id", job.ID),
+		)
+		if err := p.repo.FinishCallback(ctx, job.ID, model.CallbackSkipped, 0); err != nil {
+			p.logger.Warn("processor: recording skipped status callback failed",
+				zap.String("job_id", job.ID),
+				zap.Error(err),
+			)
+		}
+		return
+	}
+
+	ok := p.callbacks.Enqueue(job.StatusCallbackURL, callback.Status{
+		JobID:          job.ID,
+		TenantID:       job.TenantID,
+		EventType:      job.EventType,
+		Status:         status,
+		Classification: class,
+		Attempts:       attempts,
+		LastError:      lastErr,
+		CompletedAt:    time.Now().UTC(),
+	})
+	if !ok {
+		p.logger.Warn("processor: status callback deferred, callback queue is full",
+			zap.String("job_id", job.ID),
+		)
+	}
+}
+
+// ResendCallbacks queues the status callbacks that have been pending since
+// before stale: those that did not fit in the queue, and those lost with the
+// queue of a worker that stopped before sending them. It returns how many it
+// queued.
+func (p *Processor) ResendCallbacks(ctx context.Context, stale time.Time) (int, error) {
+	if p.callbacks == nil {
+		return 0, nil
+	}
+	queued := 0
+	for {
+		pending, err := p.repo.ClaimCallbacks(ctx, stale, callbackBatch)
+		if err != nil {
+			return queued, err
+		}
+		for _, c := range pending {
+			// The rest of the batch was claimed all the same, and is
+			// picked up again once it is stale.
+			if !p.callbacks.Enqueue(c.Job.StatusCallbackURL, callback.Status{
+				JobID:          c.Job.ID,
+				TenantID:       c.Job.TenantID,
+				EventType:      c.Job.EventType,
+				Status:         c.Job.Status,
+				Classification: c.Job.Classification,
+				Attempts:       c.Attempts,
+				LastError:      c.Job.Error,
+				CompletedAt:    c.Job.UpdatedAt.UTC(),
+			}) {
+				return queued, nil
+			}
+			queued++
+		}
+		if len(pending) < callbackBatch {
+			return queued, nil
+		}
+	}
+}
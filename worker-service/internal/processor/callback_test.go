@@ -0,0 +1,86 @@
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zaptest"
+
+	"github.com/Bharat1Rajput/contract"
+	"github.com/Bharat1Rajput/workerService/internal/callback"
+	"github.com/Bharat1Rajput/workerService/internal/model"
+	"github.com/Bharat1Rajput/workerService/internal/repository"
+)
+
+// TestRefusedCallbackIsResent checks that a status callback that does not
+// fit in the queue stays pending and is sent by ResendCallbacks.
+func TestRefusedCallbackIsResent(t *testing.T) {
+	var (
+		mu   sync.Mutex
+		sent []callback.Status
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var status callback.Status
+		if err := json.NewDecoder(r.Body).Decode(&status); err != nil {
+			t.Errorf("decode callback: %v", err)
+		}
+		mu.Lock()
+		sent = append(sent, status)
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	logger := zaptest.NewLogger(t)
+	repo := repository.NewMemoryJobRepository()
+	job := &model.WebhookJob{Job: contract.Job{
+		ID:                "job-1",
+		ClientURL:         "https://example.com/hook",
+		StatusCallbackURL: srv.URL,
+	}}
+	if _, err := repo.UpsertProcessing(ctx, job, time.Minute); err != nil {
+		t.Fatalf("UpsertProcessing: %v", err)
+	}
+	if err := repo.MarkSuccess(ctx, job.ID); err != nil {
+		t.Fatalf("MarkSuccess: %v", err)
+	}
+
+	// A closed Notifier refuses every callback, as a full one does.
+	refusing := callback.New(srv.Client(), "secret", 1, time.Millisecond, 1, 1, repo, logger)
+	if err := refusing.Close(ctx); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	p := &Processor{repo: repo, callbacks: refusing, logger: logger}
+	p.notify(ctx, job, model.StatusSuccess, model.ClassificationSuccess, 1, "")
+	if status, _ := repo.Callback(ctx, job.ID); status != model.CallbackPending {
+		t.Fatalf("callback after refusal: got %q, want %q", status, model.CallbackPending)
+	}
+
+	p.callbacks = callback.New(srv.Client(), "secret", 1, time.Millisecond, 1, 10, repo, logger)
+	if n, err := p.ResendCallbacks(ctx, time.Now().Add(-time.Hour)); err != nil || n != 0 {
+		t.Fatalf("ResendCallbacks before stale = %d, %v; want 0", n, err)
+	}
+	if n, err := p.ResendCallbacks(ctx, time.Now().Add(time.Second)); err != nil || n != 1 {
+		t.Fatalf("ResendCallbacks = %d, %v; want 1", n, err)
+	}
+	if err := p.callbacks.Close(ctx); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	if status, attempts := repo.Callback(ctx, job.ID); status != model.CallbackSent || attempts != 1 {
+		t.Errorf("callback: got %q after %d requests, want %q after 1", status, attempts, model.CallbackSent)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(sent) != 1 || sent[0].JobID != job.ID || sent[0].Status != model.StatusSuccess {
+		t.Errorf("sent: got %+v, want one success callback for %s", sent, job.ID)
+	}
+	if n, err := p.ResendCallbacks(ctx, time.Now().Add(time.Second)); err != nil || n != 0 {
+		t.Errorf("ResendCallbacks after sending = %d, %v; want 0", n, err)
+	}
+}
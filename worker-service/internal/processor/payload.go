@@ -0,0 +1,80 @@
+package processor
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/Bharat1Rajput/blob"
+	"github.com/Bharat1Rajput/workerService/internal/model"
+)
+
+// newRequest builds the delivery request. Offloaded payloads are streamed
+// from the blob store and checked against their recorded size and checksum
+// as they are sent; GetBody reopens the blob if the client follows a redirect.
+func (p *Processor) newRequest(ctx context.Context, job *model.WebhookJob) (*http.Request, error) {
+	if job.PayloadRef == nil {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, job.ClientURL, bytes.NewReader(job.Content()))
+		if err != nil {
+			return nil, fmt.Errorf("processor: build request: %w", err)
+		}
+		return req, nil
+	}
+
+	if p.blobs == nil {
+		return nil, fmt.Errorf("processor: payload is offloaded but no blob store is configured")
+	}
+
+	ref := *job.PayloadRef
+	open := func() (io.ReadCloser, error) {
+		rc, err := p.blobs.Get(ctx, ref.Key)
+		if err != nil {
+			return nil, fmt.Errorf("processor: open payload blob: %w", blobError(err))
+		}
+		return verifiedBody{Reader: blob.Verify(rc, ref), Closer: rc}, nil
+	}
+
+	body, err := open()
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, job.ClientURL, body)
+	if err != nil {
+		body.Close()
+		return nil, fmt.Errorf("processor: build request: %w", err)
+	}
+	req.ContentLength = ref.Size
+	req.GetBody = open
+	return req, nil
+}
+
+type verifiedBody struct {
+	io.Reader
+	io.Closer
+}
+
+// blobStoreError is a blob store failure that may clear up by itself, such as
+// an unreachable store, as opposed to a missing or corrupt blob. Deliveries
+// that hit one are retried.
+type blobStoreError struct {
+	err error
+}
+
+func (e *blobStoreError) Error() string { return e.err.Error() }
+func (e *blobStoreError) Unwrap() error { return e.err }
+
+// blobError marks err as a blobStoreError unless retrying cannot fix it.
+func blobError(err error) error {
+	if errors.Is(err, blob.ErrNotFound) || errors.Is(err, blob.ErrCorrupt) {
+		return err
+	}
+	return &blobStoreError{err: err}
+}
+
+func isBlobStoreError(err error) bool {
+	var e *blobStoreError
+	return errors.As(err, &e)
+}
@@ -0,0 +1,45 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/Bharat1Rajput/blob"
+	"github.com/Bharat1Rajput/contract"
+	"github.com/Bharat1Rajput/workerService/internal/model"
+)
+
+type failingStore struct {
+	blob.Store
+	err error
+}
+
+func (s failingStore) Get(context.Context, string) (io.ReadCloser, error) {
+	return nil, s.err
+}
+
+func TestBlobFailureClass(t *testing.T) {
+	job := &model.WebhookJob{Job: contract.Job{
+		ID:         "job-1",
+		ClientURL:  "https://example.com/hook",
+		PayloadRef: &blob.Ref{Key: "payloads/job-1.bin", Size: 5},
+	}}
+	for _, tc := range []struct {
+		err  error
+		want model.Classification
+	}{
+		{errors.New("blob.local: open: too many open files"), model.ClassificationRetryable},
+		{blob.ErrNotFound, model.ClassificationPermanent},
+	} {
+		p := &Processor{blobs: failingStore{err: tc.err}}
+		attempt := &model.Attempt{}
+		if err := p.postWebhook(context.Background(), job, attempt); !errors.Is(err, tc.err) {
+			t.Fatalf("postWebhook error = %v, want %v", err, tc.err)
+		}
+		if got := classify(attempt); got != tc.want {
+			t.Errorf("%v: classified %s (%s), want %s", tc.err, got, attempt.ErrorClass, tc.want)
+		}
+	}
+}
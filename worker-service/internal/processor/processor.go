@@ -1,103 +1,326 @@
 package processor
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
-	"net/http"
 	"time"
 
 	"go.uber.org/zap"
 
+	"github.com/Bharat1Rajput/blob"
+	"github.com/Bharat1Rajput/contract"
+	"github.com/Bharat1Rajput/workerService/internal/callback"
 	"github.com/Bharat1Rajput/workerService/internal/config"
+	"github.com/Bharat1Rajput/workerService/internal/endpoint"
+	"github.com/Bharat1Rajput/workerService/internal/httpclient"
 	"github.com/Bharat1Rajput/workerService/internal/model"
+	"github.com/Bharat1Rajput/workerService/internal/ordering"
 	"github.com/Bharat1Rajput/workerService/internal/repository"
+	"github.com/Bharat1Rajput/workerService/internal/retry"
 )
 
+// RetryError is returned by ProcessJob when an attempt failed and the job is
+// to be tried again After a delay. The consumer puts the message back on the
+// queue for that long instead of holding it while it waits.
+type RetryError struct {
+	After time.Duration
+	Err   error
+}
+
+func (e *RetryError) Error() string {
+	return fmt.Sprintf("processor: retry in %v: %v", e.After, e.Err)
+}
+
+func (e *RetryError) Unwrap() error { return e.Err }
+
+// errNotDue is the RetryError cause for a job delivered before its next
+// attempt is due, as brokers may redeliver early, for instance after a
+// worker crash.
+var errNotDue = errors.New("processor: retry not due yet")
+
+// errClaimed is the RetryError cause for a job delivered while another
+// worker holds it.
+var errClaimed = errors.New("processor: job is held by another worker")
+
+// ErrPreceded is the RetryError cause for a job delivered while an earlier
+// job with the same ordering key is unfinished, possibly on another worker
+// or from before a restart. The job must give up its place in line.
+var ErrPreceded = errors.New("processor: an earlier job with the same ordering key is unfinished")
+
+// dueTolerance absorbs clock differences between workers when deciding
+// whether a redelivered job is due.
+const dueTolerance = time.Second
+
 type Processor struct {
-	cfg    *config.Config
-	repo   repository.JobRepository
-	client *http.Client
-	logger *zap.Logger
+	cfg       *config.Config
+	repo      repository.JobRepository
+	policies  *retry.Registry
+	endpoints *endpoint.Registry
+	clients   *httpclient.Pool
+	blobs     blob.Store
+	callbacks *callback.Notifier
+	logger    *zap.Logger
 }
 
-func New(cfg *config.Config, repo repository.JobRepository, logger *zap.Logger) *Processor {
+// New builds a Processor. callbacks may be nil, in which case status
+// callbacks requested by jobs are skipped.
+func New(cfg *config.Config, repo repository.JobRepository, policies *retry.Registry, endpoints *endpoint.Registry, clients *httpclient.Pool, blobs blob.Store, callbacks *callback.Notifier, logger *zap.Logger) *Processor {
 	return &Processor{
-		cfg:  cfg,
-		repo: repo,
-		client: &http.Client{
-			Timeout: time.Duration(cfg.HTTPClientTimeoutSec) * time.Second,
-		},
-		logger: logger,
+		cfg:       cfg,
+		repo:      repo,
+		policies:  policies,
+		endpoints: endpoints,
+		clients:   clients,
+		blobs:     blobs,
+		callbacks: callbacks,
+		logger:    logger,
 	}
 }
 
+// ProcessJob makes one delivery attempt. It returns nil once the job has
+// succeeded, has been cancelled or was already finished, a *RetryError if it
+// should be attempted again later, and any other error if it failed for good
+// or could not be recorded.
 func (p *Processor) ProcessJob(ctx context.Context, job *model.WebhookJob) error {
-	if err := p.repo.UpsertProcessing(ctx, job); err != nil {
+	if job.OrderingKey != "" {
+		due, ok, err := p.repo.Preceding(ctx, job)
+		if err != nil {
+			return err
+		}
+		if ok {
+			return &RetryError{After: max(time.Until(due), ordering.Recheck), Err: ErrPreceded}
+		}
+	}
+
+	claimed, err := p.repo.UpsertProcessing(ctx, job, p.lease())
+	if err != nil {
 		return err
 	}
+	if !claimed {
+		return p.unclaimed(ctx, job)
+	}
+	// An attempt cut short by shutdown hands the job back, so that its
+	// redelivery is not taken for a duplicate.
+	defer func() {
+		if ctx.Err() != nil {
+			p.release(job)
+		}
+	}()
 
-	attempt := 0
-	for {
-		attempt++
-		err := p.postWebhook(ctx, job)
-		if err == nil {
-			if err := p.repo.MarkSuccess(ctx, job.ID); err != nil {
-				return err
-			}
-			return nil
+	// A job can pass its expiry while waiting behind others with the same
+	// ordering key, or for its next retry.
+	if job.Expired(time.Now()) {
+		return p.expire(ctx, job, "job expired before delivery", 0)
+	}
+
+	next, err := p.repo.NextAttempt(ctx, job.ID)
+	if err != nil {
+		return err
+	}
+	if wait := time.Until(next); wait > dueTolerance {
+		if err := p.repo.ScheduleRetry(ctx, job.ID, next); err != nil {
+			return err
 		}
+		return &RetryError{After: wait, Err: errNotDue}
+	}
 
-		p.logger.Warn("processor: job failed", zap.String("job_id", job.ID), zap.Error(err))
+	attempt := &model.Attempt{JobID: job.ID, Number: job.Attempt, StartedAt: time.Now().UTC()}
+	delivery, err := p.transformJob(ctx, job)
+	switch {
+	case err == nil:
+		err = p.postWebhook(ctx, delivery, attempt)
+	case isBlobStoreError(err):
+		attempt.ErrorClass = model.ErrorClassBlobStore
+	default:
+		p.logger.Warn("processor: transform failed", zap.String("job_id", job.ID), zap.Error(err))
+		if err := p.repo.MarkFailed(ctx, job.ID, err.Error(), model.ClassificationPermanent); err != nil {
+			return err
+		}
+		p.notify(ctx, job, model.StatusFailed, model.ClassificationPermanent, 0, err.Error())
+		return fmt.Errorf("processor: job %s: %w", job.ID, err)
+	}
+	attempt.Duration = time.Since(attempt.StartedAt)
+	if err != nil {
+		attempt.Error = err.Error()
+	}
+	p.recordAttempt(ctx, attempt)
+	// The repository numbers attempts across redeliveries of the job, and
+	// after those its message says were made before it was published.
+	attempts := max(attempt.Number, 1)
 
-		retryCount, incErr := p.repo.IncrementRetry(ctx, job.ID)
-		if incErr != nil {
-			return incErr
+	class := classify(attempt)
+	if class == model.ClassificationSuccess {
+		if err := p.repo.MarkSuccess(ctx, job.ID); err != nil {
+			return err
 		}
+		p.notify(ctx, job, model.StatusSuccess, class, attempts, "")
+		return nil
+	}
 
-		if retryCount >= p.cfg.MaxRetries {
-			if err := p.repo.MarkFailed(ctx, job.ID, err.Error()); err != nil {
-				return err
-			}
-			return fmt.Errorf("processor: job %s exhausted retries: %w", job.ID, err)
+	p.logger.Warn("processor: job failed",
+		zap.String("job_id", job.ID),
+		zap.String("classification", string(class)),
+		zap.Error(err),
+	)
+
+	if class == model.ClassificationPermanent {
+		if err := p.repo.MarkFailed(ctx, job.ID, err.Error(), class); err != nil {
+			return err
 		}
+		p.notify(ctx, job, model.StatusFailed, class, attempts, err.Error())
+		return fmt.Errorf("processor: job %s failed permanently: %w", job.ID, err)
+	}
+
+	retryCount, incErr := p.repo.IncrementRetry(ctx, job.ID, err.Error(), class)
+	if incErr != nil {
+		return incErr
+	}
 
-		backoff := time.Duration(p.cfg.BackoffBaseMS) * time.Millisecond * (1 << (retryCount - 1))
-		if backoff > 5*time.Minute {
-			backoff = 5 * time.Minute
+	policy := p.policyFor(job)
+	backoff := policy.Backoff(retryCount)
+	if attempt.RetryAfter > 0 {
+		backoff = attempt.RetryAfter
+	}
+
+	if job.Expired(time.Now().Add(backoff)) {
+		p.logger.Info("processor: abandoning retries past expiry", zap.String("job_id", job.ID))
+		return p.expire(ctx, job, fmt.Sprintf("job expired during retries: %v", err), attempts)
+	}
+
+	if policy.Exhausted(retryCount, job.CreatedAt, backoff, time.Now()) {
+		if err := p.repo.MarkFailed(ctx, job.ID, err.Error(), class); err != nil {
+			return err
 		}
-		select {
-		case <-time.After(backoff):
-		case <-ctx.Done():
-			return ctx.Err()
+		p.notify(ctx, job, model.StatusFailed, class, attempts, err.Error())
+		return fmt.Errorf("processor: job %s exhausted retry policy %q: %w", job.ID, policy.Name, err)
+	}
+
+	// The retry time is kept with the job so that a redelivery arriving
+	// early is put back until then rather than attempted.
+	if err := p.repo.ScheduleRetry(ctx, job.ID, time.Now().Add(backoff)); err != nil {
+		return err
+	}
+	return &RetryError{After: backoff, Err: err}
+}
+
+// Discard records a job that expired before it was delivered, without
+// attempting it. A job that is finished or held by another worker is left
+// alone.
+func (p *Processor) Discard(ctx context.Context, job *model.WebhookJob) error {
+	claimed, err := p.repo.UpsertProcessing(ctx, job, p.lease())
+	if err != nil || !claimed {
+		return err
+	}
+	return p.expire(ctx, job, "job expired before delivery", 0)
+}
+
+// unclaimed handles a delivery of a job it could not claim. A cancelled job
+// is dropped here, which is how an operator stops a job between attempts
+// (see dispatchctl cancel); so is a duplicate delivery of a finished job.
+// A job held by another worker is looked at again once that worker's lease
+// would have run out, in case it stopped.
+func (p *Processor) unclaimed(ctx context.Context, job *model.WebhookJob) error {
+	status, err := p.repo.Status(ctx, job.ID)
+	if err != nil {
+		return err
+	}
+	switch status {
+	case model.StatusCancelled:
+		p.logger.Info("processor: job cancelled", zap.String("job_id", job.ID))
+		if job.StatusCallbackURL != "" {
+			if err := p.repo.QueueCallback(ctx, job.ID, job.StatusCallbackURL); err != nil {
+				return err
+			}
 		}
+		p.notify(ctx, job, model.StatusCancelled, "", 0, "")
+		return nil
+	case model.StatusPending, model.StatusProcessing:
+		return &RetryError{After: p.lease(), Err: errClaimed}
+	default:
+		p.logger.Info("processor: skipping delivery of finished job",
+			zap.String("job_id", job.ID),
+			zap.String("status", string(status)),
+		)
+		return nil
 	}
 }
 
-func (p *Processor) postWebhook(ctx context.Context, job *model.WebhookJob) error {
-	payload := job.Payload
-	buf := bytes.NewBufferString(payload)
+// release puts a claimed job back to pending, due at once.
+func (p *Processor) release(job *model.WebhookJob) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := p.repo.ScheduleRetry(ctx, job.ID, time.Now()); err != nil {
+		p.logger.Error("processor: release job", zap.String("job_id", job.ID), zap.Error(err))
+	}
+}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, job.ClientURL, buf)
+// lease is how long a claimed job is left to its worker before another may
+// take it over.
+func (p *Processor) lease() time.Duration {
+	return time.Duration(p.cfg.ProcessingLeaseSec) * time.Second
+}
+
+func (p *Processor) postWebhook(ctx context.Context, job *model.WebhookJob, attempt *model.Attempt) error {
+	req, err := p.newRequest(ctx, job)
 	if err != nil {
-		return fmt.Errorf("processor: build request: %w", err)
+		attempt.ErrorClass = model.ErrorClassRequest
+		if isBlobStoreError(err) {
+			attempt.ErrorClass = model.ErrorClassBlobStore
+		}
+		return err
 	}
-	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Content-Type", job.MediaType())
 	req.Header.Set("X-Webhook-Job-Id", job.ID)
+	// The delivery joins the trace the job was submitted in.
+	for _, name := range contract.TraceHeaders {
+		if v := job.Headers[name]; v != "" {
+			req.Header.Set(name, v)
+		}
+	}
 
-	resp, err := p.client.Do(req)
+	client, err := p.clients.For(p.endpoints.Match(job.ClientURL))
 	if err != nil {
+		attempt.ErrorClass = model.ErrorClassTLSConfig
+		return fmt.Errorf("processor: client: %w", err)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		attempt.ErrorClass = transportErrorClass(err)
 		return fmt.Errorf("processor: do request: %w", err)
 	}
 	defer resp.Body.Close()
 
+	attempt.StatusCode = resp.StatusCode
+	attempt.ResponseHeaders = captureHeaders(resp.Header, p.cfg.AttemptCaptureBytes)
+	attempt.ResponseBody = captureBody(resp.Body, p.cfg.AttemptCaptureBytes)
+	attempt.RetryAfter = retryAfter(resp.Header, time.Now(), time.Duration(p.cfg.RetryAfterMaxSec)*time.Second)
+
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		var respBody map[string]any
-		_ = json.NewDecoder(resp.Body).Decode(&respBody)
+		attempt.ErrorClass = statusErrorClass(resp.StatusCode)
 		return fmt.Errorf("processor: non-2xx status %d", resp.StatusCode)
 	}
 
 	return nil
 }
 
+// policyFor resolves the retry policy for a job: the one named on the
+// submission, else the one configured for its endpoint, else the default.
+func (p *Processor) policyFor(job *model.WebhookJob) *retry.Policy {
+	if job.RetryPolicy != "" {
+		if policy, ok := p.policies.Get(job.RetryPolicy); ok {
+			return policy
+		}
+		p.logger.Warn("processor: unknown retry policy, using default",
+			zap.String("job_id", job.ID),
+			zap.String("retry_policy", job.RetryPolicy),
+		)
+	}
+	if ep := p.endpoints.Match(job.ClientURL); ep != nil && ep.RetryPolicy != "" {
+		if policy, ok := p.policies.Get(ep.RetryPolicy); ok {
+			return policy
+		}
+	}
+	return p.policies.Default()
+}
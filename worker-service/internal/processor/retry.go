@@ -0,0 +1,56 @@
+package processor
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Bharat1Rajput/workerService/internal/model"
+)
+
+// classify decides whether a failed attempt is worth retrying. 4xx responses
+// are the receiver rejecting the request itself, so only the ones that signal
+// "try later" (408, 425, 429) are retried; 5xx and network errors always are.
+// An unusable endpoint TLS setup is retried too, since certificates are
+// reloaded from disk once an operator fixes them.
+func classify(attempt *model.Attempt) model.Classification {
+	switch attempt.ErrorClass {
+	case model.ErrorClassNone:
+		return model.ClassificationSuccess
+	case model.ErrorClassTimeout, model.ErrorClassNetwork, model.ErrorClassHTTP5xx, model.ErrorClassTLSConfig, model.ErrorClassBlobStore:
+		return model.ClassificationRetryable
+	case model.ErrorClassHTTP4xx:
+		switch attempt.StatusCode {
+		case http.StatusRequestTimeout, http.StatusTooEarly, http.StatusTooManyRequests:
+			return model.ClassificationRetryable
+		}
+		return model.ClassificationPermanent
+	default:
+		return model.ClassificationPermanent
+	}
+}
+
+// retryAfter parses a Retry-After header given either as delay-seconds or as
+// an HTTP date, capped at max. It returns 0 when the header is absent or invalid.
+func retryAfter(h http.Header, now time.Time, max time.Duration) time.Duration {
+	v := strings.TrimSpace(h.Get("Retry-After"))
+	if v == "" {
+		return 0
+	}
+
+	var d time.Duration
+	if secs, err := strconv.Atoi(v); err == nil {
+		d = time.Duration(secs) * time.Second
+	} else if t, err := http.ParseTime(v); err == nil {
+		d = t.Sub(now)
+	}
+
+	if d <= 0 {
+		return 0
+	}
+	if max > 0 && d > max {
+		return max
+	}
+	return d
+}
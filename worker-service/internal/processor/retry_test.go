@@ -0,0 +1,117 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/Bharat1Rajput/ssrf"
+	"github.com/Bharat1Rajput/workerService/internal/model"
+)
+
+func TestClassifyStatusCodes(t *testing.T) {
+	for _, tc := range []struct {
+		code int
+		want model.Classification
+	}{
+		{http.StatusOK, model.ClassificationSuccess},
+		{http.StatusNoContent, model.ClassificationSuccess},
+		{http.StatusMovedPermanently, model.ClassificationPermanent},
+		{http.StatusNotModified, model.ClassificationPermanent},
+		{http.StatusBadRequest, model.ClassificationPermanent},
+		{http.StatusUnauthorized, model.ClassificationPermanent},
+		{http.StatusNotFound, model.ClassificationPermanent},
+		{http.StatusGone, model.ClassificationPermanent},
+		{http.StatusRequestTimeout, model.ClassificationRetryable},
+		{http.StatusTooEarly, model.ClassificationRetryable},
+		{http.StatusTooManyRequests, model.ClassificationRetryable},
+		{http.StatusInternalServerError, model.ClassificationRetryable},
+		{http.StatusBadGateway, model.ClassificationRetryable},
+		{http.StatusServiceUnavailable, model.ClassificationRetryable},
+		{599, model.ClassificationRetryable},
+		{600, model.ClassificationPermanent},
+	} {
+		attempt := &model.Attempt{StatusCode: tc.code}
+		if tc.code < 200 || tc.code >= 300 {
+			attempt.ErrorClass = statusErrorClass(tc.code)
+		}
+		if got := classify(attempt); got != tc.want {
+			t.Errorf("status %d (%s): classified %s, want %s", tc.code, attempt.ErrorClass, got, tc.want)
+		}
+	}
+}
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestClassifyTransportErrors(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		err   error
+		class model.ErrorClass
+		want  model.Classification
+	}{
+		{"deadline", fmt.Errorf("do: %w", context.DeadlineExceeded), model.ErrorClassTimeout, model.ClassificationRetryable},
+		{"net timeout", &net.OpError{Op: "read", Err: timeoutError{}}, model.ErrorClassTimeout, model.ClassificationRetryable},
+		{"refused", &net.OpError{Op: "dial", Err: os.NewSyscallError("connect", errors.New("connection refused"))}, model.ErrorClassNetwork, model.ClassificationRetryable},
+		{"dns", &net.DNSError{Err: "no such host", Name: "example.invalid", IsNotFound: true}, model.ErrorClassNetwork, model.ClassificationRetryable},
+		{"blocked", fmt.Errorf("dial: %w: 10.0.0.1 is a private or reserved address", ssrf.ErrBlocked), model.ErrorClassBlocked, model.ClassificationPermanent},
+	} {
+		class := transportErrorClass(tc.err)
+		if class != tc.class {
+			t.Errorf("%s: error class %s, want %s", tc.name, class, tc.class)
+		}
+		if got := classify(&model.Attempt{ErrorClass: class}); got != tc.want {
+			t.Errorf("%s: classified %s, want %s", tc.name, got, tc.want)
+		}
+	}
+
+	for _, class := range []model.ErrorClass{model.ErrorClassTLSConfig, model.ErrorClassBlobStore} {
+		if got := classify(&model.Attempt{ErrorClass: class}); got != model.ClassificationRetryable {
+			t.Errorf("%s: classified %s, want %s", class, got, model.ClassificationRetryable)
+		}
+	}
+	if got := classify(&model.Attempt{ErrorClass: model.ErrorClassRequest}); got != model.ClassificationPermanent {
+		t.Errorf("%s: classified %s, want %s", model.ErrorClassRequest, got, model.ClassificationPermanent)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)
+	for _, tc := range []struct {
+		name  string
+		value string
+		max   time.Duration
+		want  time.Duration
+	}{
+		{"absent", "", time.Minute, 0},
+		{"seconds", "30", time.Minute, 30 * time.Second},
+		{"seconds with spaces", " 5 ", time.Minute, 5 * time.Second},
+		{"zero seconds", "0", time.Minute, 0},
+		{"negative seconds", "-5", time.Minute, 0},
+		{"seconds over max", "3600", time.Minute, time.Minute},
+		{"seconds without max", "3600", 0, time.Hour},
+		{"http date", now.Add(45 * time.Second).Format(http.TimeFormat), time.Minute, 45 * time.Second},
+		{"http date over max", now.Add(time.Hour).Format(http.TimeFormat), time.Minute, time.Minute},
+		{"past http date", now.Add(-time.Minute).Format(http.TimeFormat), time.Minute, 0},
+		{"http date now", now.Format(http.TimeFormat), time.Minute, 0},
+		{"invalid", "soon", time.Minute, 0},
+		{"fractional seconds", "1.5", time.Minute, 0},
+	} {
+		h := http.Header{}
+		if tc.value != "" {
+			h.Set("Retry-After", tc.value)
+		}
+		if got := retryAfter(h, now, tc.max); got != tc.want {
+			t.Errorf("%s: retryAfter(%q) = %v, want %v", tc.name, tc.value, got, tc.want)
+		}
+	}
+}
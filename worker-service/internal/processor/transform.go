@@ -0,0 +1,61 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/Bharat1Rajput/blob"
+	"github.com/Bharat1Rajput/workerService/internal/model"
+)
+
+// transformJob returns the job to deliver: the job itself when its endpoint
+// has no transformation, otherwise a copy carrying the transformed body
+// inline. Offloaded payloads are read and verified in full first, since a
+// template needs the whole document.
+func (p *Processor) transformJob(ctx context.Context, job *model.WebhookJob) (*model.WebhookJob, error) {
+	t := p.endpoints.Match(job.ClientURL).Transformer()
+	if t == nil {
+		return job, nil
+	}
+	if !model.IsJSONContentType(job.MediaType()) {
+		return nil, fmt.Errorf("processor: transform requires a JSON payload, got %q", job.MediaType())
+	}
+
+	payload, err := p.loadPayload(ctx, job)
+	if err != nil {
+		return nil, err
+	}
+	body, err := t.Apply(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	out := *job
+	out.Body = body
+	out.Payload = ""
+	out.PayloadRef = nil
+	out.ContentType = t.ContentType()
+	return &out, nil
+}
+
+func (p *Processor) loadPayload(ctx context.Context, job *model.WebhookJob) ([]byte, error) {
+	if job.PayloadRef == nil {
+		return job.Content(), nil
+	}
+	if p.blobs == nil {
+		return nil, fmt.Errorf("processor: payload is offloaded but no blob store is configured")
+	}
+
+	rc, err := p.blobs.Get(ctx, job.PayloadRef.Key)
+	if err != nil {
+		return nil, fmt.Errorf("processor: open payload blob: %w", blobError(err))
+	}
+	defer rc.Close()
+
+	payload, err := io.ReadAll(blob.Verify(rc, *job.PayloadRef))
+	if err != nil {
+		return nil, fmt.Errorf("processor: read payload blob: %w", blobError(err))
+	}
+	return payload, nil
+}
@@ -0,0 +1,260 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Bharat1Rajput/jobstore"
+	"github.com/Bharat1Rajput/workerService/internal/model"
+)
+
+var (
+	ErrNotFound     = errors.New("repository: job not found")
+	ErrNotRetryable = jobstore.ErrNotRetryable
+)
+
+// JobFilter narrows job listings. Zero fields match everything.
+type JobFilter struct {
+	Status    model.JobStatus
+	TenantID  string
+	URLPrefix string
+	Error     string
+	Since     time.Time
+	Until     time.Time
+	Limit     int
+}
+
+// AdminRepository backs operator tooling such as dispatchctl.
+type AdminRepository interface {
+	ListJobs(ctx context.Context, filter JobFilter) ([]model.WebhookJob, error)
+	GetJob(ctx context.Context, id string) (*model.WebhookJob, error)
+	ListAttempts(ctx context.Context, jobID string) ([]model.Attempt, error)
+	// ResetForRetry resets a failed, expired or cancelled job as described
+	// at jobstore.ResetForRetry and hands it to publish.
+	ResetForRetry(ctx context.Context, id string, publish func(*model.WebhookJob) error) (*model.WebhookJob, error)
+	// Cancel marks a pending or processing job cancelled so the worker skips
+	// it, and reports whether it did. A job still in the queue has no row
+	// if it was submitted to an API without a database, and is then
+	// ErrNotFound unless queued is set: then a placeholder row is recorded
+	// for it instead.
+	Cancel(ctx context.Context, id string, queued bool) (bool, error)
+	// ResendCallback marks a finished job's status callback pending again,
+	// as due now, for the workers to send on their next sweep. It reports
+	// whether the job is finished and asked for a callback.
+	ResendCallback(ctx context.Context, id string) (bool, error)
+}
+
+func (r *PostgresJobRepository) ListJobs(ctx context.Context, filter JobFilter) ([]model.WebhookJob, error) {
+	var (
+		where []string
+		args  []any
+	)
+	add := func(cond string, arg any) {
+		args = append(args, arg)
+		where = append(where, fmt.Sprintf(cond, len(args)))
+	}
+	if filter.Status != "" {
+		add("status = $%d", filter.Status)
+	}
+	if filter.TenantID != "" {
+		add("tenant_id = $%d", filter.TenantID)
+	}
+	if filter.URLPrefix != "" {
+		add("starts_with(client_url, $%d)", filter.URLPrefix)
+	}
+	if filter.Error != "" {
+		add(`error ILIKE '%%' || $%d || '%%'`, likeEscape(filter.Error))
+	}
+	if !filter.Since.IsZero() {
+		add("created_at >= $%d", filter.Since)
+	}
+	if !filter.Until.IsZero() {
+		add("created_at < $%d", filter.Until)
+	}
+
+	query := "SELECT " + jobColumns + " FROM webhook_jobs"
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = 50
+	}
+	args = append(args, limit)
+	query += fmt.Sprintf(" ORDER BY created_at DESC, id DESC LIMIT $%d", len(args))
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("repository.job: list jobs: %w", err)
+	}
+	defer rows.Close()
+
+	var jobs []model.WebhookJob
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("repository.job: list jobs: %w", err)
+	}
+	return jobs, nil
+}
+
+func (r *PostgresJobRepository) GetJob(ctx context.Context, id string) (*model.WebhookJob, error) {
+	query := "SELECT " + jobColumns + " FROM webhook_jobs WHERE id = $1"
+	job, err := scanJob(r.db.QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+func (r *PostgresJobRepository) ListAttempts(ctx context.Context, jobID string) ([]model.Attempt, error) {
+	const query = `
+		SELECT attempt, started_at, duration_ms, status_code,
+		       response_headers, response_body, error, error_class
+		FROM webhook_attempts
+		WHERE job_id = $1
+		ORDER BY attempt
+	`
+	rows, err := r.db.QueryContext(ctx, query, jobID)
+	if err != nil {
+		return nil, fmt.Errorf("repository.job: list attempts: %w", err)
+	}
+	defer rows.Close()
+
+	var attempts []model.Attempt
+	for rows.Next() {
+		var (
+			a          model.Attempt
+			durationMS int64
+			headers    []byte
+		)
+		if err := rows.Scan(
+			&a.Number,
+			&a.StartedAt,
+			&durationMS,
+			&a.StatusCode,
+			&headers,
+			&a.ResponseBody,
+			&a.Error,
+			&a.ErrorClass,
+		); err != nil {
+			return nil, fmt.Errorf("repository.job: scan attempt: %w", err)
+		}
+		a.JobID = jobID
+		a.Duration = time.Duration(durationMS) * time.Millisecond
+		if err := json.Unmarshal(headers, &a.ResponseHeaders); err != nil {
+			return nil, fmt.Errorf("repository.job: decode attempt headers: %w", err)
+		}
+		attempts = append(attempts, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("repository.job: list attempts: %w", err)
+	}
+	return attempts, nil
+}
+
+func (r *PostgresJobRepository) ResetForRetry(ctx context.Context, id string, publish func(*model.WebhookJob) error) (*model.WebhookJob, error) {
+	var job model.WebhookJob
+	err := jobstore.ResetForRetry(ctx, r.db, jobstore.Postgres, id, func(tx jobstore.Querier) error {
+		var err error
+		job, err = scanJob(tx.QueryRowContext(ctx, "SELECT "+jobColumns+" FROM webhook_jobs WHERE id = $1", id))
+		if err != nil {
+			return err
+		}
+		return publish(&job)
+	})
+	if errors.Is(err, jobstore.ErrNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+func (r *PostgresJobRepository) Cancel(ctx context.Context, id string, queued bool) (bool, error) {
+	const (
+		update = `
+			UPDATE webhook_jobs
+			SET status = $1, error = 'cancelled by operator', updated_at = $2
+			WHERE id = $3 AND status IN ($4, $5)
+		`
+		insert = `
+			INSERT INTO webhook_jobs (id, payload, client_url, status, error, created_at, updated_at)
+			VALUES ($3, '', '', $1, 'cancelled before delivery', $2, $2)
+			ON CONFLICT (id) DO UPDATE
+			SET status = EXCLUDED.status,
+			    error = 'cancelled by operator',
+			    updated_at = EXCLUDED.updated_at
+			WHERE webhook_jobs.status IN ($4, $5)
+		`
+	)
+	query := update
+	if queued {
+		query = insert
+	}
+	res, err := r.db.ExecContext(
+		ctx,
+		query,
+		model.StatusCancelled,
+		time.Now().UTC(),
+		id,
+		model.StatusPending,
+		model.StatusProcessing,
+	)
+	if err != nil {
+		return false, fmt.Errorf("repository.job: cancel: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("repository.job: cancel: %w", err)
+	}
+	if n == 0 && !queued {
+		if _, err := r.GetJob(ctx, id); err != nil {
+			return false, err
+		}
+	}
+	return n > 0, nil
+}
+
+func (r *PostgresJobRepository) ResendCallback(ctx context.Context, id string) (bool, error) {
+	const query = `
+		UPDATE webhook_jobs
+		SET callback_status = 'pending', callback_queued_at = $2
+		WHERE id = $1
+		  AND status_callback_url <> ''
+		  AND status IN ('success', 'failed', 'expired', 'cancelled')
+	`
+	res, err := r.db.ExecContext(ctx, query, id, time.Unix(0, 0).UTC())
+	if err != nil {
+		return false, fmt.Errorf("repository.job: resend callback: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("repository.job: resend callback: %w", err)
+	}
+	if n == 0 {
+		if _, err := r.GetJob(ctx, id); err != nil {
+			return false, err
+		}
+	}
+	return n > 0, nil
+}
+
+// likeEscape escapes LIKE wildcards so s matches literally.
+func likeEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
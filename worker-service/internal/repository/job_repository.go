@@ -3,6 +3,8 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
@@ -10,10 +12,39 @@ import (
 )
 
 type JobRepository interface {
-	UpsertProcessing(ctx context.Context, job *model.WebhookJob) error
+	// UpsertProcessing records the job as processing and reports whether
+	// this delivery claimed it. A job is claimed when it is new, pending,
+	// or was claimed more than lease ago by a worker that then stopped.
+	UpsertProcessing(ctx context.Context, job *model.WebhookJob, lease time.Duration) (bool, error)
 	MarkSuccess(ctx context.Context, id string) error
-	MarkFailed(ctx context.Context, id string, errMsg string) error
-	IncrementRetry(ctx context.Context, id string) (int, error)
+	MarkFailed(ctx context.Context, id string, errMsg string, class model.Classification) error
+	MarkExpired(ctx context.Context, id string, errMsg string) error
+	IncrementRetry(ctx context.Context, id string, errMsg string, class model.Classification) (int, error)
+	// ScheduleRetry releases a claimed job back to pending and records when
+	// it is next due; NextAttempt returns it, or the zero time if no retry
+	// is scheduled.
+	ScheduleRetry(ctx context.Context, id string, at time.Time) error
+	NextAttempt(ctx context.Context, id string) (time.Time, error)
+	RecordAttempt(ctx context.Context, attempt *model.Attempt) error
+	Status(ctx context.Context, id string) (model.JobStatus, error)
+	// Preceding reports whether a job created before job, with the same
+	// ordering key and client_url, is not finished yet, and when that job
+	// is next due (the zero time if it is due now).
+	Preceding(ctx context.Context, job *model.WebhookJob) (time.Time, bool, error)
+
+	// MarkSuccess, MarkFailed and MarkExpired record the job's status
+	// callback, if it asked for one, as pending in the same statement as
+	// the status, so that a worker stopping right after cannot lose it.
+	// QueueCallback does so for a job found cancelled, whose callback goes
+	// to url.
+	QueueCallback(ctx context.Context, id string, url string) error
+	// ClaimCallbacks returns up to limit finished jobs whose status callback
+	// has been pending since before stale, and records them as queued now
+	// so that other workers leave them alone.
+	ClaimCallbacks(ctx context.Context, stale time.Time, limit int) ([]model.PendingCallback, error)
+	// FinishCallback records how a job's pending status callback ended,
+	// after attempts requests.
+	FinishCallback(ctx context.Context, id string, status model.CallbackStatus, attempts int) error
 }
 
 type PostgresJobRepository struct {
@@ -24,29 +55,104 @@ func NewPostgresJobRepository(db *sql.DB) *PostgresJobRepository {
 	return &PostgresJobRepository{db: db}
 }
 
-func (r *PostgresJobRepository) UpsertProcessing(ctx context.Context, job *model.WebhookJob) error {
+func (r *PostgresJobRepository) UpsertProcessing(ctx context.Context, job *model.WebhookJob, lease time.Duration) (bool, error) {
 	const query = `
 		INSERT INTO webhook_jobs (
-			id, payload, client_url, status, error, retry_count, created_at, updated_at
-		) VALUES ($1,$2,$3,$4,$5,$6,$7,$8)
-		ON CONFLICT (id) DO NOTHING
+			id, tenant_id, event_type, schema_version, payload, payload_bytes,
+			content_type, payload_ref, client_url, status, error, retry_count, retry_policy,
+			ordering_key, priority, expires_at, status_callback_url, created_at, updated_at
+		) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15,$16,$17,$18,$19)
+		ON CONFLICT (id) DO UPDATE
+		SET status = EXCLUDED.status,
+		    updated_at = EXCLUDED.updated_at
+		WHERE webhook_jobs.status = 'pending'
+		   OR (webhook_jobs.status = 'processing' AND webhook_jobs.updated_at < $20)
 	`
-	_, err := r.db.ExecContext(
+	payloadRef, err := marshalNullable(job.PayloadRef)
+	if err != nil {
+		return false, fmt.Errorf("repository.job: marshal payload ref: %w", err)
+	}
+
+	// The body is kept verbatim as BYTEA whatever its type, and delivered
+	// and retried from there. Legacy string payloads stay in the TEXT column.
+	now := time.Now().UTC()
+	res, err := r.db.ExecContext(
 		ctx,
 		query,
 		job.ID,
+		job.TenantID,
+		job.EventType,
+		job.SchemaVersion,
 		job.Payload,
+		job.Body,
+		job.MediaType(),
+		payloadRef,
 		job.ClientURL,
 		model.StatusProcessing,
 		"",
 		job.RetryCount,
-		time.Now().UTC(),
-		time.Now().UTC(),
+		job.RetryPolicy,
+		job.OrderingKey,
+		job.Priority,
+		job.ExpiresAt,
+		job.StatusCallbackURL,
+		createdAt(job, now),
+		now,
+		now.Add(-lease),
 	)
 	if err != nil {
-		return fmt.Errorf("repository.job: upsert processing: %w", err)
+		return false, fmt.Errorf("repository.job: upsert processing: %w", err)
 	}
-	return nil
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("repository.job: upsert processing: %w", err)
+	}
+	if n > 0 {
+		r.copyPayloadJSON(ctx, job)
+	}
+	return n > 0, nil
+}
+
+func (r *PostgresJobRepository) Preceding(ctx context.Context, job *model.WebhookJob) (time.Time, bool, error) {
+	const query = `
+		SELECT next_attempt_at
+		FROM webhook_jobs
+		WHERE client_url = $1 AND ordering_key = $2
+		  AND status IN ('pending', 'processing')
+		  AND (created_at, id) < ($3, $4)
+		ORDER BY created_at, id
+		LIMIT 1
+	`
+	var due sql.NullTime
+	err := r.db.QueryRowContext(ctx, query, job.ClientURL, job.OrderingKey, job.CreatedAt, job.ID).Scan(&due)
+	if errors.Is(err, sql.ErrNoRows) {
+		return time.Time{}, false, nil
+	}
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("repository.job: preceding: %w", err)
+	}
+	return due.Time, true, nil
+}
+
+// createdAt is when job was submitted, or now for messages that do not say.
+func createdAt(job *model.WebhookJob, now time.Time) time.Time {
+	if job.CreatedAt.IsZero() {
+		return now
+	}
+	return job.CreatedAt.UTC()
+}
+
+// copyPayloadJSON stores a JSON body in the JSONB column as well, so it can
+// be queried. JSONB normalises the document and refuses some valid JSON,
+// such as \u0000, so the copy is best-effort: it is never read back as the
+// body, and a failure leaves the column NULL without failing the claim.
+func (r *PostgresJobRepository) copyPayloadJSON(ctx context.Context, job *model.WebhookJob) {
+	body := payloadJSON(job)
+	if body == nil {
+		return
+	}
+	const query = `UPDATE webhook_jobs SET payload_json = $2::jsonb WHERE id = $1 AND payload_json IS NULL`
+	_, _ = r.db.ExecContext(ctx, query, job.ID, string(body))
 }
 
 func (r *PostgresJobRepository) MarkSuccess(ctx context.Context, id string) error {
@@ -54,43 +160,298 @@ func (r *PostgresJobRepository) MarkSuccess(ctx context.Context, id string) erro
 		UPDATE webhook_jobs
 		SET status = $1,
 		    error = '',
-		    updated_at = $2
-		WHERE id = $3
+		    classification = $2,
+		    updated_at = $3,
+		    callback_status = CASE WHEN status_callback_url = '' THEN '' ELSE 'pending' END,
+		    callback_queued_at = $3
+		WHERE id = $4
 	`
-	_, err := r.db.ExecContext(ctx, query, model.StatusSuccess, time.Now().UTC(), id)
+	_, err := r.db.ExecContext(ctx, query, model.StatusSuccess, model.ClassificationSuccess, time.Now().UTC(), id)
 	if err != nil {
 		return fmt.Errorf("repository.job: mark success: %w", err)
 	}
 	return nil
 }
 
-func (r *PostgresJobRepository) MarkFailed(ctx context.Context, id string, errMsg string) error {
+func (r *PostgresJobRepository) MarkFailed(ctx context.Context, id string, errMsg string, class model.Classification) error {
 	const query = `
 		UPDATE webhook_jobs
 		SET status = $1,
 		    error = $2,
-		    updated_at = $3
-		WHERE id = $4
+		    classification = $3,
+		    updated_at = $4,
+		    callback_status = CASE WHEN status_callback_url = '' THEN '' ELSE 'pending' END,
+		    callback_queued_at = $4
+		WHERE id = $5
 	`
-	_, err := r.db.ExecContext(ctx, query, model.StatusFailed, errMsg, time.Now().UTC(), id)
+	_, err := r.db.ExecContext(ctx, query, model.StatusFailed, errMsg, class, time.Now().UTC(), id)
 	if err != nil {
 		return fmt.Errorf("repository.job: mark failed: %w", err)
 	}
 	return nil
 }
 
-func (r *PostgresJobRepository) IncrementRetry(ctx context.Context, id string) (int, error) {
+func (r *PostgresJobRepository) MarkExpired(ctx context.Context, id string, errMsg string) error {
+	const query = `
+		UPDATE webhook_jobs
+		SET status = $1,
+		    error = $2,
+		    updated_at = $3,
+		    callback_status = CASE WHEN status_callback_url = '' THEN '' ELSE 'pending' END,
+		    callback_queued_at = $3
+		WHERE id = $4
+	`
+	_, err := r.db.ExecContext(ctx, query, model.StatusExpired, errMsg, time.Now().UTC(), id)
+	if err != nil {
+		return fmt.Errorf("repository.job: mark expired: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresJobRepository) QueueCallback(ctx context.Context, id string, url string) error {
+	const query = `
+		UPDATE webhook_jobs
+		SET status_callback_url = $2,
+		    callback_status = 'pending',
+		    callback_queued_at = $3
+		WHERE id = $1
+	`
+	if _, err := r.db.ExecContext(ctx, query, id, url, time.Now().UTC()); err != nil {
+		return fmt.Errorf("repository.job: queue callback: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresJobRepository) ClaimCallbacks(ctx context.Context, stale time.Time, limit int) ([]model.PendingCallback, error) {
+	const query = `
+		UPDATE webhook_jobs j
+		SET callback_queued_at = $1
+		WHERE j.id IN (
+			SELECT id
+			FROM webhook_jobs
+			WHERE callback_status = 'pending'
+			  AND callback_queued_at < $2
+			  AND status IN ('success', 'failed', 'expired', 'cancelled')
+			ORDER BY callback_queued_at
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + callbackColumns
+	rows, err := r.db.QueryContext(ctx, query, time.Now().UTC(), stale.UTC(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("repository.job: claim callbacks: %w", err)
+	}
+	defer rows.Close()
+	return scanCallbacks(rows)
+}
+
+func (r *PostgresJobRepository) FinishCallback(ctx context.Context, id string, status model.CallbackStatus, attempts int) error {
+	const query = `
+		UPDATE webhook_jobs
+		SET callback_status = $2,
+		    callback_attempts = callback_attempts + $3
+		WHERE id = $1 AND callback_status = 'pending'
+	`
+	if _, err := r.db.ExecContext(ctx, query, id, status, attempts); err != nil {
+		return fmt.Errorf("repository.job: finish callback: %w", err)
+	}
+	return nil
+}
+
+// callbackColumns is the column list scanCallbacks expects, for rows of
+// webhook_jobs aliased j.
+const callbackColumns = `j.id, j.tenant_id, j.event_type, j.status, j.classification, j.error,
+	j.status_callback_url, j.updated_at,
+	(SELECT COUNT(*) FROM webhook_attempts a WHERE a.job_id = j.id)`
+
+func scanCallbacks(rows *sql.Rows) ([]model.PendingCallback, error) {
+	var callbacks []model.PendingCallback
+	for rows.Next() {
+		c := model.PendingCallback{Job: &model.WebhookJob{}}
+		if err := rows.Scan(
+			&c.Job.ID,
+			&c.Job.TenantID,
+			&c.Job.EventType,
+			&c.Job.Status,
+			&c.Job.Classification,
+			&c.Job.Error,
+			&c.Job.StatusCallbackURL,
+			&c.Job.UpdatedAt,
+			&c.Attempts,
+		); err != nil {
+			return nil, fmt.Errorf("repository.job: scan callback: %w", err)
+		}
+		callbacks = append(callbacks, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("repository.job: scan callbacks: %w", err)
+	}
+	return callbacks, nil
+}
+
+func (r *PostgresJobRepository) IncrementRetry(ctx context.Context, id string, errMsg string, class model.Classification) (int, error) {
 	const query = `
 		UPDATE webhook_jobs
 		SET retry_count = retry_count + 1,
-		    updated_at = $1
-		WHERE id = $2
+		    error = $1,
+		    classification = $2,
+		    updated_at = $3
+		WHERE id = $4
 		RETURNING retry_count
 	`
 	var retryCount int
-	if err := r.db.QueryRowContext(ctx, query, time.Now().UTC(), id).Scan(&retryCount); err != nil {
+	if err := r.db.QueryRowContext(ctx, query, errMsg, class, time.Now().UTC(), id).Scan(&retryCount); err != nil {
 		return 0, fmt.Errorf("repository.job: increment retry: %w", err)
 	}
 	return retryCount, nil
 }
 
+func (r *PostgresJobRepository) ScheduleRetry(ctx context.Context, id string, at time.Time) error {
+	const query = `
+		UPDATE webhook_jobs
+		SET status = $1, next_attempt_at = $2, updated_at = $3
+		WHERE id = $4 AND status = $5
+	`
+	_, err := r.db.ExecContext(ctx, query, model.StatusPending, at.UTC(), time.Now().UTC(), id, model.StatusProcessing)
+	if err != nil {
+		return fmt.Errorf("repository.job: schedule retry: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresJobRepository) NextAttempt(ctx context.Context, id string) (time.Time, error) {
+	var at sql.NullTime
+	err := r.db.QueryRowContext(ctx, `SELECT next_attempt_at FROM webhook_jobs WHERE id = $1`, id).Scan(&at)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("repository.job: next attempt: %w", err)
+	}
+	return at.Time, nil
+}
+
+func (r *PostgresJobRepository) Status(ctx context.Context, id string) (model.JobStatus, error) {
+	var status model.JobStatus
+	err := r.db.QueryRowContext(ctx, `SELECT status FROM webhook_jobs WHERE id = $1`, id).Scan(&status)
+	if err != nil {
+		return "", fmt.Errorf("repository.job: status: %w", err)
+	}
+	return status, nil
+}
+
+func (r *PostgresJobRepository) RecordAttempt(ctx context.Context, attempt *model.Attempt) error {
+	const query = `
+		INSERT INTO webhook_attempts (
+			job_id, attempt, started_at, duration_ms, status_code,
+			response_headers, response_body, error, error_class
+		)
+		SELECT $1, GREATEST(COALESCE(MAX(attempt), 0), $9) + 1, $2, $3, $4, $5, $6, $7, $8
+		FROM webhook_attempts
+		WHERE job_id = $1
+		RETURNING attempt
+	`
+	headers, err := json.Marshal(attempt.ResponseHeaders)
+	if err != nil {
+		return fmt.Errorf("repository.job: marshal attempt headers: %w", err)
+	}
+
+	if err := r.db.QueryRowContext(
+		ctx,
+		query,
+		attempt.JobID,
+		attempt.StartedAt,
+		attempt.Duration.Milliseconds(),
+		attempt.StatusCode,
+		headers,
+		attempt.ResponseBody,
+		attempt.Error,
+		attempt.ErrorClass,
+		attempt.Number,
+	).Scan(&attempt.Number); err != nil {
+		return fmt.Errorf("repository.job: record attempt: %w", err)
+	}
+	return nil
+}
+
+// jobColumns is the column list scanJob expects.
+const jobColumns = `id, tenant_id, event_type, schema_version, payload, payload_json::text, payload_bytes,
+	content_type, payload_ref, client_url, status, error, retry_count, classification, retry_policy,
+	ordering_key, priority, expires_at, status_callback_url, created_at, updated_at`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanJob reads a row selected with jobColumns. Scan errors are returned
+// unwrapped so callers can test for sql.ErrNoRows.
+func scanJob(s rowScanner) (model.WebhookJob, error) {
+	var (
+		job          model.WebhookJob
+		payloadJSON  []byte
+		payloadBytes []byte
+		payloadRef   []byte
+	)
+	if err := s.Scan(
+		&job.ID,
+		&job.TenantID,
+		&job.EventType,
+		&job.SchemaVersion,
+		&job.Payload,
+		&payloadJSON,
+		&payloadBytes,
+		&job.ContentType,
+		&payloadRef,
+		&job.ClientURL,
+		&job.Status,
+		&job.Error,
+		&job.RetryCount,
+		&job.Classification,
+		&job.RetryPolicy,
+		&job.OrderingKey,
+		&job.Priority,
+		&job.ExpiresAt,
+		&job.StatusCallbackURL,
+		&job.CreatedAt,
+		&job.UpdatedAt,
+	); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return job, err
+		}
+		return job, fmt.Errorf("repository.job: scan job: %w", err)
+	}
+	if payloadRef != nil {
+		if err := json.Unmarshal(payloadRef, &job.PayloadRef); err != nil {
+			return job, fmt.Errorf("repository.job: decode payload ref: %w", err)
+		}
+	}
+	// Rows written before bodies were always kept as BYTEA have JSON
+	// bodies only in the JSONB column.
+	if payloadBytes != nil {
+		job.Body = payloadBytes
+	} else {
+		job.Body = payloadJSON
+	}
+	return job, nil
+}
+
+// payloadJSON returns the job's body if it is JSON, for the JSONB column.
+func payloadJSON(job *model.WebhookJob) []byte {
+	if job.Body != nil && model.IsJSONContentType(job.MediaType()) && json.Valid(job.Body) {
+		return job.Body
+	}
+	return nil
+}
+
+// marshalNullable encodes v as JSON for a nullable JSONB column.
+func marshalNullable[T any](v *T) ([]byte, error) {
+	if v == nil {
+		return nil, nil
+	}
+	return json.Marshal(v)
+}
+
+// nullableText passes JSON bytes to a JSONB column as text, keeping nil as NULL.
+func nullableText(b []byte) any {
+	if b == nil {
+		return nil
+	}
+	return string(b)
+}
@@ -0,0 +1,96 @@
+//go:build integration
+
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	_ "github.com/lib/pq"
+	"go.uber.org/zap"
+
+	"github.com/Bharat1Rajput/contract"
+	"github.com/Bharat1Rajput/workerService/internal/migrate"
+	"github.com/Bharat1Rajput/workerService/internal/model"
+	"github.com/Bharat1Rajput/workerService/migrations"
+)
+
+// The integration tests run against a real Postgres database, to which they
+// apply worker-service's migrations, so use a scratch one:
+//
+//	REPOSITORY_TEST_POSTGRES_URL=postgres://... go test -tags integration ./internal/repository
+
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	url := os.Getenv("REPOSITORY_TEST_POSTGRES_URL")
+	if url == "" {
+		t.Skip("REPOSITORY_TEST_POSTGRES_URL not set")
+	}
+	db, err := sql.Open("postgres", url)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	runner, err := migrate.New(db, migrations.FS, zap.NewNop())
+	if err != nil {
+		t.Fatalf("load migrations: %v", err)
+	}
+	if err := runner.Up(context.Background()); err != nil {
+		t.Fatalf("apply migrations: %v", err)
+	}
+	return db
+}
+
+func TestPayloadRoundTripsExactly(t *testing.T) {
+	db := openTestDB(t)
+	repo := NewPostgresJobRepository(db)
+	ctx := context.Background()
+
+	for _, tc := range []struct {
+		name   string
+		body   string
+		copied bool
+	}{
+		{"reordered keys", `{ "b": 1,  "a": {"z": true, "y": null}, "b": 2 }`, true},
+		{"nul escape", `{"s": "a\u0000b"}`, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			now := time.Now().UTC()
+			job := &model.WebhookJob{Job: contract.Job{
+				ID:          fmt.Sprintf("roundtrip-%d", now.UnixNano()),
+				Body:        []byte(tc.body),
+				ContentType: model.ContentTypeJSON,
+				ClientURL:   "https://example.com/hook",
+				Status:      model.StatusPending,
+				CreatedAt:   now,
+				UpdatedAt:   now,
+			}}
+			claimed, err := repo.UpsertProcessing(ctx, job, time.Minute)
+			if err != nil || !claimed {
+				t.Fatalf("UpsertProcessing = %v, %v; want claimed", claimed, err)
+			}
+
+			got, err := repo.GetJob(ctx, job.ID)
+			if err != nil {
+				t.Fatalf("GetJob: %v", err)
+			}
+			if string(got.Body) != tc.body {
+				t.Errorf("body = %s, want %s", got.Body, tc.body)
+			}
+
+			var copied bool
+			if err := db.QueryRowContext(ctx,
+				`SELECT payload_json IS NOT NULL FROM webhook_jobs WHERE id = $1`, job.ID).Scan(&copied); err != nil {
+				t.Fatalf("read payload_json: %v", err)
+			}
+			if copied != tc.copied {
+				t.Errorf("payload_json set = %v, want %v", copied, tc.copied)
+			}
+		})
+	}
+}
@@ -0,0 +1,269 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Bharat1Rajput/workerService/internal/model"
+)
+
+// MemoryJobRepository keeps jobs and their attempts in memory, for tests and
+// for running without Postgres. It follows PostgresJobRepository's
+// semantics; nothing survives a restart.
+type MemoryJobRepository struct {
+	mu          sync.Mutex
+	jobs        map[string]*model.WebhookJob
+	attempts    map[string][]model.Attempt
+	nextAttempt map[string]time.Time
+	callbacks   map[string]*memoryCallback
+}
+
+type memoryCallback struct {
+	status   model.CallbackStatus
+	attempts int
+	queuedAt time.Time
+}
+
+func NewMemoryJobRepository() *MemoryJobRepository {
+	return &MemoryJobRepository{
+		jobs:        make(map[string]*model.WebhookJob),
+		attempts:    make(map[string][]model.Attempt),
+		nextAttempt: make(map[string]time.Time),
+		callbacks:   make(map[string]*memoryCallback),
+	}
+}
+
+func (r *MemoryJobRepository) UpsertProcessing(_ context.Context, job *model.WebhookJob, lease time.Duration) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now().UTC()
+	if existing, ok := r.jobs[job.ID]; ok {
+		stale := existing.Status == model.StatusProcessing && existing.UpdatedAt.Before(now.Add(-lease))
+		if existing.Status != model.StatusPending && !stale {
+			return false, nil
+		}
+		existing.Status = model.StatusProcessing
+		existing.UpdatedAt = now
+		return true, nil
+	}
+
+	stored := *job
+	stored.ContentType = job.MediaType()
+	stored.Status = model.StatusProcessing
+	stored.Error = ""
+	stored.Classification = ""
+	stored.CreatedAt = createdAt(job, now)
+	stored.UpdatedAt = now
+	r.jobs[job.ID] = &stored
+	return true, nil
+}
+
+func (r *MemoryJobRepository) MarkSuccess(_ context.Context, id string) error {
+	r.update(id, func(job *model.WebhookJob) {
+		job.Status = model.StatusSuccess
+		job.Error = ""
+		job.Classification = model.ClassificationSuccess
+		r.queueCallback(job)
+	})
+	return nil
+}
+
+func (r *MemoryJobRepository) MarkFailed(_ context.Context, id string, errMsg string, class model.Classification) error {
+	r.update(id, func(job *model.WebhookJob) {
+		job.Status = model.StatusFailed
+		job.Error = errMsg
+		job.Classification = class
+		r.queueCallback(job)
+	})
+	return nil
+}
+
+func (r *MemoryJobRepository) MarkExpired(_ context.Context, id string, errMsg string) error {
+	r.update(id, func(job *model.WebhookJob) {
+		job.Status = model.StatusExpired
+		job.Error = errMsg
+		r.queueCallback(job)
+	})
+	return nil
+}
+
+// queueCallback records job's status callback as pending, if it asked for
+// one. r.mu must be held.
+func (r *MemoryJobRepository) queueCallback(job *model.WebhookJob) {
+	if job.StatusCallbackURL == "" {
+		delete(r.callbacks, job.ID)
+		return
+	}
+	r.callbacks[job.ID] = &memoryCallback{status: model.CallbackPending, queuedAt: time.Now().UTC()}
+}
+
+func (r *MemoryJobRepository) QueueCallback(_ context.Context, id string, url string) error {
+	r.update(id, func(job *model.WebhookJob) {
+		job.StatusCallbackURL = url
+		r.queueCallback(job)
+	})
+	return nil
+}
+
+func (r *MemoryJobRepository) ClaimCallbacks(_ context.Context, stale time.Time, limit int) ([]model.PendingCallback, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var claimed []model.PendingCallback
+	for id, c := range r.callbacks {
+		if len(claimed) == limit {
+			break
+		}
+		job := r.jobs[id]
+		if c.status != model.CallbackPending || !c.queuedAt.Before(stale) {
+			continue
+		}
+		if job.Status == model.StatusPending || job.Status == model.StatusProcessing {
+			continue
+		}
+		c.queuedAt = time.Now().UTC()
+		copied := *job
+		claimed = append(claimed, model.PendingCallback{Job: &copied, Attempts: len(r.attempts[id])})
+	}
+	return claimed, nil
+}
+
+func (r *MemoryJobRepository) FinishCallback(_ context.Context, id string, status model.CallbackStatus, attempts int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if c, ok := r.callbacks[id]; ok && c.status == model.CallbackPending {
+		c.status = status
+		c.attempts += attempts
+	}
+	return nil
+}
+
+// Callback returns the state of job id's status callback and the number of
+// requests made for it.
+func (r *MemoryJobRepository) Callback(_ context.Context, id string) (model.CallbackStatus, int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	c, ok := r.callbacks[id]
+	if !ok {
+		return model.CallbackNone, 0
+	}
+	return c.status, c.attempts
+}
+
+func (r *MemoryJobRepository) IncrementRetry(_ context.Context, id string, errMsg string, class model.Classification) (int, error) {
+	var retryCount int
+	ok := r.update(id, func(job *model.WebhookJob) {
+		job.RetryCount++
+		job.Error = errMsg
+		job.Classification = class
+		retryCount = job.RetryCount
+	})
+	if !ok {
+		return 0, fmt.Errorf("repository.job: increment retry: %w", ErrNotFound)
+	}
+	return retryCount, nil
+}
+
+func (r *MemoryJobRepository) ScheduleRetry(_ context.Context, id string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job, ok := r.jobs[id]
+	if !ok {
+		return fmt.Errorf("repository.job: schedule retry: %w", ErrNotFound)
+	}
+	if job.Status == model.StatusProcessing {
+		job.Status = model.StatusPending
+		job.UpdatedAt = time.Now().UTC()
+		r.nextAttempt[id] = at
+	}
+	return nil
+}
+
+func (r *MemoryJobRepository) NextAttempt(_ context.Context, id string) (time.Time, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.jobs[id]; !ok {
+		return time.Time{}, fmt.Errorf("repository.job: next attempt: %w", ErrNotFound)
+	}
+	return r.nextAttempt[id], nil
+}
+
+func (r *MemoryJobRepository) Status(_ context.Context, id string) (model.JobStatus, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job, ok := r.jobs[id]
+	if !ok {
+		return "", fmt.Errorf("repository.job: status: %w", ErrNotFound)
+	}
+	return job.Status, nil
+}
+
+func (r *MemoryJobRepository) Preceding(_ context.Context, job *model.WebhookJob) (time.Time, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var first *model.WebhookJob
+	for _, j := range r.jobs {
+		if j.ClientURL != job.ClientURL || j.OrderingKey != job.OrderingKey {
+			continue
+		}
+		if j.Status != model.StatusPending && j.Status != model.StatusProcessing {
+			continue
+		}
+		if !before(j, job) || (first != nil && !before(j, first)) {
+			continue
+		}
+		first = j
+	}
+	if first == nil {
+		return time.Time{}, false, nil
+	}
+	return r.nextAttempt[first.ID], true, nil
+}
+
+// before orders jobs by creation time, then ID.
+func before(a, b *model.WebhookJob) bool {
+	if !a.CreatedAt.Equal(b.CreatedAt) {
+		return a.CreatedAt.Before(b.CreatedAt)
+	}
+	return a.ID < b.ID
+}
+
+func (r *MemoryJobRepository) RecordAttempt(_ context.Context, attempt *model.Attempt) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	attempt.Number = max(len(r.attempts[attempt.JobID]), attempt.Number) + 1
+	r.attempts[attempt.JobID] = append(r.attempts[attempt.JobID], *attempt)
+	return nil
+}
+
+func (r *MemoryJobRepository) GetJob(_ context.Context, id string) (*model.WebhookJob, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job, ok := r.jobs[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	copied := *job
+	return &copied, nil
+}
+
+func (r *MemoryJobRepository) ListAttempts(_ context.Context, jobID string) ([]model.Attempt, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]model.Attempt(nil), r.attempts[jobID]...), nil
+}
+
+// update applies fn to job id and stamps it, reporting whether it exists.
+func (r *MemoryJobRepository) update(id string, fn func(*model.WebhookJob)) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job, ok := r.jobs[id]
+	if !ok {
+		return false
+	}
+	fn(job)
+	job.UpdatedAt = time.Now().UTC()
+	return true
+}
@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/Bharat1Rajput/workerService/internal/model"
+)
+
+// PurgeFilter selects jobs in a terminal status last updated before Before.
+// An empty TenantID matches every tenant except those in ExcludeTenants.
+type PurgeFilter struct {
+	Status         model.JobStatus
+	Before         time.Time
+	TenantID       string
+	ExcludeTenants []string
+}
+
+type RetentionRepository interface {
+	// PurgeBatch deletes up to limit jobs matching filter, handing them to
+	// archive first, and returns the deleted jobs. If archive fails nothing
+	// is deleted.
+	PurgeBatch(ctx context.Context, filter PurgeFilter, limit int, archive func([]model.WebhookJob) error) ([]model.WebhookJob, error)
+}
+
+func (r *PostgresJobRepository) PurgeBatch(ctx context.Context, filter PurgeFilter, limit int, archive func([]model.WebhookJob) error) ([]model.WebhookJob, error) {
+	const selectQuery = `
+		SELECT ` + jobColumns + `
+		FROM webhook_jobs
+		WHERE status = $1
+		  AND updated_at < $2
+		  AND ($3 = '' OR tenant_id = $3)
+		  AND ($3 <> '' OR NOT (tenant_id = ANY($4)))
+		ORDER BY updated_at
+		LIMIT $5
+		FOR UPDATE SKIP LOCKED
+	`
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("repository.job: begin purge: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(
+		ctx,
+		selectQuery,
+		filter.Status,
+		filter.Before,
+		filter.TenantID,
+		pq.Array(filter.ExcludeTenants),
+		limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("repository.job: select purge batch: %w", err)
+	}
+
+	var (
+		jobs []model.WebhookJob
+		ids  []string
+	)
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		jobs = append(jobs, job)
+		ids = append(ids, job.ID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("repository.job: select purge batch: %w", err)
+	}
+	if len(jobs) == 0 {
+		return nil, nil
+	}
+
+	if archive != nil {
+		if err := archive(jobs); err != nil {
+			return nil, fmt.Errorf("repository.job: archive purge batch: %w", err)
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM webhook_jobs WHERE id = ANY($1)`, pq.Array(ids)); err != nil {
+		return nil, fmt.Errorf("repository.job: delete purge batch: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("repository.job: commit purge: %w", err)
+	}
+	return jobs, nil
+}
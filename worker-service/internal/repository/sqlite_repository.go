@@ -0,0 +1,282 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Bharat1Rajput/workerService/internal/model"
+)
+
+// SQLiteJobRepository is JobRepository on SQLite, for dispatchgo's
+// single-process mode. The tables come from migrations.SQLiteSchema.
+type SQLiteJobRepository struct {
+	db *sql.DB
+}
+
+func NewSQLiteJobRepository(db *sql.DB) *SQLiteJobRepository {
+	return &SQLiteJobRepository{db: db}
+}
+
+func (r *SQLiteJobRepository) UpsertProcessing(ctx context.Context, job *model.WebhookJob, lease time.Duration) (bool, error) {
+	const query = `
+		INSERT INTO webhook_jobs (
+			id, tenant_id, event_type, schema_version, payload, payload_json, payload_bytes,
+			content_type, payload_ref, client_url, status, error, retry_count, retry_policy,
+			ordering_key, priority, expires_at, status_callback_url, created_at, updated_at
+		) VALUES (?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?)
+		ON CONFLICT (id) DO UPDATE
+		SET status = excluded.status,
+		    updated_at = excluded.updated_at
+		WHERE webhook_jobs.status = 'pending'
+		   OR (webhook_jobs.status = 'processing' AND webhook_jobs.updated_at < ?)
+	`
+	payloadRef, err := marshalNullable(job.PayloadRef)
+	if err != nil {
+		return false, fmt.Errorf("repository.job: marshal payload ref: %w", err)
+	}
+
+	var expiresAt *time.Time
+	if job.ExpiresAt != nil {
+		t := job.ExpiresAt.UTC()
+		expiresAt = &t
+	}
+
+	now := time.Now().UTC()
+	res, err := r.db.ExecContext(
+		ctx,
+		query,
+		job.ID,
+		job.TenantID,
+		job.EventType,
+		job.SchemaVersion,
+		job.Payload,
+		nullableText(payloadJSON(job)),
+		job.Body,
+		job.MediaType(),
+		nullableText(payloadRef),
+		job.ClientURL,
+		model.StatusProcessing,
+		"",
+		job.RetryCount,
+		job.RetryPolicy,
+		job.OrderingKey,
+		job.Priority,
+		expiresAt,
+		job.StatusCallbackURL,
+		createdAt(job, now),
+		now,
+		now.Add(-lease),
+	)
+	if err != nil {
+		return false, fmt.Errorf("repository.job: upsert processing: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("repository.job: upsert processing: %w", err)
+	}
+	return n > 0, nil
+}
+
+func (r *SQLiteJobRepository) MarkSuccess(ctx context.Context, id string) error {
+	const query = `
+		UPDATE webhook_jobs
+		SET status = ?, error = '', classification = ?, updated_at = ?,
+		    callback_status = CASE WHEN status_callback_url = '' THEN '' ELSE 'pending' END,
+		    callback_queued_at = ?
+		WHERE id = ?
+	`
+	now := time.Now().UTC()
+	_, err := r.db.ExecContext(ctx, query, model.StatusSuccess, model.ClassificationSuccess, now, now, id)
+	if err != nil {
+		return fmt.Errorf("repository.job: mark success: %w", err)
+	}
+	return nil
+}
+
+func (r *SQLiteJobRepository) MarkFailed(ctx context.Context, id string, errMsg string, class model.Classification) error {
+	const query = `
+		UPDATE webhook_jobs
+		SET status = ?, error = ?, classification = ?, updated_at = ?,
+		    callback_status = CASE WHEN status_callback_url = '' THEN '' ELSE 'pending' END,
+		    callback_queued_at = ?
+		WHERE id = ?
+	`
+	now := time.Now().UTC()
+	_, err := r.db.ExecContext(ctx, query, model.StatusFailed, errMsg, class, now, now, id)
+	if err != nil {
+		return fmt.Errorf("repository.job: mark failed: %w", err)
+	}
+	return nil
+}
+
+func (r *SQLiteJobRepository) MarkExpired(ctx context.Context, id string, errMsg string) error {
+	const query = `
+		UPDATE webhook_jobs
+		SET status = ?, error = ?, updated_at = ?,
+		    callback_status = CASE WHEN status_callback_url = '' THEN '' ELSE 'pending' END,
+		    callback_queued_at = ?
+		WHERE id = ?
+	`
+	now := time.Now().UTC()
+	_, err := r.db.ExecContext(ctx, query, model.StatusExpired, errMsg, now, now, id)
+	if err != nil {
+		return fmt.Errorf("repository.job: mark expired: %w", err)
+	}
+	return nil
+}
+
+func (r *SQLiteJobRepository) QueueCallback(ctx context.Context, id string, url string) error {
+	const query = `
+		UPDATE webhook_jobs
+		SET status_callback_url = ?, callback_status = 'pending', callback_queued_at = ?
+		WHERE id = ?
+	`
+	if _, err := r.db.ExecContext(ctx, query, url, time.Now().UTC(), id); err != nil {
+		return fmt.Errorf("repository.job: queue callback: %w", err)
+	}
+	return nil
+}
+
+func (r *SQLiteJobRepository) ClaimCallbacks(ctx context.Context, stale time.Time, limit int) ([]model.PendingCallback, error) {
+	const query = `
+		UPDATE webhook_jobs
+		SET callback_queued_at = ?
+		WHERE id IN (
+			SELECT id
+			FROM webhook_jobs
+			WHERE callback_status = 'pending'
+			  AND callback_queued_at < ?
+			  AND status IN ('success', 'failed', 'expired', 'cancelled')
+			ORDER BY callback_queued_at
+			LIMIT ?
+		)
+		RETURNING id, tenant_id, event_type, status, classification, error,
+			status_callback_url, updated_at,
+			(SELECT COUNT(*) FROM webhook_attempts a WHERE a.job_id = webhook_jobs.id)
+	`
+	rows, err := r.db.QueryContext(ctx, query, time.Now().UTC(), stale.UTC(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("repository.job: claim callbacks: %w", err)
+	}
+	defer rows.Close()
+	return scanCallbacks(rows)
+}
+
+func (r *SQLiteJobRepository) FinishCallback(ctx context.Context, id string, status model.CallbackStatus, attempts int) error {
+	const query = `
+		UPDATE webhook_jobs
+		SET callback_status = ?, callback_attempts = callback_attempts + ?
+		WHERE id = ? AND callback_status = 'pending'
+	`
+	if _, err := r.db.ExecContext(ctx, query, status, attempts, id); err != nil {
+		return fmt.Errorf("repository.job: finish callback: %w", err)
+	}
+	return nil
+}
+
+func (r *SQLiteJobRepository) IncrementRetry(ctx context.Context, id string, errMsg string, class model.Classification) (int, error) {
+	const query = `
+		UPDATE webhook_jobs
+		SET retry_count = retry_count + 1,
+		    error = ?,
+		    classification = ?,
+		    updated_at = ?
+		WHERE id = ?
+		RETURNING retry_count
+	`
+	var retryCount int
+	if err := r.db.QueryRowContext(ctx, query, errMsg, class, time.Now().UTC(), id).Scan(&retryCount); err != nil {
+		return 0, fmt.Errorf("repository.job: increment retry: %w", err)
+	}
+	return retryCount, nil
+}
+
+func (r *SQLiteJobRepository) ScheduleRetry(ctx context.Context, id string, at time.Time) error {
+	const query = `
+		UPDATE webhook_jobs
+		SET status = ?, next_attempt_at = ?, updated_at = ?
+		WHERE id = ? AND status = ?
+	`
+	_, err := r.db.ExecContext(ctx, query, model.StatusPending, at.UTC(), time.Now().UTC(), id, model.StatusProcessing)
+	if err != nil {
+		return fmt.Errorf("repository.job: schedule retry: %w", err)
+	}
+	return nil
+}
+
+func (r *SQLiteJobRepository) NextAttempt(ctx context.Context, id string) (time.Time, error) {
+	var at sql.NullTime
+	err := r.db.QueryRowContext(ctx, `SELECT next_attempt_at FROM webhook_jobs WHERE id = ?`, id).Scan(&at)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("repository.job: next attempt: %w", err)
+	}
+	return at.Time, nil
+}
+
+func (r *SQLiteJobRepository) Preceding(ctx context.Context, job *model.WebhookJob) (time.Time, bool, error) {
+	const query = `
+		SELECT next_attempt_at
+		FROM webhook_jobs
+		WHERE client_url = ? AND ordering_key = ?
+		  AND status IN ('pending', 'processing')
+		  AND (created_at, id) < (?, ?)
+		ORDER BY created_at, id
+		LIMIT 1
+	`
+	var due sql.NullTime
+	err := r.db.QueryRowContext(ctx, query, job.ClientURL, job.OrderingKey, job.CreatedAt.UTC(), job.ID).Scan(&due)
+	if errors.Is(err, sql.ErrNoRows) {
+		return time.Time{}, false, nil
+	}
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("repository.job: preceding: %w", err)
+	}
+	return due.Time, true, nil
+}
+
+func (r *SQLiteJobRepository) Status(ctx context.Context, id string) (model.JobStatus, error) {
+	var status model.JobStatus
+	err := r.db.QueryRowContext(ctx, `SELECT status FROM webhook_jobs WHERE id = ?`, id).Scan(&status)
+	if err != nil {
+		return "", fmt.Errorf("repository.job: status: %w", err)
+	}
+	return status, nil
+}
+
+func (r *SQLiteJobRepository) RecordAttempt(ctx context.Context, attempt *model.Attempt) error {
+	const query = `
+		INSERT INTO webhook_attempts (
+			job_id, attempt, started_at, duration_ms, status_code,
+			response_headers, response_body, error, error_class
+		)
+		SELECT ?1, MAX(COALESCE(MAX(attempt), 0), ?9) + 1, ?2, ?3, ?4, ?5, ?6, ?7, ?8
+		FROM webhook_attempts
+		WHERE job_id = ?1
+		RETURNING attempt
+	`
+	headers, err := json.Marshal(attempt.ResponseHeaders)
+	if err != nil {
+		return fmt.Errorf("repository.job: marshal attempt headers: %w", err)
+	}
+
+	if err := r.db.QueryRowContext(
+		ctx,
+		query,
+		attempt.JobID,
+		attempt.StartedAt.UTC(),
+		attempt.Duration.Milliseconds(),
+		attempt.StatusCode,
+		string(headers),
+		attempt.ResponseBody,
+		attempt.Error,
+		attempt.ErrorClass,
+		attempt.Number,
+	).Scan(&attempt.Number); err != nil {
+		return fmt.Errorf("repository.job: record attempt: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package retention
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	"github.com/Bharat1Rajput/workerService/internal/model"
+)
+
+// Archiver writes each purged batch to its own gzip-compressed NDJSON file.
+type Archiver struct {
+	dir string
+	seq atomic.Uint64
+}
+
+func NewArchiver(dir string) (*Archiver, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("retention: create archive dir: %w", err)
+	}
+	return &Archiver{dir: dir}, nil
+}
+
+// Write stores jobs and only returns once the file is flushed to disk, so the
+// caller can safely delete the rows afterwards.
+func (a *Archiver) Write(jobs []model.WebhookJob) error {
+	name := fmt.Sprintf("webhook_jobs-%s-%06d.ndjson.gz",
+		time.Now().UTC().Format("20060102T150405Z"),
+		a.seq.Add(1),
+	)
+	path := filepath.Join(a.dir, name)
+	tmp := path + ".tmp"
+
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o640)
+	if err != nil {
+		return fmt.Errorf("retention: create archive: %w", err)
+	}
+	defer os.Remove(tmp)
+
+	zw := gzip.NewWriter(f)
+	enc := json.NewEncoder(zw)
+	for i := range jobs {
+		if err := enc.Encode(&jobs[i]); err != nil {
+			f.Close()
+			return fmt.Errorf("retention: encode archive: %w", err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		f.Close()
+		return fmt.Errorf("retention: flush archive: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return fmt.Errorf("retention: sync archive: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("retention: close archive: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("retention: finalize archive: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package retention
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+)
+
+// Window is how long terminal jobs of each status are kept. A zero value
+// keeps jobs of that status forever.
+type Window struct {
+	SuccessDays int `json:"success_days"`
+	FailedDays  int `json:"failed_days"`
+}
+
+func (w Window) success() time.Duration { return days(w.SuccessDays) }
+func (w Window) failed() time.Duration  { return days(w.FailedDays) }
+
+func days(n int) time.Duration {
+	return time.Duration(n) * 24 * time.Hour
+}
+
+// Policy is the default retention window plus per-tenant overrides.
+type Policy struct {
+	Default Window
+	Tenants map[string]Window
+}
+
+// LoadPolicy builds a Policy from the default window and an optional JSON
+// file mapping tenant IDs to windows, e.g. {"acme": {"success_days": 30}}.
+// Fields left out of an override inherit the default.
+func LoadPolicy(def Window, overridesFile string) (*Policy, error) {
+	p := &Policy{Default: def, Tenants: map[string]Window{}}
+	if overridesFile == "" {
+		return p, nil
+	}
+
+	data, err := os.ReadFile(overridesFile)
+	if err != nil {
+		return nil, fmt.Errorf("retention: read overrides: %w", err)
+	}
+
+	var raw map[string]struct {
+		SuccessDays *int `json:"success_days"`
+		FailedDays  *int `json:"failed_days"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("retention: decode overrides: %w", err)
+	}
+
+	for tenant, o := range raw {
+		if tenant == "" {
+			return nil, fmt.Errorf("retention: override for empty tenant id")
+		}
+		w := def
+		if o.SuccessDays != nil {
+			w.SuccessDays = *o.SuccessDays
+		}
+		if o.FailedDays != nil {
+			w.FailedDays = *o.FailedDays
+		}
+		p.Tenants[tenant] = w
+	}
+	return p, nil
+}
+
+func (p *Policy) tenantIDs() []string {
+	ids := make([]string, 0, len(p.Tenants))
+	for id := range p.Tenants {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
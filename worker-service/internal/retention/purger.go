@@ -0,0 +1,133 @@
+package retention
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Bharat1Rajput/blob"
+	"github.com/Bharat1Rajput/workerService/internal/model"
+	"github.com/Bharat1Rajput/workerService/internal/repository"
+)
+
+// Purger periodically deletes terminal jobs that have outlived their
+// retention window, in batches of at most batchSize rows.
+type Purger struct {
+	repo      repository.RetentionRepository
+	blobs     blob.Store
+	policy    *Policy
+	archiver  *Archiver
+	interval  time.Duration
+	batchSize int
+	logger    *zap.Logger
+}
+
+// NewPurger builds a Purger. archiver may be nil to delete without archiving,
+// and blobs may be nil when payload offloading is disabled.
+func NewPurger(repo repository.RetentionRepository, blobs blob.Store, policy *Policy, archiver *Archiver, interval time.Duration, batchSize int, logger *zap.Logger) *Purger {
+	return &Purger{
+		repo:      repo,
+		blobs:     blobs,
+		policy:    policy,
+		archiver:  archiver,
+		interval:  interval,
+		batchSize: batchSize,
+		logger:    logger,
+	}
+}
+
+// Run purges once immediately and then every interval until ctx is canceled.
+func (p *Purger) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		p.PurgeOnce(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// PurgeOnce runs a full pass over every tenant and status.
+func (p *Purger) PurgeOnce(ctx context.Context) {
+	now := time.Now().UTC()
+	overridden := p.policy.tenantIDs()
+
+	for _, tenant := range overridden {
+		p.purgeWindow(ctx, now, p.policy.Tenants[tenant], repository.PurgeFilter{TenantID: tenant})
+	}
+	p.purgeWindow(ctx, now, p.policy.Default, repository.PurgeFilter{ExcludeTenants: overridden})
+}
+
+func (p *Purger) purgeWindow(ctx context.Context, now time.Time, w Window, base repository.PurgeFilter) {
+	for status, keep := range map[model.JobStatus]time.Duration{
+		model.StatusSuccess:   w.success(),
+		model.StatusFailed:    w.failed(),
+		model.StatusExpired:   w.failed(),
+		model.StatusCancelled: w.failed(),
+	} {
+		if keep <= 0 {
+			continue
+		}
+		filter := base
+		filter.Status = status
+		filter.Before = now.Add(-keep)
+		p.purge(ctx, filter)
+	}
+}
+
+func (p *Purger) purge(ctx context.Context, filter repository.PurgeFilter) {
+	var archive func([]model.WebhookJob) error
+	if p.archiver != nil {
+		archive = p.archiver.Write
+	}
+
+	total := 0
+	for ctx.Err() == nil {
+		jobs, err := p.repo.PurgeBatch(ctx, filter, p.batchSize, archive)
+		if err != nil {
+			p.logger.Error("retention: purge batch",
+				zap.String("tenant_id", filter.TenantID),
+				zap.String("status", string(filter.Status)),
+				zap.Error(err),
+			)
+			return
+		}
+		p.deleteBlobs(ctx, jobs)
+		total += len(jobs)
+		if len(jobs) < p.batchSize {
+			break
+		}
+	}
+
+	if total > 0 {
+		p.logger.Info("retention: purged jobs",
+			zap.String("tenant_id", filter.TenantID),
+			zap.String("status", string(filter.Status)),
+			zap.Int("count", total),
+		)
+	}
+}
+
+// deleteBlobs removes offloaded payloads of purged jobs. Failures only leave
+// an orphaned blob behind, so they are logged and skipped.
+func (p *Purger) deleteBlobs(ctx context.Context, jobs []model.WebhookJob) {
+	if p.blobs == nil {
+		return
+	}
+	for _, job := range jobs {
+		if job.PayloadRef == nil {
+			continue
+		}
+		if err := p.blobs.Delete(ctx, job.PayloadRef.Key); err != nil {
+			p.logger.Warn("retention: delete payload blob",
+				zap.String("job_id", job.ID),
+				zap.Error(err),
+			)
+		}
+	}
+}
@@ -0,0 +1,209 @@
+package retry
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"os"
+	"time"
+)
+
+type Strategy string
+
+const (
+	StrategyExponential Strategy = "exponential"
+	StrategyLinear      Strategy = "linear"
+	StrategyFixed       Strategy = "fixed"
+)
+
+type Jitter string
+
+const (
+	JitterNone  Jitter = "none"
+	JitterFull  Jitter = "full"
+	JitterEqual Jitter = "equal"
+)
+
+// maxBackoff keeps exponential doubling from overflowing time.Duration.
+const maxBackoff = time.Duration(math.MaxInt64 / 2)
+
+// Policy describes how often and for how long a failed job is retried.
+//
+// MaxAttempts bounds the total number of delivery attempts; for a fixed
+// schedule it defaults to one attempt plus one retry per schedule entry.
+// MaxAge, when set, stops retrying once the next attempt would land later
+// than the job's creation time plus MaxAge.
+type Policy struct {
+	Name        string     `json:"name"`
+	Strategy    Strategy   `json:"strategy"`
+	MaxAttempts int        `json:"max_attempts"`
+	Base        Duration   `json:"base"`
+	Max         Duration   `json:"max"`
+	Schedule    []Duration `json:"schedule"`
+	Jitter      Jitter     `json:"jitter"`
+	MaxAge      Duration   `json:"max_age"`
+}
+
+// Backoff returns the delay before retry number n (1-based).
+func (p *Policy) Backoff(n int) time.Duration {
+	if n < 1 {
+		n = 1
+	}
+
+	var d time.Duration
+	switch p.Strategy {
+	case StrategyFixed:
+		if len(p.Schedule) == 0 {
+			return 0
+		}
+		if n > len(p.Schedule) {
+			n = len(p.Schedule)
+		}
+		d = p.Schedule[n-1].Duration()
+	case StrategyLinear:
+		d = p.Base.Duration() * time.Duration(n)
+	default:
+		d = p.Base.Duration()
+		for i := 1; i < n && d < maxBackoff && (p.Max == 0 || d < p.Max.Duration()); i++ {
+			d *= 2
+		}
+	}
+
+	if p.Max > 0 && d > p.Max.Duration() {
+		d = p.Max.Duration()
+	}
+	return p.jitter(d)
+}
+
+// Exhausted reports whether failed attempts have used up the policy, or
+// whether waiting another backoff would exceed MaxAge for a job created at createdAt.
+func (p *Policy) Exhausted(failed int, createdAt time.Time, backoff time.Duration, now time.Time) bool {
+	if failed >= p.MaxAttempts {
+		return true
+	}
+	if p.MaxAge > 0 && now.Add(backoff).Sub(createdAt) > p.MaxAge.Duration() {
+		return true
+	}
+	return false
+}
+
+func (p *Policy) jitter(d time.Duration) time.Duration {
+	if d <= 0 {
+		return d
+	}
+	switch p.Jitter {
+	case JitterFull:
+		return time.Duration(rand.Int64N(int64(d)))
+	case JitterEqual:
+		half := d / 2
+		return half + time.Duration(rand.Int64N(int64(d-half)))
+	default:
+		return d
+	}
+}
+
+func (p *Policy) validate() error {
+	if p.Name == "" {
+		return fmt.Errorf("retry: policy name is required")
+	}
+	switch p.Strategy {
+	case StrategyExponential, StrategyLinear:
+		if p.Base <= 0 {
+			return fmt.Errorf("retry: policy %q: base must be positive", p.Name)
+		}
+	case StrategyFixed:
+		if len(p.Schedule) == 0 {
+			return fmt.Errorf("retry: policy %q: fixed strategy needs a schedule", p.Name)
+		}
+		if p.MaxAttempts == 0 {
+			p.MaxAttempts = len(p.Schedule) + 1
+		}
+	default:
+		return fmt.Errorf("retry: policy %q: unknown strategy %q", p.Name, p.Strategy)
+	}
+	switch p.Jitter {
+	case "", JitterNone, JitterFull, JitterEqual:
+	default:
+		return fmt.Errorf("retry: policy %q: unknown jitter %q", p.Name, p.Jitter)
+	}
+	if p.MaxAttempts < 1 {
+		return fmt.Errorf("retry: policy %q: max_attempts must be at least 1", p.Name)
+	}
+	return nil
+}
+
+// DefaultName is the policy used when neither the job nor its endpoint picks one.
+const DefaultName = "default"
+
+// DefaultPolicy is the exponential policy driven by MAX_RETRIES and BACKOFF_BASE_MS.
+func DefaultPolicy(maxAttempts int, base time.Duration) *Policy {
+	return &Policy{
+		Name:        DefaultName,
+		Strategy:    StrategyExponential,
+		MaxAttempts: maxAttempts,
+		Base:        Duration(base),
+		Max:         Duration(5 * time.Minute),
+		Jitter:      JitterNone,
+	}
+}
+
+// Registry holds the named policies available to jobs and endpoints.
+type Registry struct {
+	policies map[string]*Policy
+	fallback *Policy
+}
+
+// NewRegistry builds a registry whose Default is fallback.
+func NewRegistry(fallback *Policy) *Registry {
+	return &Registry{
+		policies: map[string]*Policy{fallback.Name: fallback},
+		fallback: fallback,
+	}
+}
+
+// Load reads a JSON array of policies from path into a registry built around
+// fallback. An empty path yields a registry containing only fallback.
+func Load(path string, fallback *Policy) (*Registry, error) {
+	r := NewRegistry(fallback)
+	if path == "" {
+		return r, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("retry: read policies: %w", err)
+	}
+
+	var policies []*Policy
+	if err := json.Unmarshal(data, &policies); err != nil {
+		return nil, fmt.Errorf("retry: decode policies: %w", err)
+	}
+	for _, p := range policies {
+		if err := r.Add(p); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// Add validates and registers p, replacing any policy with the same name.
+func (r *Registry) Add(p *Policy) error {
+	if err := p.validate(); err != nil {
+		return err
+	}
+	r.policies[p.Name] = p
+	if p.Name == r.fallback.Name {
+		r.fallback = p
+	}
+	return nil
+}
+
+func (r *Registry) Get(name string) (*Policy, bool) {
+	p, ok := r.policies[name]
+	return p, ok
+}
+
+func (r *Registry) Default() *Policy {
+	return r.fallback
+}
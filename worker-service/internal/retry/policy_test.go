@@ -0,0 +1,206 @@
+package retry
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func seconds(s ...int) []Duration {
+	out := make([]Duration, len(s))
+	for i, n := range s {
+		out[i] = Duration(time.Duration(n) * time.Second)
+	}
+	return out
+}
+
+func TestBackoff(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		policy Policy
+		want   []time.Duration // for retries 1, 2, ...
+	}{
+		{
+			"exponential",
+			Policy{Strategy: StrategyExponential, Base: Duration(time.Second)},
+			[]time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second},
+		},
+		{
+			"exponential capped",
+			Policy{Strategy: StrategyExponential, Base: Duration(time.Second), Max: Duration(5 * time.Second)},
+			[]time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second},
+		},
+		{
+			"linear",
+			Policy{Strategy: StrategyLinear, Base: Duration(10 * time.Second)},
+			[]time.Duration{10 * time.Second, 20 * time.Second, 30 * time.Second},
+		},
+		{
+			"linear capped",
+			Policy{Strategy: StrategyLinear, Base: Duration(10 * time.Second), Max: Duration(15 * time.Second)},
+			[]time.Duration{10 * time.Second, 15 * time.Second, 15 * time.Second},
+		},
+		{
+			"fixed repeats its last entry",
+			Policy{Strategy: StrategyFixed, Schedule: seconds(30, 300, 3600)},
+			[]time.Duration{30 * time.Second, 5 * time.Minute, time.Hour, time.Hour},
+		},
+	} {
+		for i, want := range tc.want {
+			if got := tc.policy.Backoff(i + 1); got != want {
+				t.Errorf("%s: Backoff(%d) = %v, want %v", tc.name, i+1, got, want)
+			}
+		}
+	}
+
+	p := Policy{Strategy: StrategyExponential, Base: Duration(time.Second)}
+	if got := p.Backoff(0); got != time.Second {
+		t.Errorf("Backoff(0) = %v, want the first retry's %v", got, time.Second)
+	}
+	if got := p.Backoff(1000); got <= 0 {
+		t.Errorf("Backoff(1000) = %v, want it not to overflow", got)
+	}
+}
+
+func TestJitterBounds(t *testing.T) {
+	const base = time.Second
+	for _, tc := range []struct {
+		jitter   Jitter
+		min, max time.Duration // inclusive, exclusive
+	}{
+		{JitterNone, base, base + 1},
+		{"", base, base + 1},
+		{JitterFull, 0, base},
+		{JitterEqual, base / 2, base},
+	} {
+		p := Policy{Strategy: StrategyFixed, Schedule: []Duration{Duration(base)}, Jitter: tc.jitter}
+		for i := 0; i < 1000; i++ {
+			if got := p.Backoff(1); got < tc.min || got >= tc.max {
+				t.Fatalf("jitter %q: Backoff = %v, want in [%v, %v)", tc.jitter, got, tc.min, tc.max)
+			}
+		}
+	}
+}
+
+func TestExhausted(t *testing.T) {
+	created := time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)
+	for _, tc := range []struct {
+		name    string
+		policy  Policy
+		failed  int
+		backoff time.Duration
+		now     time.Time
+		want    bool
+	}{
+		{"attempts left", Policy{MaxAttempts: 3}, 2, time.Second, created, false},
+		{"attempts used up", Policy{MaxAttempts: 3}, 3, time.Second, created, true},
+		{"no max age", Policy{MaxAttempts: 100}, 1, 24 * time.Hour, created.Add(24 * time.Hour), false},
+		{"within max age", Policy{MaxAttempts: 100, MaxAge: Duration(time.Hour)}, 1, 10 * time.Minute, created.Add(50 * time.Minute), false},
+		{"next attempt at max age", Policy{MaxAttempts: 100, MaxAge: Duration(time.Hour)}, 1, 10 * time.Minute, created.Add(50*time.Minute + time.Nanosecond), true},
+		{"past max age", Policy{MaxAttempts: 100, MaxAge: Duration(time.Hour)}, 1, 0, created.Add(2 * time.Hour), true},
+	} {
+		if got := tc.policy.Exhausted(tc.failed, created, tc.backoff, tc.now); got != tc.want {
+			t.Errorf("%s: Exhausted = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func writePolicies(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "policies.json")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("write policies: %v", err)
+	}
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	fallback := DefaultPolicy(5, time.Second)
+	path := writePolicies(t, `[
+		{"name": "payments", "strategy": "exponential", "max_attempts": 10, "base": "2s", "max": "1h", "jitter": "full", "max_age": "24h"},
+		{"name": "bulk", "strategy": "linear", "max_attempts": 4, "base": "1m"},
+		{"name": "partner", "strategy": "fixed", "schedule": ["30s", "5m", "1h"]}
+	]`)
+	r, err := Load(path, fallback)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	if r.Default() != fallback {
+		t.Errorf("Default() = %+v, want the fallback", r.Default())
+	}
+	payments, ok := r.Get("payments")
+	if !ok || payments.Strategy != StrategyExponential || payments.Max.Duration() != time.Hour ||
+		payments.Jitter != JitterFull || payments.MaxAge.Duration() != 24*time.Hour {
+		t.Errorf("payments: got %+v", payments)
+	}
+	bulk, ok := r.Get("bulk")
+	if !ok || bulk.Strategy != StrategyLinear || bulk.Base.Duration() != time.Minute || bulk.MaxAttempts != 4 {
+		t.Errorf("bulk: got %+v", bulk)
+	}
+	partner, ok := r.Get("partner")
+	if !ok || partner.Strategy != StrategyFixed || len(partner.Schedule) != 3 {
+		t.Errorf("partner: got %+v", partner)
+	}
+	if partner.MaxAttempts != 4 {
+		t.Errorf("partner: max_attempts defaulted to %d, want one attempt plus one per schedule entry", partner.MaxAttempts)
+	}
+	if _, ok := r.Get("missing"); ok {
+		t.Error(`Get("missing") found a policy`)
+	}
+}
+
+func TestLoadReplacesDefault(t *testing.T) {
+	path := writePolicies(t, `[{"name": "default", "strategy": "linear", "max_attempts": 2, "base": "1s"}]`)
+	r, err := Load(path, DefaultPolicy(5, time.Second))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if r.Default().Strategy != StrategyLinear {
+		t.Errorf("Default() = %+v, want the policy from the file", r.Default())
+	}
+}
+
+func TestLoadWithoutFile(t *testing.T) {
+	fallback := DefaultPolicy(5, time.Second)
+	r, err := Load("", fallback)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if p, ok := r.Get(DefaultName); !ok || p != fallback {
+		t.Errorf("Get(%q) = %+v, %v; want the fallback", DefaultName, p, ok)
+	}
+}
+
+func TestLoadRejectsMalformed(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"not json", `{"name": `, "decode policies"},
+		{"not an array", `{"name": "x"}`, "decode policies"},
+		{"bad duration", `[{"name": "x", "strategy": "linear", "max_attempts": 3, "base": "soon"}]`, "parse duration"},
+		{"numeric duration", `[{"name": "x", "strategy": "linear", "max_attempts": 3, "base": 30}]`, "must be a string"},
+		{"no name", `[{"strategy": "linear", "max_attempts": 3, "base": "1s"}]`, "name is required"},
+		{"unknown strategy", `[{"name": "x", "strategy": "random", "max_attempts": 3, "base": "1s"}]`, "unknown strategy"},
+		{"unknown jitter", `[{"name": "x", "strategy": "linear", "max_attempts": 3, "base": "1s", "jitter": "some"}]`, "unknown jitter"},
+		{"exponential without base", `[{"name": "x", "strategy": "exponential", "max_attempts": 3}]`, "base must be positive"},
+		{"linear with negative base", `[{"name": "x", "strategy": "linear", "max_attempts": 3, "base": "-1s"}]`, "base must be positive"},
+		{"fixed without schedule", `[{"name": "x", "strategy": "fixed", "max_attempts": 3}]`, "needs a schedule"},
+		{"exponential without max_attempts", `[{"name": "x", "strategy": "exponential", "base": "1s"}]`, "max_attempts"},
+		{"linear with zero max_attempts", `[{"name": "x", "strategy": "linear", "max_attempts": 0, "base": "1s"}]`, "max_attempts"},
+		{"fixed with negative max_attempts", `[{"name": "x", "strategy": "fixed", "max_attempts": -1, "schedule": ["1s"]}]`, "max_attempts"},
+	} {
+		_, err := Load(writePolicies(t, tc.content), DefaultPolicy(5, time.Second))
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%s: Load error = %v, want one containing %q", tc.name, err, tc.want)
+		}
+	}
+
+	if _, err := Load(filepath.Join(t.TempDir(), "missing.json"), DefaultPolicy(5, time.Second)); err == nil {
+		t.Error("Load of a missing file: want an error")
+	}
+}
@@ -0,0 +1,63 @@
+package transform
+
+import (
+	"strconv"
+	"strings"
+)
+
+// The mapping DSL is a JSON document describing the output shape. Objects and
+// arrays are copied structurally; a string value of "$" or starting with "$."
+// is replaced by the payload value at that dotted path ("$.order.items.0.sku"),
+// or null if the path does not exist. A leading "$$" escapes a literal "$".
+// Every other value is emitted as-is.
+func applyMapping(mapping, data any) any {
+	switch m := mapping.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(m))
+		for k, v := range m {
+			out[k] = applyMapping(v, data)
+		}
+		return out
+	case []any:
+		out := make([]any, len(m))
+		for i, v := range m {
+			out[i] = applyMapping(v, data)
+		}
+		return out
+	case string:
+		switch {
+		case strings.HasPrefix(m, "$$"):
+			return m[1:]
+		case m == "$":
+			return data
+		case strings.HasPrefix(m, "$."):
+			return lookup(data, strings.Split(m[2:], "."))
+		}
+		return m
+	default:
+		return m
+	}
+}
+
+func lookup(data any, path []string) any {
+	cur := data
+	for _, seg := range path {
+		switch node := cur.(type) {
+		case map[string]any:
+			v, ok := node[seg]
+			if !ok {
+				return nil
+			}
+			cur = v
+		case []any:
+			i, err := strconv.Atoi(seg)
+			if err != nil || i < 0 || i >= len(node) {
+				return nil
+			}
+			cur = node[i]
+		default:
+			return nil
+		}
+	}
+	return cur
+}
@@ -0,0 +1,96 @@
+package transform
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"text/template"
+
+	"github.com/Bharat1Rajput/workerService/internal/model"
+)
+
+const (
+	TypeTemplate = "template"
+	TypeMapping  = "mapping"
+)
+
+// Spec is how an endpoint reshapes payloads before delivery: either a Go
+// text/template rendered with the decoded payload as its data, or a JSON
+// mapping (see mapping.go). ContentType sets the delivered Content-Type and
+// defaults to application/json.
+type Spec struct {
+	Type        string          `json:"type"`
+	Template    string          `json:"template,omitempty"`
+	Mapping     json.RawMessage `json:"mapping,omitempty"`
+	ContentType string          `json:"content_type,omitempty"`
+}
+
+// Transform is a compiled Spec, safe for concurrent use.
+type Transform struct {
+	contentType string
+	tmpl        *template.Template
+	mapping     any
+}
+
+func Compile(spec *Spec) (*Transform, error) {
+	t := &Transform{contentType: spec.ContentType}
+	if t.contentType == "" {
+		t.contentType = model.ContentTypeJSON
+	}
+
+	switch spec.Type {
+	case TypeTemplate:
+		tmpl, err := template.New("transform").
+			Option("missingkey=zero").
+			Funcs(template.FuncMap{"json": toJSON}).
+			Parse(spec.Template)
+		if err != nil {
+			return nil, fmt.Errorf("transform: parse template: %w", err)
+		}
+		t.tmpl = tmpl
+	case TypeMapping:
+		if err := json.Unmarshal(spec.Mapping, &t.mapping); err != nil {
+			return nil, fmt.Errorf("transform: decode mapping: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("transform: unknown type %q", spec.Type)
+	}
+	return t, nil
+}
+
+func (t *Transform) ContentType() string {
+	return t.contentType
+}
+
+// Apply reshapes a JSON payload and returns the body to deliver.
+func (t *Transform) Apply(payload []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(payload))
+	dec.UseNumber()
+	var data any
+	if err := dec.Decode(&data); err != nil {
+		return nil, fmt.Errorf("transform: payload is not JSON: %w", err)
+	}
+
+	if t.tmpl != nil {
+		var buf bytes.Buffer
+		if err := t.tmpl.Execute(&buf, data); err != nil {
+			return nil, fmt.Errorf("transform: execute template: %w", err)
+		}
+		return buf.Bytes(), nil
+	}
+
+	out, err := json.Marshal(applyMapping(t.mapping, data))
+	if err != nil {
+		return nil, fmt.Errorf("transform: encode mapping: %w", err)
+	}
+	return out, nil
+}
+
+// toJSON lets templates embed values safely, e.g. {"text": {{json .message}}}.
+func toJSON(v any) (string, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
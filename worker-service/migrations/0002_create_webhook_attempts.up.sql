@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS webhook_attempts (
+    id               BIGSERIAL   PRIMARY KEY,
+    job_id           TEXT        NOT NULL REFERENCES webhook_jobs(id) ON DELETE CASCADE,
+    attempt          INTEGER     NOT NULL,
+    started_at       TIMESTAMPTZ NOT NULL,
+    duration_ms      BIGINT      NOT NULL DEFAULT 0,
+    status_code      INTEGER     NOT NULL DEFAULT 0,
+    response_headers JSONB       NOT NULL DEFAULT '{}',
+    response_body    TEXT        NOT NULL DEFAULT '',
+    error            TEXT        NOT NULL DEFAULT '',
+    error_class      TEXT        NOT NULL DEFAULT '',
+    UNIQUE (job_id, attempt)
+);
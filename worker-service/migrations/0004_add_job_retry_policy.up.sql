@@ -0,0 +1,2 @@
+ALTER TABLE webhook_jobs
+    ADD COLUMN IF NOT EXISTS retry_policy TEXT NOT NULL DEFAULT '';
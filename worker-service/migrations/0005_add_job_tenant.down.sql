@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_webhook_jobs_status_updated_at;
+
+ALTER TABLE webhook_jobs
+    DROP COLUMN IF EXISTS tenant_id;
@@ -0,0 +1,5 @@
+ALTER TABLE webhook_jobs
+    ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_webhook_jobs_status_updated_at
+    ON webhook_jobs (status, updated_at);
@@ -0,0 +1,7 @@
+ALTER TABLE webhook_jobs
+    ALTER COLUMN payload DROP DEFAULT;
+
+ALTER TABLE webhook_jobs
+    DROP COLUMN IF EXISTS content_type,
+    DROP COLUMN IF EXISTS payload_bytes,
+    DROP COLUMN IF EXISTS payload_json;
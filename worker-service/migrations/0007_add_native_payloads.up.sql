@@ -0,0 +1,7 @@
+ALTER TABLE webhook_jobs
+    ADD COLUMN IF NOT EXISTS payload_json  JSONB,
+    ADD COLUMN IF NOT EXISTS payload_bytes BYTEA,
+    ADD COLUMN IF NOT EXISTS content_type  TEXT NOT NULL DEFAULT 'application/json';
+
+ALTER TABLE webhook_jobs
+    ALTER COLUMN payload SET DEFAULT '';
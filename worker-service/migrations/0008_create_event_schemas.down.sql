@@ -0,0 +1,5 @@
+ALTER TABLE webhook_jobs
+    DROP COLUMN IF EXISTS schema_version,
+    DROP COLUMN IF EXISTS event_type;
+
+DROP TABLE IF EXISTS event_schemas;
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS event_schemas (
+    event_type    TEXT        NOT NULL,
+    version       INTEGER     NOT NULL,
+    schema        JSONB       NOT NULL,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    deprecated_at TIMESTAMPTZ,
+    PRIMARY KEY (event_type, version)
+);
+
+ALTER TABLE webhook_jobs
+    ADD COLUMN IF NOT EXISTS event_type     TEXT    NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS schema_version INTEGER NOT NULL DEFAULT 0;
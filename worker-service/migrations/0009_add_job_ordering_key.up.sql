@@ -0,0 +1,2 @@
+ALTER TABLE webhook_jobs
+    ADD COLUMN IF NOT EXISTS ordering_key TEXT NOT NULL DEFAULT '';
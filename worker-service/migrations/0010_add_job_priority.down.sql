@@ -0,0 +1,2 @@
+ALTER TABLE webhook_jobs
+    DROP COLUMN IF EXISTS priority;
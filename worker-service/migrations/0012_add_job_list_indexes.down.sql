@@ -0,0 +1,6 @@
+-- migrate:no-transaction
+DROP INDEX CONCURRENTLY IF EXISTS idx_webhook_jobs_error_trgm;
+DROP INDEX CONCURRENTLY IF EXISTS idx_webhook_jobs_host_created_id;
+DROP INDEX CONCURRENTLY IF EXISTS idx_webhook_jobs_tenant_created_id;
+DROP INDEX CONCURRENTLY IF EXISTS idx_webhook_jobs_status_created_id;
+DROP INDEX CONCURRENTLY IF EXISTS idx_webhook_jobs_created_id;
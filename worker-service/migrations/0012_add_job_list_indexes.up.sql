@@ -0,0 +1,21 @@
+-- migrate:no-transaction
+-- Indexes behind GET /webhooks: newest-first keyset pagination on
+-- (created_at, id), optionally narrowed by status, tenant or client_url
+-- host, plus trigram search on the last error. Built concurrently so large
+-- tables stay writable; the host expression must match the API's query.
+CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_webhook_jobs_created_id
+    ON webhook_jobs (created_at DESC, id DESC);
+
+CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_webhook_jobs_status_created_id
+    ON webhook_jobs (status, created_at DESC, id DESC);
+
+CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_webhook_jobs_tenant_created_id
+    ON webhook_jobs (tenant_id, created_at DESC, id DESC);
+
+CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_webhook_jobs_host_created_id
+    ON webhook_jobs ((lower(substring(client_url from '^[A-Za-z][A-Za-z0-9+.-]*://(?:[^@/]*@)?([^/:?#]+)'))), created_at DESC, id DESC);
+
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_webhook_jobs_error_trgm
+    ON webhook_jobs USING gin (error gin_trgm_ops);
@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS webhook_jobs_notify_update ON webhook_jobs;
+DROP TRIGGER IF EXISTS webhook_jobs_notify_insert ON webhook_jobs;
+DROP FUNCTION IF EXISTS notify_webhook_job_event();
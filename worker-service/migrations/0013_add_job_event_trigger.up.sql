@@ -0,0 +1,29 @@
+-- Publishes job status transitions on the webhook_job_events channel for
+-- api-service's SSE streams. NOTIFY payloads are capped at 8000 bytes, so
+-- the error is truncated.
+CREATE OR REPLACE FUNCTION notify_webhook_job_event() RETURNS trigger AS $$
+BEGIN
+    PERFORM pg_notify('webhook_job_events', json_build_object(
+        'job_id', NEW.id,
+        'tenant_id', NEW.tenant_id,
+        'status', NEW.status,
+        'retry_count', NEW.retry_count,
+        'classification', NEW.classification,
+        'error', left(NEW.error, 1000),
+        'updated_at', NEW.updated_at
+    )::text);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS webhook_jobs_notify_insert ON webhook_jobs;
+CREATE TRIGGER webhook_jobs_notify_insert
+    AFTER INSERT ON webhook_jobs
+    FOR EACH ROW EXECUTE FUNCTION notify_webhook_job_event();
+
+DROP TRIGGER IF EXISTS webhook_jobs_notify_update ON webhook_jobs;
+CREATE TRIGGER webhook_jobs_notify_update
+    AFTER UPDATE OF status, retry_count ON webhook_jobs
+    FOR EACH ROW
+    WHEN (OLD.status IS DISTINCT FROM NEW.status OR OLD.retry_count IS DISTINCT FROM NEW.retry_count)
+    EXECUTE FUNCTION notify_webhook_job_event();
@@ -0,0 +1,2 @@
+ALTER TABLE webhook_jobs
+    ADD COLUMN IF NOT EXISTS status_callback_url TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS job_queue_notify ON job_queue;
+DROP FUNCTION IF EXISTS notify_job_queue();
+DROP TABLE IF EXISTS job_queue;
@@ -0,0 +1,32 @@
+-- Job queue for BROKER=postgres. api-service inserts a row per job; workers
+-- claim rows with FOR UPDATE SKIP LOCKED and hold them until locked_until,
+-- which they keep extending while the job runs. A row whose lock has lapsed
+-- is claimed again by another worker. Rows are deleted once settled.
+CREATE TABLE IF NOT EXISTS job_queue (
+    id              BIGSERIAL PRIMARY KEY,
+    body            BYTEA NOT NULL,
+    priority        SMALLINT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    locked_by       TEXT,
+    locked_until    TIMESTAMPTZ,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_job_queue_next_attempt_at
+    ON job_queue (next_attempt_at);
+
+-- Wakes idle workers listening on job_queue when a row becomes claimable
+-- without waiting for their next poll.
+CREATE OR REPLACE FUNCTION notify_job_queue() RETURNS trigger AS $$
+BEGIN
+    PERFORM pg_notify('job_queue', '');
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS job_queue_notify ON job_queue;
+CREATE TRIGGER job_queue_notify
+    AFTER INSERT OR UPDATE OF locked_by ON job_queue
+    FOR EACH ROW
+    WHEN (NEW.locked_by IS NULL AND NEW.next_attempt_at <= NOW())
+    EXECUTE FUNCTION notify_job_queue();
@@ -0,0 +1,6 @@
+-- migrate:no-transaction
+-- Looks up the unfinished jobs ahead of a job in its ordering key's line,
+-- which the worker checks before every delivery of an ordered job.
+CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_webhook_jobs_ordering_unfinished
+    ON webhook_jobs (client_url, ordering_key, created_at, id)
+    WHERE ordering_key <> '' AND status IN ('pending', 'processing');
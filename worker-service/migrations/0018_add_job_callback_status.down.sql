@@ -0,0 +1,4 @@
+ALTER TABLE webhook_jobs
+    DROP COLUMN IF EXISTS callback_queued_at,
+    DROP COLUMN IF EXISTS callback_attempts,
+    DROP COLUMN IF EXISTS callback_status;
@@ -0,0 +1,6 @@
+-- Status callbacks are queued in the worker's memory. Their state is kept
+-- with the job so that one lost with the queue is found and sent again.
+ALTER TABLE webhook_jobs
+    ADD COLUMN IF NOT EXISTS callback_status    TEXT        NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS callback_attempts  INTEGER     NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS callback_queued_at TIMESTAMPTZ;
@@ -0,0 +1,5 @@
+-- migrate:no-transaction
+-- Finds the pending status callbacks the worker sweeps up.
+CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_webhook_jobs_callback_pending
+    ON webhook_jobs (callback_queued_at)
+    WHERE callback_status = 'pending';
@@ -0,0 +1,28 @@
+// Package migrations embeds the versioned SQL migrations for worker-service.
+//
+// Files are named NNNN_description.up.sql / NNNN_description.down.sql and are
+// applied in version order by internal/migrate.
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS
+
+// SQLiteSchema creates the same tables in SQLite, for dispatchgo. It is not
+// versioned: every statement is safe to run on each start.
+//
+//go:embed sqlite/schema.sql
+var SQLiteSchema string
+
+// SQLiteColumns are the columns added to SQLiteSchema since it was first
+// released. Databases created before then get them with ALTER TABLE, which
+// SQLite cannot make conditional.
+var SQLiteColumns = []struct {
+	Table, Column, Definition string
+}{
+	{"webhook_jobs", "next_attempt_at", "TIMESTAMP"},
+	{"webhook_jobs", "callback_status", "TEXT NOT NULL DEFAULT ''"},
+	{"webhook_jobs", "callback_attempts", "INTEGER NOT NULL DEFAULT 0"},
+	{"webhook_jobs", "callback_queued_at", "TIMESTAMP"},
+}
@@ -0,0 +1,69 @@
+-- SQLite schema for dispatchgo's single-process mode, equivalent to the
+-- Postgres tables after all versioned migrations. Statements are idempotent
+-- and applied on every start; there is no versioning, so a column added here
+-- must also be listed in SQLiteColumns (embed.go) for existing databases.
+--
+-- Times are stored as text in UTC and compare correctly as strings.
+CREATE TABLE IF NOT EXISTS webhook_jobs (
+    id                  TEXT      PRIMARY KEY,
+    tenant_id           TEXT      NOT NULL DEFAULT '',
+    event_type          TEXT      NOT NULL DEFAULT '',
+    schema_version      INTEGER   NOT NULL DEFAULT 0,
+    payload             TEXT      NOT NULL DEFAULT '',
+    payload_json        TEXT,
+    payload_bytes       BLOB,
+    content_type        TEXT      NOT NULL DEFAULT 'application/json',
+    payload_ref         TEXT,
+    client_url          TEXT      NOT NULL,
+    status              TEXT      NOT NULL DEFAULT 'pending',
+    error               TEXT      NOT NULL DEFAULT '',
+    retry_count         INTEGER   NOT NULL DEFAULT 0,
+    classification      TEXT      NOT NULL DEFAULT '',
+    retry_policy        TEXT      NOT NULL DEFAULT '',
+    ordering_key        TEXT      NOT NULL DEFAULT '',
+    priority            INTEGER   NOT NULL DEFAULT 0,
+    expires_at          TIMESTAMP,
+    status_callback_url TEXT      NOT NULL DEFAULT '',
+    next_attempt_at     TIMESTAMP,
+    callback_status     TEXT      NOT NULL DEFAULT '',
+    callback_attempts   INTEGER   NOT NULL DEFAULT 0,
+    callback_queued_at  TIMESTAMP,
+    created_at          TIMESTAMP NOT NULL,
+    updated_at          TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_jobs_created_id
+    ON webhook_jobs (created_at DESC, id DESC);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_jobs_status_created_id
+    ON webhook_jobs (status, created_at DESC, id DESC);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_jobs_tenant_created_id
+    ON webhook_jobs (tenant_id, created_at DESC, id DESC);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_jobs_ordering_unfinished
+    ON webhook_jobs (client_url, ordering_key, created_at, id)
+    WHERE ordering_key <> '' AND status IN ('pending', 'processing');
+
+CREATE TABLE IF NOT EXISTS webhook_attempts (
+    id               INTEGER   PRIMARY KEY,
+    job_id           TEXT      NOT NULL REFERENCES webhook_jobs(id) ON DELETE CASCADE,
+    attempt          INTEGER   NOT NULL,
+    started_at       TIMESTAMP NOT NULL,
+    duration_ms      INTEGER   NOT NULL DEFAULT 0,
+    status_code      INTEGER   NOT NULL DEFAULT 0,
+    response_headers TEXT      NOT NULL DEFAULT '{}',
+    response_body    TEXT      NOT NULL DEFAULT '',
+    error            TEXT      NOT NULL DEFAULT '',
+    error_class      TEXT      NOT NULL DEFAULT '',
+    UNIQUE (job_id, attempt)
+);
+
+CREATE TABLE IF NOT EXISTS event_schemas (
+    event_type    TEXT      NOT NULL,
+    version       INTEGER   NOT NULL,
+    schema        TEXT      NOT NULL,
+    created_at    TIMESTAMP NOT NULL,
+    deprecated_at TIMESTAMP,
+    PRIMARY KEY (event_type, version)
+);
@@ -0,0 +1,296 @@
+// Package worker assembles worker-service's worker pool: the consumer, its
+// processor and the retention purger, plus the admin API. cmd/main.go runs
+// it on its own; dispatchgo runs it next to the HTTP API in one process.
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Bharat1Rajput/blob"
+	"github.com/Bharat1Rajput/broker"
+	"github.com/Bharat1Rajput/ssrf"
+	"github.com/Bharat1Rajput/workerService/internal/admin"
+	"github.com/Bharat1Rajput/workerService/internal/callback"
+	"github.com/Bharat1Rajput/workerService/internal/config"
+	"github.com/Bharat1Rajput/workerService/internal/consumer"
+	"github.com/Bharat1Rajput/workerService/internal/endpoint"
+	"github.com/Bharat1Rajput/workerService/internal/httpclient"
+	"github.com/Bharat1Rajput/workerService/internal/migrate"
+	"github.com/Bharat1Rajput/workerService/internal/processor"
+	"github.com/Bharat1Rajput/workerService/internal/repository"
+	"github.com/Bharat1Rajput/workerService/internal/retention"
+	"github.com/Bharat1Rajput/workerService/internal/retry"
+	"github.com/Bharat1Rajput/workerService/migrations"
+)
+
+// Config is worker-service's configuration, read from the environment.
+type Config = config.Config
+
+// Storage backends for job history, selected with STORAGE.
+const (
+	StoragePostgres = config.StoragePostgres
+	StorageSQLite   = config.StorageSQLite
+)
+
+// LoadConfig reads Config from the environment.
+func LoadConfig() (*Config, error) {
+	return config.Load()
+}
+
+// Storage is where the workers record job history. retention is nil if the
+// backend cannot purge, in which case RETENTION_ENABLED is refused.
+type Storage struct {
+	jobs      repository.JobRepository
+	retention repository.RetentionRepository
+}
+
+// PostgresStorage records job history in the Postgres database at db.
+func PostgresStorage(db *sql.DB) *Storage {
+	repo := repository.NewPostgresJobRepository(db)
+	return &Storage{jobs: repo, retention: repo}
+}
+
+// SQLiteStorage records job history in a dispatchgo SQLite database, which
+// has no retention purger.
+func SQLiteStorage(db *sql.DB) *Storage {
+	return &Storage{jobs: repository.NewSQLiteJobRepository(db)}
+}
+
+// Migrate applies the pending Postgres migrations of migrations.FS to db.
+func Migrate(ctx context.Context, db *sql.DB, logger *zap.Logger) error {
+	runner, err := migrate.New(db, migrations.FS, logger)
+	if err != nil {
+		return err
+	}
+	return runner.Up(ctx)
+}
+
+// Pending status callbacks are looked for every callbackSweepInterval, and
+// sent again once they have waited callbackStaleAfter: long enough to be
+// sure that no worker still has them queued.
+const (
+	callbackSweepInterval = time.Minute
+	callbackStaleAfter    = 10 * time.Minute
+)
+
+// Worker is the worker pool, ready to Run.
+type Worker struct {
+	cfg       *config.Config
+	consumer  *consumer.Consumer
+	processor *processor.Processor
+	callbacks *callback.Notifier
+	purger    *retention.Purger
+	adminSrv  *http.Server
+	logger    *zap.Logger
+}
+
+// New builds the worker pool, processing jobs received from source. The
+// Worker closes source on Close.
+func New(cfg *Config, source broker.Consumer, store *Storage, logger *zap.Logger) (*Worker, error) {
+	policies, err := retry.Load(
+		cfg.RetryPoliciesFile,
+		retry.DefaultPolicy(cfg.MaxRetries, time.Duration(cfg.BackoffBaseMS)*time.Millisecond),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("load retry policies: %w", err)
+	}
+	endpoints, err := endpoint.Load(cfg.EndpointsFile)
+	if err != nil {
+		return nil, fmt.Errorf("load endpoints: %w", err)
+	}
+	for _, ep := range endpoints.All() {
+		if _, ok := policies.Get(ep.RetryPolicy); ep.RetryPolicy != "" && !ok {
+			return nil, fmt.Errorf("endpoint %q references unknown retry policy %q", ep.Name, ep.RetryPolicy)
+		}
+	}
+
+	guard, err := ssrf.New(cfg.SSRFDenyCIDRs, cfg.SSRFAllowCIDRs)
+	if err != nil {
+		return nil, fmt.Errorf("build ssrf guard: %w", err)
+	}
+
+	clients := httpclient.NewPool(
+		time.Duration(cfg.HTTPClientTimeoutSec)*time.Second,
+		guard,
+		time.Duration(cfg.TLSReloadCheckSec)*time.Second,
+		logger,
+	)
+	for _, ep := range endpoints.All() {
+		if _, err := clients.For(ep); err != nil {
+			return nil, fmt.Errorf("invalid tls settings for endpoint %q: %w", ep.Name, err)
+		}
+	}
+
+	blobs, err := blob.Open(cfg.BlobStore, cfg.BlobLocalDir)
+	if err != nil {
+		return nil, fmt.Errorf("open blob store: %w", err)
+	}
+
+	var callbacks *callback.Notifier
+	if cfg.CallbackHMACSecret != "" {
+		callbacks = callback.New(
+			clients.Default(),
+			cfg.CallbackHMACSecret,
+			cfg.CallbackMaxAttempts,
+			time.Duration(cfg.CallbackBackoffMS)*time.Millisecond,
+			cfg.CallbackWorkers,
+			cfg.CallbackQueueSize,
+			store.jobs,
+			logger,
+		)
+	} else {
+		logger.Warn("CALLBACK_HMAC_SECRET not set, status callbacks disabled")
+	}
+
+	var purger *retention.Purger
+	if cfg.RetentionEnabled {
+		if store.retention == nil {
+			return nil, fmt.Errorf("retention is not supported with STORAGE=%s", cfg.Storage)
+		}
+		if purger, err = newPurger(cfg, store.retention, blobs, logger); err != nil {
+			return nil, fmt.Errorf("configure retention: %w", err)
+		}
+	}
+
+	var adminSrv *http.Server
+	if cfg.AdminAddr != "" {
+		adminSrv = &http.Server{
+			Addr:    cfg.AdminAddr,
+			Handler: admin.NewHandler(endpoints, cfg.AdminToken).Routes(),
+		}
+	}
+
+	proc := processor.New(cfg, store.jobs, policies, endpoints, clients, blobs, callbacks, logger)
+	return &Worker{
+		cfg:       cfg,
+		consumer:  consumer.New(cfg, source, proc, logger),
+		processor: proc,
+		callbacks: callbacks,
+		purger:    purger,
+		adminSrv:  adminSrv,
+		logger:    logger,
+	}, nil
+}
+
+// Run consumes jobs until ctx ends, then drains the jobs in flight. It
+// returns early with an error if the consumer or the admin API stops.
+func (w *Worker) Run(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	if w.purger != nil {
+		go w.purger.Run(ctx)
+	}
+	if w.callbacks != nil {
+		go w.resendCallbacks(ctx)
+	}
+
+	consumerDone := make(chan error, 1)
+	go func() {
+		consumerDone <- w.consumer.Start(ctx)
+	}()
+
+	errCh := make(chan error, 1)
+	if w.adminSrv != nil {
+		go func() {
+			w.logger.Info("admin api starting", zap.String("addr", w.adminSrv.Addr))
+			if err := w.adminSrv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				errCh <- err
+			}
+		}()
+	}
+
+	select {
+	case <-ctx.Done():
+		w.logger.Info("worker pool shutting down")
+		if err := <-consumerDone; err != nil {
+			w.logger.Error("consumer stopped with error", zap.Error(err))
+		}
+	case err := <-consumerDone:
+		if err == nil {
+			err = fmt.Errorf("consumer stopped unexpectedly")
+		}
+		return err
+	case err := <-errCh:
+		cancel()
+		<-consumerDone
+		return err
+	}
+
+	if w.adminSrv != nil {
+		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer shutdownCancel()
+		if err := w.adminSrv.Shutdown(shutdownCtx); err != nil {
+			w.logger.Error("admin api shutdown error", zap.Error(err))
+		}
+	}
+	return nil
+}
+
+// resendCallbacks sends the status callbacks left pending, once on start and
+// then every callbackSweepInterval until ctx ends.
+func (w *Worker) resendCallbacks(ctx context.Context) {
+	ticker := time.NewTicker(callbackSweepInterval)
+	defer ticker.Stop()
+
+	for {
+		n, err := w.processor.ResendCallbacks(ctx, time.Now().Add(-callbackStaleAfter))
+		if err != nil && ctx.Err() == nil {
+			w.logger.Error("resending status callbacks failed", zap.Error(err))
+		}
+		if n > 0 {
+			w.logger.Info("resending status callbacks", zap.Int("count", n))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Close delivers the status callbacks still queued, giving up after
+// DrainTimeoutSec, and releases the job source.
+func (w *Worker) Close() error {
+	if w.callbacks != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Duration(w.cfg.DrainTimeoutSec)*time.Second)
+		defer cancel()
+		if err := w.callbacks.Close(ctx); err != nil {
+			w.logger.Warn("status callbacks abandoned on shutdown", zap.Error(err))
+		}
+	}
+	return w.consumer.Close()
+}
+
+func newPurger(cfg *config.Config, repo repository.RetentionRepository, blobs blob.Store, logger *zap.Logger) (*retention.Purger, error) {
+	policy, err := retention.LoadPolicy(
+		retention.Window{SuccessDays: cfg.RetentionSuccessDays, FailedDays: cfg.RetentionFailedDays},
+		cfg.RetentionTenantsFile,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var archiver *retention.Archiver
+	if cfg.RetentionArchiveDir != "" {
+		if archiver, err = retention.NewArchiver(cfg.RetentionArchiveDir); err != nil {
+			return nil, err
+		}
+	}
+
+	return retention.NewPurger(
+		repo,
+		blobs,
+		policy,
+		archiver,
+		time.Duration(cfg.RetentionIntervalSec)*time.Second,
+		cfg.RetentionBatchSize,
+		logger,
+	), nil
+}